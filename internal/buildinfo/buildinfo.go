@@ -0,0 +1,78 @@
+// Package buildinfo 提供服务的版本、提交和构建时间。发布构建通过 -ldflags 注入：
+//
+//	go build -ldflags "-X recipe-agent/internal/buildinfo.Version=2.2.0 \
+//	  -X recipe-agent/internal/buildinfo.Commit=$(git rev-parse --short HEAD) \
+//	  -X recipe-agent/internal/buildinfo.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// 未注入的字段从 debug.ReadBuildInfo 中的模块版本和VCS信息读取
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+	"sync"
+)
+
+// 构建时通过 -ldflags -X 注入的值
+var (
+	Version   string
+	Commit    string
+	BuildTime string
+)
+
+// unknown 无法确定时使用的值
+const unknown = "unknown"
+
+// Info 构建信息
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"buildTime"`
+	GoVersion string `json:"goVersion"`
+}
+
+// Get 返回构建信息，首次调用时读取并缓存
+var Get = sync.OnceValue(func() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+	}
+
+	if build, ok := debug.ReadBuildInfo(); ok {
+		if info.Version == "" && build.Main.Version != "" && build.Main.Version != "(devel)" {
+			info.Version = build.Main.Version
+		}
+		var modified bool
+		for _, setting := range build.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = setting.Value
+				}
+			case "vcs.time":
+				if info.BuildTime == "" {
+					info.BuildTime = setting.Value
+				}
+			case "vcs.modified":
+				modified = setting.Value == "true"
+			}
+		}
+		// 从VCS读取的提交有未提交的修改时标记为 dirty
+		if Commit == "" && info.Commit != "" && modified {
+			info.Commit += "-dirty"
+		}
+	}
+
+	if info.Version == "" {
+		info.Version = "dev"
+	}
+	if info.Commit == "" {
+		info.Commit = unknown
+	}
+	if info.BuildTime == "" {
+		info.BuildTime = unknown
+	}
+	return info
+})
@@ -0,0 +1,528 @@
+// Package config 加载服务配置：内置默认值 → 配置文件（YAML或TOML）→ 环境变量，加载后统一校验
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-yaml"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// redactedValue 打印配置时替换密钥的占位符
+const redactedValue = "******"
+
+// Config 服务配置。字段的 env 标签为覆盖该字段的环境变量，secret 标签标记打印时需要隐藏的密钥
+type Config struct {
+	Server      Server      `yaml:"server" toml:"server"`
+	DeepSeek    DeepSeek    `yaml:"deepseek" toml:"deepseek"`
+	Spoonacular Spoonacular `yaml:"spoonacular" toml:"spoonacular"`
+	Translation Translation `yaml:"translation" toml:"translation"`
+	Data        Data        `yaml:"data" toml:"data"`
+	Jobs        Jobs        `yaml:"jobs" toml:"jobs"`
+	Webhooks    Webhooks    `yaml:"webhooks" toml:"webhooks"`
+	Batch       Batch       `yaml:"batch" toml:"batch"`
+	Admin       Admin       `yaml:"admin" toml:"admin"`
+	Metrics     Metrics     `yaml:"metrics" toml:"metrics"`
+	Tracing     Tracing     `yaml:"tracing" toml:"tracing"`
+	Log         Log         `yaml:"log" toml:"log"`
+	Health      Health      `yaml:"health" toml:"health"`
+	Breaker     Breaker     `yaml:"breaker" toml:"breaker"`
+}
+
+// Server HTTP服务配置
+type Server struct {
+	Port string `yaml:"port" toml:"port" env:"PORT"`
+	// Mode Gin运行模式：debug、release 或 test
+	Mode string `yaml:"mode" toml:"mode" env:"GIN_MODE"`
+	// ReadTimeout 读取完整请求的超时时间
+	ReadTimeout Duration `yaml:"read_timeout" toml:"read_timeout" env:"SERVER_READ_TIMEOUT"`
+	// WriteTimeout 写完响应的超时时间，需大于AI数据源的超时时间
+	WriteTimeout Duration `yaml:"write_timeout" toml:"write_timeout" env:"SERVER_WRITE_TIMEOUT"`
+	// IdleTimeout keep-alive连接的空闲超时时间
+	IdleTimeout Duration `yaml:"idle_timeout" toml:"idle_timeout" env:"SERVER_IDLE_TIMEOUT"`
+	// ShutdownDelay 收到退出信号后先标记为未就绪，等待负载均衡器摘除实例的时间
+	ShutdownDelay Duration `yaml:"shutdown_delay" toml:"shutdown_delay" env:"SERVER_SHUTDOWN_DELAY"`
+	// ShutdownTimeout 等待处理中的请求和后台任务完成的最长时间，超时后强制退出
+	ShutdownTimeout Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT"`
+}
+
+// DeepSeek AI服务配置，翻译服务共用同一个密钥和模型
+type DeepSeek struct {
+	APIKey  string `yaml:"api_key" toml:"api_key" env:"DEEPSEEK_API_KEY" secret:"true"`
+	BaseURL string `yaml:"base_url" toml:"base_url" env:"DEEPSEEK_BASE_URL"`
+	Model   string `yaml:"model" toml:"model" env:"DEEPSEEK_MODEL"`
+	// SourceTimeout 聚合管道中AI数据源的超时时间
+	SourceTimeout Duration `yaml:"source_timeout" toml:"source_timeout" env:"AI_SOURCE_TIMEOUT"`
+}
+
+// Spoonacular 食谱API配置
+type Spoonacular struct {
+	APIKey  string `yaml:"api_key" toml:"api_key" env:"SPOONACULAR_API_KEY" secret:"true"`
+	BaseURL string `yaml:"base_url" toml:"base_url" env:"SPOONACULAR_BASE_URL"`
+	// ResultNumber 每次搜索返回的食谱数量
+	ResultNumber  int      `yaml:"result_number" toml:"result_number" env:"SPOONACULAR_RESULT_NUMBER"`
+	SourceTimeout Duration `yaml:"source_timeout" toml:"source_timeout" env:"SPOONACULAR_SOURCE_TIMEOUT"`
+	// 按食材搜索、按菜名搜索和食谱详情的缓存时间
+	IngredientsCacheTTL Duration `yaml:"ingredients_cache_ttl" toml:"ingredients_cache_ttl" env:"SPOONACULAR_INGREDIENTS_CACHE_TTL"`
+	DishCacheTTL        Duration `yaml:"dish_cache_ttl" toml:"dish_cache_ttl" env:"SPOONACULAR_DISH_CACHE_TTL"`
+	RecipeCacheTTL      Duration `yaml:"recipe_cache_ttl" toml:"recipe_cache_ttl" env:"SPOONACULAR_RECIPE_CACHE_TTL"`
+}
+
+// Translation 翻译服务配置
+type Translation struct {
+	CacheTTL Duration `yaml:"cache_ttl" toml:"cache_ttl" env:"TRANSLATION_CACHE_TTL"`
+	// Timeout 单个词条的翻译超时，BatchTimeout 批量翻译超时，LongTextTimeout 制作说明等长文本的翻译超时
+	Timeout         Duration `yaml:"timeout" toml:"timeout" env:"TRANSLATION_TIMEOUT"`
+	BatchTimeout    Duration `yaml:"batch_timeout" toml:"batch_timeout" env:"TRANSLATION_BATCH_TIMEOUT"`
+	LongTextTimeout Duration `yaml:"long_text_timeout" toml:"long_text_timeout" env:"TRANSLATION_LONG_TEXT_TIMEOUT"`
+	GlossaryFile    string   `yaml:"glossary_file" toml:"glossary_file" env:"TRANSLATION_GLOSSARY_FILE"`
+	MemoryFile      string   `yaml:"memory_file" toml:"memory_file" env:"TRANSLATION_MEMORY_FILE"`
+	// MemoryFlushInterval 自动翻译结果先记录在内存中，按此间隔批量写入翻译记忆文件
+	MemoryFlushInterval Duration `yaml:"memory_flush_interval" toml:"memory_flush_interval" env:"TRANSLATION_MEMORY_FLUSH_INTERVAL"`
+	// Concurrency 批量翻译失败后逐个翻译时，单个请求同时发起的AI调用数上限
+	Concurrency int `yaml:"concurrency" toml:"concurrency" env:"TRANSLATION_CONCURRENCY"`
+}
+
+// Data 数据文件配置
+type Data struct {
+	IngredientsFile string `yaml:"ingredients_file" toml:"ingredients_file" env:"INGREDIENT_DATA_FILE"`
+	DishesFile      string `yaml:"dishes_file" toml:"dishes_file" env:"DISH_DATA_FILE"`
+}
+
+// Jobs 异步任务配置
+type Jobs struct {
+	StoreFile string   `yaml:"store_file" toml:"store_file" env:"JOB_STORE_FILE"`
+	Workers   int      `yaml:"workers" toml:"workers" env:"JOB_WORKERS"`
+	QueueSize int      `yaml:"queue_size" toml:"queue_size" env:"JOB_QUEUE_SIZE"`
+	ResultTTL Duration `yaml:"result_ttl" toml:"result_ttl" env:"JOB_RESULT_TTL"`
+	// FlushInterval 任务状态变化先记录在内存中，按此间隔批量写入任务文件
+	FlushInterval Duration `yaml:"flush_interval" toml:"flush_interval" env:"JOB_FLUSH_INTERVAL"`
+}
+
+// Webhooks 任务回调配置，未配置密钥时回调不可用
+type Webhooks struct {
+	Secret      string   `yaml:"secret" toml:"secret" env:"WEBHOOK_SECRET" secret:"true"`
+	StoreFile   string   `yaml:"store_file" toml:"store_file" env:"WEBHOOK_STORE_FILE"`
+	MaxAttempts int      `yaml:"max_attempts" toml:"max_attempts" env:"WEBHOOK_MAX_ATTEMPTS"`
+	Backoff     Duration `yaml:"backoff" toml:"backoff" env:"WEBHOOK_BACKOFF"`
+	MaxBackoff  Duration `yaml:"max_backoff" toml:"max_backoff" env:"WEBHOOK_MAX_BACKOFF"`
+	HistoryTTL  Duration `yaml:"history_ttl" toml:"history_ttl" env:"WEBHOOK_HISTORY_TTL"`
+	// Timeout 单次投递的请求超时
+	Timeout Duration `yaml:"timeout" toml:"timeout" env:"WEBHOOK_TIMEOUT"`
+	// AllowPrivateNetworks 允许回调地址指向本机和内网地址，默认拒绝以防止SSRF
+	AllowPrivateNetworks bool `yaml:"allow_private_networks" toml:"allow_private_networks" env:"WEBHOOK_ALLOW_PRIVATE_NETWORKS"`
+}
+
+// Batch 批量查询配置
+type Batch struct {
+	MaxItems    int `yaml:"max_items" toml:"max_items" env:"BATCH_MAX_ITEMS"`
+	Concurrency int `yaml:"concurrency" toml:"concurrency" env:"BATCH_CONCURRENCY"`
+}
+
+// Admin 管理接口配置，未配置令牌时管理接口不可用
+type Admin struct {
+	Token string `yaml:"token" toml:"token" env:"ADMIN_TOKEN" secret:"true"`
+}
+
+// Metrics Prometheus指标配置
+type Metrics struct {
+	Enabled bool   `yaml:"enabled" toml:"enabled" env:"METRICS_ENABLED"`
+	Path    string `yaml:"path" toml:"path" env:"METRICS_PATH"`
+}
+
+// Tracing OpenTelemetry链路追踪配置
+type Tracing struct {
+	// Exporter 导出器：none、otlp（OTLP/HTTP）、stdout 或 memory（保存在内存中，通过管理接口查看）
+	Exporter string `yaml:"exporter" toml:"exporter" env:"TRACING_EXPORTER"`
+	// Endpoint OTLP/HTTP接收地址，例如 http://localhost:4318
+	Endpoint    string `yaml:"endpoint" toml:"endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT"`
+	ServiceName string `yaml:"service_name" toml:"service_name" env:"OTEL_SERVICE_NAME"`
+	// SampleRatio 新链路的采样比例（0到1），上游已采样的链路始终采样
+	SampleRatio float64 `yaml:"sample_ratio" toml:"sample_ratio" env:"TRACING_SAMPLE_RATIO"`
+	// MemorySpans 内存导出器保留的span数
+	MemorySpans int `yaml:"memory_spans" toml:"memory_spans" env:"TRACING_MEMORY_SPANS"`
+}
+
+// Log 日志配置
+type Log struct {
+	// Level 最低输出级别：debug、info、warn 或 error
+	Level string `yaml:"level" toml:"level" env:"LOG_LEVEL"`
+	// Format 输出格式：json 或 text
+	Format string `yaml:"format" toml:"format" env:"LOG_FORMAT"`
+}
+
+// Health 就绪检查配置
+type Health struct {
+	// ProbeInterval 上游探测结果的缓存时间，期间的就绪检查直接使用上次结果
+	ProbeInterval Duration `yaml:"probe_interval" toml:"probe_interval" env:"HEALTH_PROBE_INTERVAL"`
+	// ProbeTimeout 单次探测的超时时间
+	ProbeTimeout Duration `yaml:"probe_timeout" toml:"probe_timeout" env:"HEALTH_PROBE_TIMEOUT"`
+}
+
+// Breaker 上游熔断配置，DeepSeek（AI与翻译共用）和Spoonacular各有一个熔断器
+type Breaker struct {
+	// Threshold 连续失败多少次后熔断
+	Threshold int `yaml:"threshold" toml:"threshold" env:"BREAKER_THRESHOLD"`
+	// Cooldown 熔断后等待多久放行一次试探调用
+	Cooldown Duration `yaml:"cooldown" toml:"cooldown" env:"BREAKER_COOLDOWN"`
+}
+
+// Duration 配置文件和环境变量中以 30s、10m、24h 形式书写的时长
+type Duration struct {
+	time.Duration
+}
+
+// UnmarshalText 解析时长文本
+func (d *Duration) UnmarshalText(text []byte) error {
+	value, err := time.ParseDuration(strings.TrimSpace(string(text)))
+	if err != nil {
+		return fmt.Errorf("无效的时长 %q", string(text))
+	}
+	d.Duration = value
+	return nil
+}
+
+// MarshalText 输出时长文本
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// Default 返回内置默认配置
+func Default() Config {
+	return Config{
+		Server: Server{
+			Port:            "8080",
+			Mode:            "release",
+			ReadTimeout:     Duration{30 * time.Second},
+			WriteTimeout:    Duration{3 * time.Minute},
+			IdleTimeout:     Duration{2 * time.Minute},
+			ShutdownTimeout: Duration{2 * time.Minute},
+		},
+		DeepSeek: DeepSeek{
+			BaseURL:       "https://api.deepseek.com/v1/chat/completions",
+			Model:         "deepseek-chat",
+			SourceTimeout: Duration{2 * time.Minute},
+		},
+		Spoonacular: Spoonacular{
+			BaseURL:             "https://api.spoonacular.com/recipes",
+			ResultNumber:        5,
+			SourceTimeout:       Duration{30 * time.Second},
+			IngredientsCacheTTL: Duration{30 * time.Minute},
+			DishCacheTTL:        Duration{60 * time.Minute},
+			RecipeCacheTTL:      Duration{60 * time.Minute},
+		},
+		Translation: Translation{
+			CacheTTL:            Duration{24 * time.Hour},
+			Timeout:             Duration{10 * time.Second},
+			BatchTimeout:        Duration{20 * time.Second},
+			LongTextTimeout:     Duration{30 * time.Second},
+			GlossaryFile:        "data/glossary.json",
+			MemoryFile:          "data/translation_memory.json",
+			MemoryFlushInterval: Duration{5 * time.Second},
+			Concurrency:         4,
+		},
+		Data: Data{
+			IngredientsFile: "data/ingredients.json",
+			DishesFile:      "data/dishes.json",
+		},
+		Jobs: Jobs{
+			StoreFile:     "data/jobs.json",
+			Workers:       4,
+			QueueSize:     100,
+			ResultTTL:     Duration{time.Hour},
+			FlushInterval: Duration{time.Second},
+		},
+		Webhooks: Webhooks{
+			StoreFile:   "data/webhooks.json",
+			MaxAttempts: 5,
+			Backoff:     Duration{2 * time.Second},
+			MaxBackoff:  Duration{5 * time.Minute},
+			HistoryTTL:  Duration{24 * time.Hour},
+			Timeout:     Duration{10 * time.Second},
+		},
+		Batch:   Batch{MaxItems: 20, Concurrency: 4},
+		Metrics: Metrics{Enabled: true, Path: "/metrics"},
+		Tracing: Tracing{
+			Exporter:    "none",
+			Endpoint:    "http://localhost:4318",
+			ServiceName: "recipe-agent",
+			SampleRatio: 1,
+			MemorySpans: 1000,
+		},
+		Log: Log{Level: "info", Format: "json"},
+		Health: Health{
+			ProbeInterval: Duration{30 * time.Second},
+			ProbeTimeout:  Duration{3 * time.Second},
+		},
+		Breaker: Breaker{Threshold: 5, Cooldown: Duration{30 * time.Second}},
+	}
+}
+
+// Load 依次应用默认配置、配置文件（path 为空时跳过）和环境变量，并校验结果
+func Load(path string) (*Config, error) {
+	cfg := Default()
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	if err := cfg.applyEnv(os.Getenv); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// loadFile 按扩展名解析YAML或TOML配置文件，文件中未出现的字段保留原值，未知字段视为错误
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("读取配置文件失败: %v", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.UnmarshalWithOptions(data, c, yaml.Strict())
+	case ".toml":
+		decoder := toml.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(c)
+	default:
+		return fmt.Errorf("不支持的配置文件格式 %s，请使用 .yaml、.yml 或 .toml", path)
+	}
+	if err != nil {
+		return fmt.Errorf("解析配置文件 %s 失败: %v", path, err)
+	}
+	return nil
+}
+
+// applyEnv 使用已设置的环境变量覆盖对应字段，返回所有无法解析的变量
+func (c *Config) applyEnv(lookup func(string) string) error {
+	var errs []error
+	eachField(reflect.ValueOf(c).Elem(), func(field reflect.StructField, value reflect.Value) {
+		name := field.Tag.Get("env")
+		raw := strings.TrimSpace(lookup(name))
+		if name == "" || raw == "" {
+			return
+		}
+
+		switch target := value.Addr().Interface().(type) {
+		case *string:
+			*target = raw
+		case *bool:
+			enabled, err := strconv.ParseBool(raw)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("环境变量 %s: 无效的布尔值 %q", name, raw))
+				return
+			}
+			*target = enabled
+		case *float64:
+			number, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("环境变量 %s: 无效的数值 %q", name, raw))
+				return
+			}
+			*target = number
+		case *int:
+			number, err := strconv.Atoi(raw)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("环境变量 %s: 无效的整数 %q", name, raw))
+				return
+			}
+			*target = number
+		case *Duration:
+			if err := target.UnmarshalText([]byte(raw)); err != nil {
+				errs = append(errs, fmt.Errorf("环境变量 %s: %v", name, err))
+			}
+		}
+	})
+	return errors.Join(errs...)
+}
+
+// Validate 校验配置，返回所有不合法的设置
+func (c *Config) Validate() error {
+	var errs []error
+	invalid := func(name, format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf("%s: %s", name, fmt.Sprintf(format, args...)))
+	}
+
+	if port, err := strconv.Atoi(c.Server.Port); err != nil || port < 1 || port > 65535 {
+		invalid("server.port", "端口必须是1到65535之间的整数，当前为 %q", c.Server.Port)
+	}
+	switch c.Server.Mode {
+	case "debug", "release", "test":
+	default:
+		invalid("server.mode", "必须是 debug、release 或 test，当前为 %q", c.Server.Mode)
+	}
+
+	for name, value := range map[string]string{
+		"deepseek.base_url":    c.DeepSeek.BaseURL,
+		"spoonacular.base_url": c.Spoonacular.BaseURL,
+	} {
+		if parsed, err := url.Parse(value); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			invalid(name, "必须是 http 或 https 地址，当前为 %q", value)
+		}
+	}
+	if strings.TrimSpace(c.DeepSeek.Model) == "" {
+		invalid("deepseek.model", "不能为空")
+	}
+	if c.Spoonacular.ResultNumber < 1 || c.Spoonacular.ResultNumber > 100 {
+		invalid("spoonacular.result_number", "必须在1到100之间，当前为 %d", c.Spoonacular.ResultNumber)
+	}
+
+	for name, value := range map[string]string{
+		"translation.glossary_file": c.Translation.GlossaryFile,
+		"translation.memory_file":   c.Translation.MemoryFile,
+		"data.ingredients_file":     c.Data.IngredientsFile,
+		"data.dishes_file":          c.Data.DishesFile,
+		"jobs.store_file":           c.Jobs.StoreFile,
+		"webhooks.store_file":       c.Webhooks.StoreFile,
+	} {
+		if strings.TrimSpace(value) == "" {
+			invalid(name, "不能为空")
+		}
+	}
+
+	for name, value := range map[string]int{
+		"jobs.workers":            c.Jobs.Workers,
+		"jobs.queue_size":         c.Jobs.QueueSize,
+		"webhooks.max_attempts":   c.Webhooks.MaxAttempts,
+		"batch.max_items":         c.Batch.MaxItems,
+		"batch.concurrency":       c.Batch.Concurrency,
+		"translation.concurrency": c.Translation.Concurrency,
+		"breaker.threshold":       c.Breaker.Threshold,
+	} {
+		if value <= 0 {
+			invalid(name, "必须大于0，当前为 %d", value)
+		}
+	}
+
+	for name, value := range map[string]Duration{
+		"server.read_timeout":               c.Server.ReadTimeout,
+		"server.write_timeout":              c.Server.WriteTimeout,
+		"server.idle_timeout":               c.Server.IdleTimeout,
+		"server.shutdown_timeout":           c.Server.ShutdownTimeout,
+		"deepseek.source_timeout":           c.DeepSeek.SourceTimeout,
+		"spoonacular.source_timeout":        c.Spoonacular.SourceTimeout,
+		"spoonacular.ingredients_cache_ttl": c.Spoonacular.IngredientsCacheTTL,
+		"spoonacular.dish_cache_ttl":        c.Spoonacular.DishCacheTTL,
+		"spoonacular.recipe_cache_ttl":      c.Spoonacular.RecipeCacheTTL,
+		"translation.cache_ttl":             c.Translation.CacheTTL,
+		"translation.timeout":               c.Translation.Timeout,
+		"translation.batch_timeout":         c.Translation.BatchTimeout,
+		"translation.long_text_timeout":     c.Translation.LongTextTimeout,
+		"translation.memory_flush_interval": c.Translation.MemoryFlushInterval,
+		"jobs.result_ttl":                   c.Jobs.ResultTTL,
+		"jobs.flush_interval":               c.Jobs.FlushInterval,
+		"webhooks.backoff":                  c.Webhooks.Backoff,
+		"webhooks.max_backoff":              c.Webhooks.MaxBackoff,
+		"webhooks.history_ttl":              c.Webhooks.HistoryTTL,
+		"webhooks.timeout":                  c.Webhooks.Timeout,
+		"health.probe_interval":             c.Health.ProbeInterval,
+		"health.probe_timeout":              c.Health.ProbeTimeout,
+		"breaker.cooldown":                  c.Breaker.Cooldown,
+	} {
+		if value.Duration <= 0 {
+			invalid(name, "必须大于0，当前为 %s", value)
+		}
+	}
+	if c.Metrics.Enabled {
+		path := c.Metrics.Path
+		switch {
+		case !strings.HasPrefix(path, "/") || path == "/":
+			invalid("metrics.path", "必须是以 / 开头的非根路径，当前为 %q", path)
+		case strings.HasPrefix(path, "/api/") || strings.HasPrefix(path, "/admin/") || strings.HasPrefix(path, "/static/"):
+			invalid("metrics.path", "不能位于 /api/、/admin/ 或 /static/ 下，当前为 %q", path)
+		}
+	}
+	switch c.Tracing.Exporter {
+	case "none", "stdout", "memory":
+	case "otlp":
+		if parsed, err := url.Parse(c.Tracing.Endpoint); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			invalid("tracing.endpoint", "必须是 http 或 https 地址，当前为 %q", c.Tracing.Endpoint)
+		}
+	default:
+		invalid("tracing.exporter", "必须是 none、otlp、stdout 或 memory，当前为 %q", c.Tracing.Exporter)
+	}
+	if strings.TrimSpace(c.Tracing.ServiceName) == "" {
+		invalid("tracing.service_name", "不能为空")
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		invalid("tracing.sample_ratio", "必须在0到1之间，当前为 %g", c.Tracing.SampleRatio)
+	}
+	if c.Tracing.MemorySpans <= 0 {
+		invalid("tracing.memory_spans", "必须大于0，当前为 %d", c.Tracing.MemorySpans)
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Log.Level)); err != nil {
+		invalid("log.level", "必须是 debug、info、warn 或 error，当前为 %q", c.Log.Level)
+	}
+	if c.Log.Format != "json" && c.Log.Format != "text" {
+		invalid("log.format", "必须是 json 或 text，当前为 %q", c.Log.Format)
+	}
+	if c.Server.ShutdownDelay.Duration < 0 {
+		invalid("server.shutdown_delay", "不能为负数，当前为 %s", c.Server.ShutdownDelay)
+	}
+	// 同步接口需要等待AI数据源，写超时过短会在AI返回前断开连接
+	if c.Server.WriteTimeout.Duration <= c.DeepSeek.SourceTimeout.Duration {
+		invalid("server.write_timeout", "必须大于 deepseek.source_timeout（%s），当前为 %s", c.DeepSeek.SourceTimeout, c.Server.WriteTimeout)
+	}
+	if c.Webhooks.MaxBackoff.Duration < c.Webhooks.Backoff.Duration {
+		invalid("webhooks.max_backoff", "不能小于 webhooks.backoff（%s）", c.Webhooks.Backoff)
+	}
+
+	// 按字段名排序，保证每次输出的顺序一致
+	sort.Slice(errs, func(i, j int) bool {
+		return errs[i].Error() < errs[j].Error()
+	})
+	return errors.Join(errs...)
+}
+
+// Redacted 返回隐藏了密钥的配置副本，未设置的密钥保持为空
+func (c Config) Redacted() Config {
+	eachField(reflect.ValueOf(&c).Elem(), func(field reflect.StructField, value reflect.Value) {
+		if field.Tag.Get("secret") == "true" && value.String() != "" {
+			value.SetString(redactedValue)
+		}
+	})
+	return c
+}
+
+// Secrets 返回已设置的密钥，日志中出现这些值时会被隐藏
+func (c Config) Secrets() []string {
+	var secrets []string
+	eachField(reflect.ValueOf(&c).Elem(), func(field reflect.StructField, value reflect.Value) {
+		if field.Tag.Get("secret") == "true" && value.String() != "" {
+			secrets = append(secrets, value.String())
+		}
+	})
+	return secrets
+}
+
+// YAML 输出隐藏密钥后的生效配置
+func (c Config) YAML() ([]byte, error) {
+	return yaml.Marshal(c.Redacted())
+}
+
+// eachField 遍历配置各分组中的字段
+func eachField(config reflect.Value, visit func(field reflect.StructField, value reflect.Value)) {
+	for i := 0; i < config.NumField(); i++ {
+		section := config.Field(i)
+		for j := 0; j < section.NumField(); j++ {
+			visit(section.Type().Field(j), section.Field(j))
+		}
+	}
+}
@@ -0,0 +1,328 @@
+package handlers
+
+import (
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"recipe-agent/internal/buildinfo"
+	"recipe-agent/internal/config"
+	"recipe-agent/internal/logging"
+	"recipe-agent/internal/metrics"
+	"recipe-agent/internal/services"
+)
+
+// defaultCacheEntryLimit 缓存条目列表默认返回的数量
+const defaultCacheEntryLimit = 100
+
+// AdminCache 可通过管理接口查看和清理的缓存
+type AdminCache interface {
+	GetCacheStatus() metrics.CacheStatus
+	CacheEntries(prefix string) []services.CachedEntry
+	PurgeCache(prefix string) int
+	CleanExpiredCache() int
+}
+
+// AdminHandler 缓存、上游熔断器、日志级别和运行状态的管理处理器
+type AdminHandler struct {
+	batch         config.Batch
+	caches        map[string]AdminCache
+	recipeService *services.RecipeService
+	jobService    *services.JobService
+	breakers      []*services.Breaker
+	startedAt     time.Time
+}
+
+// CacheStats 单个缓存的统计
+type CacheStats struct {
+	Name   string              `json:"name"`
+	Status metrics.CacheStatus `json:"status"`
+}
+
+// CacheStatsResponse 缓存统计响应结构
+type CacheStatsResponse struct {
+	Success bool         `json:"success"`
+	Caches  []CacheStats `json:"caches"`
+}
+
+// CacheEntriesResponse 缓存条目列表响应结构，Total 为匹配的条目总数，Entries 最多返回 limit 条
+type CacheEntriesResponse struct {
+	Success bool                   `json:"success"`
+	Cache   string                 `json:"cache"`
+	Prefix  string                 `json:"prefix"`
+	Total   int                    `json:"total"`
+	Entries []services.CachedEntry `json:"entries"`
+}
+
+// CachePurgeResponse 缓存清理响应结构
+type CachePurgeResponse struct {
+	Success bool   `json:"success"`
+	Cache   string `json:"cache"`
+	Removed int    `json:"removed"`
+}
+
+// CacheWarmRequest 缓存预热请求结构
+type CacheWarmRequest struct {
+	Ingredients [][]string `json:"ingredients,omitempty" description:"食材组合，每组按食材搜索一次，如 [[\"鸡蛋\",\"番茄\"]]"`
+	Dishes      []string   `json:"dishes,omitempty" description:"菜名，每个按菜名搜索一次"`
+}
+
+// CacheWarmResponse 缓存预热响应结构
+type CacheWarmResponse struct {
+	Success bool                       `json:"success"`
+	Results []services.CacheWarmResult `json:"results"`
+}
+
+// UpstreamListResponse 上游熔断器状态响应结构
+type UpstreamListResponse struct {
+	Success   bool                     `json:"success"`
+	Upstreams []services.BreakerStatus `json:"upstreams"`
+}
+
+// UpstreamResponse 单个上游熔断器状态响应结构
+type UpstreamResponse struct {
+	Success  bool                   `json:"success"`
+	Upstream services.BreakerStatus `json:"upstream"`
+}
+
+// LogLevelRequest 日志级别修改请求结构
+type LogLevelRequest struct {
+	Level string `json:"level" enum:"debug,info,warn,error"`
+}
+
+// LogLevelResponse 日志级别响应结构
+type LogLevelResponse struct {
+	Success bool   `json:"success"`
+	Level   string `json:"level" enum:"debug,info,warn,error"`
+}
+
+// RuntimeResponse 运行状态响应结构
+type RuntimeResponse struct {
+	Success    bool               `json:"success"`
+	Build      buildinfo.Info     `json:"build"`
+	StartedAt  time.Time          `json:"startedAt"`
+	Uptime     string             `json:"uptime"`
+	Goroutines int                `json:"goroutines"`
+	Memory     RuntimeMemory      `json:"memory"`
+	LogLevel   string             `json:"logLevel"`
+	Jobs       metrics.PoolStatus `json:"jobs"`
+}
+
+// RuntimeMemory 内存使用情况，单位为字节
+type RuntimeMemory struct {
+	HeapAlloc uint64 `json:"heapAlloc"`
+	HeapInuse uint64 `json:"heapInuse"`
+	Sys       uint64 `json:"sys"`
+	NumGC     uint32 `json:"numGC"`
+}
+
+// NewAdminHandler 创建管理处理器实例，breakers 为各上游的熔断器
+func NewAdminHandler(batch config.Batch, recipeService *services.RecipeService, translationService *services.TranslationService, jobService *services.JobService, breakers ...*services.Breaker) *AdminHandler {
+	return &AdminHandler{
+		batch: batch,
+		caches: map[string]AdminCache{
+			"recipe":      recipeService,
+			"translation": translationService,
+		},
+		recipeService: recipeService,
+		jobService:    jobService,
+		breakers:      breakers,
+		startedAt:     time.Now(),
+	}
+}
+
+// ListCaches 返回各缓存的条目数和命中率
+func (h *AdminHandler) ListCaches(c *gin.Context) {
+	names := make([]string, 0, len(h.caches))
+	for name := range h.caches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	caches := make([]CacheStats, 0, len(names))
+	for _, name := range names {
+		caches = append(caches, CacheStats{Name: name, Status: h.caches[name].GetCacheStatus()})
+	}
+	c.JSON(http.StatusOK, CacheStatsResponse{Success: true, Caches: caches})
+}
+
+// ListCacheEntries 列出键以 prefix 开头的缓存条目
+func (h *AdminHandler) ListCacheEntries(c *gin.Context) {
+	name, cache, ok := h.cacheParam(c)
+	if !ok {
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultCacheEntryLimit)))
+	if err != nil || limit <= 0 || limit > 1000 {
+		limit = defaultCacheEntryLimit
+	}
+
+	prefix := c.Query("prefix")
+	entries := cache.CacheEntries(prefix)
+	total := len(entries)
+	if total > limit {
+		entries = entries[:limit]
+	}
+	c.JSON(http.StatusOK, CacheEntriesResponse{Success: true, Cache: name, Prefix: prefix, Total: total, Entries: entries})
+}
+
+// PurgeCache 删除键以 prefix 开头的缓存条目；expired=true 时只清理过期条目。
+// 不带 prefix 时需要 all=true 才清空整个缓存，避免误操作
+func (h *AdminHandler) PurgeCache(c *gin.Context) {
+	name, cache, ok := h.cacheParam(c)
+	if !ok {
+		return
+	}
+
+	var removed int
+	prefix := c.Query("prefix")
+	switch {
+	case c.Query("expired") == "true":
+		removed = cache.CleanExpiredCache()
+	case prefix == "" && c.Query("all") != "true":
+		abortWithProblem(c, &APIError{
+			Code:   CodeValidationError,
+			Detail: "清空整个缓存需要 all=true",
+			Fields: []FieldError{newFieldError("prefix", FieldRequired)},
+		})
+		return
+	default:
+		removed = cache.PurgeCache(prefix)
+	}
+	c.JSON(http.StatusOK, CachePurgeResponse{Success: true, Cache: name, Removed: removed})
+}
+
+// WarmCache 按食材组合和菜名预先查询，写入翻译和食谱缓存
+func (h *AdminHandler) WarmCache(c *gin.Context) {
+	var req CacheWarmRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortWithProblem(c, &APIError{Code: CodeValidationError, Detail: err.Error()})
+		return
+	}
+
+	var groups [][]string
+	for _, group := range req.Ingredients {
+		var ingredients []string
+		for _, ingredient := range group {
+			if ingredient = strings.TrimSpace(ingredient); ingredient != "" {
+				ingredients = append(ingredients, ingredient)
+			}
+		}
+		if len(ingredients) > 0 {
+			groups = append(groups, ingredients)
+		}
+	}
+	var dishes []string
+	for _, dish := range req.Dishes {
+		if dish = strings.TrimSpace(dish); dish != "" {
+			dishes = append(dishes, dish)
+		}
+	}
+
+	switch count := len(groups) + len(dishes); {
+	case count == 0:
+		abortWithProblem(c, &APIError{
+			Code:   CodeValidationError,
+			Fields: []FieldError{newFieldError("ingredients", FieldRequired), newFieldError("dishes", FieldRequired)},
+		})
+		return
+	case count > h.batch.MaxItems:
+		abortWithProblem(c, &APIError{
+			Code:   CodeValidationError,
+			Detail: "预热查询数量不能超过 " + strconv.Itoa(h.batch.MaxItems),
+		})
+		return
+	}
+
+	results := h.recipeService.WarmCache(c.Request.Context(), groups, dishes, h.batch.Concurrency)
+	c.JSON(http.StatusOK, CacheWarmResponse{Success: true, Results: results})
+}
+
+// cacheParam 解析路径中的缓存名称
+func (h *AdminHandler) cacheParam(c *gin.Context) (string, AdminCache, bool) {
+	name := c.Param("name")
+	cache, exists := h.caches[name]
+	if !exists {
+		abortWithProblem(c, &APIError{Code: CodeNotFound, Detail: "缓存不存在: " + name})
+		return "", nil, false
+	}
+	return name, cache, true
+}
+
+// ListUpstreams 返回各上游的熔断状态和调用额度
+func (h *AdminHandler) ListUpstreams(c *gin.Context) {
+	upstreams := make([]services.BreakerStatus, 0, len(h.breakers))
+	for _, breaker := range h.breakers {
+		upstreams = append(upstreams, breaker.Status())
+	}
+	c.JSON(http.StatusOK, UpstreamListResponse{Success: true, Upstreams: upstreams})
+}
+
+// ResetUpstream 手动关闭上游熔断器
+func (h *AdminHandler) ResetUpstream(c *gin.Context) {
+	name := c.Param("name")
+	for _, breaker := range h.breakers {
+		if breaker.Name() == name {
+			breaker.Reset()
+			c.JSON(http.StatusOK, UpstreamResponse{Success: true, Upstream: breaker.Status()})
+			return
+		}
+	}
+	abortWithProblem(c, &APIError{Code: CodeNotFound, Detail: "上游不存在: " + name})
+}
+
+// GetLogLevel 返回当前的日志级别
+func (h *AdminHandler) GetLogLevel(c *gin.Context) {
+	c.JSON(http.StatusOK, LogLevelResponse{Success: true, Level: logLevelName()})
+}
+
+// SetLogLevel 修改日志级别，立即生效，重启后恢复为配置中的级别
+func (h *AdminHandler) SetLogLevel(c *gin.Context) {
+	var req LogLevelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortWithProblem(c, &APIError{Code: CodeValidationError, Detail: err.Error()})
+		return
+	}
+	if err := logging.SetLevel(req.Level); err != nil {
+		abortWithProblem(c, &APIError{
+			Code:   CodeValidationError,
+			Detail: err.Error(),
+			Fields: []FieldError{newFieldError("level", FieldInvalid)},
+		})
+		return
+	}
+	c.JSON(http.StatusOK, LogLevelResponse{Success: true, Level: logLevelName()})
+}
+
+// logLevelName 返回当前日志级别的小写名称
+func logLevelName() string {
+	return strings.ToLower(logging.Level().String())
+}
+
+// Runtime 返回构建信息、运行时间、goroutine数、内存和任务工作池状态
+func (h *AdminHandler) Runtime(c *gin.Context) {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+
+	c.JSON(http.StatusOK, RuntimeResponse{
+		Success:    true,
+		Build:      buildinfo.Get(),
+		StartedAt:  h.startedAt,
+		Uptime:     time.Since(h.startedAt).Round(time.Second).String(),
+		Goroutines: runtime.NumGoroutine(),
+		Memory: RuntimeMemory{
+			HeapAlloc: stats.HeapAlloc,
+			HeapInuse: stats.HeapInuse,
+			Sys:       stats.Sys,
+			NumGC:     stats.NumGC,
+		},
+		LogLevel: logLevelName(),
+		Jobs:     h.jobService.PoolStatus(),
+	})
+}
@@ -1,389 +1,237 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
-	"net/http"
+	"log/slog"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"recipe-agent/internal/config"
+	"recipe-agent/internal/metrics"
+	"recipe-agent/internal/render"
 	"recipe-agent/internal/services"
 )
 
 // AgentHandler 处理器结构
 type AgentHandler struct {
-	recipeService *services.RecipeService
-	aiService     *services.AIService
+	recipeService  *services.RecipeService
+	aiService      *services.AIService
+	ontology       *services.IngredientOntology
+	suggestService *services.SuggestService
+	corrector      *services.CorrectionService
+	batch          config.Batch
+	queryTypes     *QueryTypeRegistry
+	renderer       *render.Renderer
 }
 
 // RecipeRequest 食谱请求结构
 type RecipeRequest struct {
-	Ingredients []string `json:"ingredients"`
-	DishName    string   `json:"dishName"`
-	QueryType   string   `json:"queryType"`
+	Ingredients []string `json:"ingredients,omitempty" description:"食材列表，ingredients和substitute（需要替代的食材）必填，leftover可选"`
+	DishName    string   `json:"dishName,omitempty" description:"菜名，dish、pairing和leftover（剩菜）必填，substitute可选"`
+	Question    string   `json:"question,omitempty" description:"烹饪问题，question必填，不超过500字"`
+	QueryType   string   `json:"queryType" enum:"ingredients,dish,substitute,pairing,leftover,question"`
+	Locale      string   `json:"locale,omitempty" description:"响应语言，默认zh-CN"`
+	// Constraints 附加要求（耗时、人数、忌口、饮食偏好、现有用量），会加入AI提示词
+	Constraints *services.Constraints `json:"constraints,omitempty"`
+	// Corrections 校验时自动纠正的食材和菜名，由服务端填写，提交的值会被忽略
+	Corrections []services.Correction `json:"corrections,omitempty"`
 }
 
 // RecipeResponse 食谱响应结构
 type RecipeResponse struct {
-	Result           string                 `json:"result"`
-	Type             string                 `json:"type"`
-	Timestamp        time.Time             `json:"timestamp"`
-	SupplementaryData map[string]interface{} `json:"supplementaryData"`
-	Success          bool                  `json:"success"`
-	Message          string                `json:"message,omitempty"`
+	Result     string          `json:"result" description:"Markdown格式的结果"`
+	ResultHTML string          `json:"resultHtml" description:"渲染并过滤后的安全HTML，可直接插入页面"`
+	Document   render.Document `json:"document" description:"从结果解析出的结构化食谱"`
+	// Corrections 自动纠正的食材和菜名（如 西红柿抄鸡蛋 → 西红柿炒鸡蛋）
+	Corrections       []services.Correction `json:"corrections,omitempty"`
+	Type              string                `json:"type"`
+	Timestamp         time.Time             `json:"timestamp"`
+	SupplementaryData *Supplementary        `json:"supplementaryData,omitempty"`
+	Success           bool                  `json:"success"`
 }
 
-// NewAgentHandler 创建处理器实例
-func NewAgentHandler(recipeService *services.RecipeService, aiService *services.AIService) *AgentHandler {
-	return &AgentHandler{
-		recipeService: recipeService,
-		aiService:     aiService,
-	}
+// NewAgentHandler 创建处理器实例，注册内置查询类型
+func NewAgentHandler(batch config.Batch, recipeService *services.RecipeService, aiService *services.AIService, ontology *services.IngredientOntology, suggestService *services.SuggestService, corrector *services.CorrectionService) *AgentHandler {
+	h := &AgentHandler{
+		recipeService:  recipeService,
+		aiService:      aiService,
+		ontology:       ontology,
+		suggestService: suggestService,
+		corrector:      corrector,
+		batch:          batch,
+		queryTypes:     NewQueryTypeRegistry(),
+		renderer:       render.NewRenderer(),
+	}
+	for _, queryType := range builtinQueryTypes(recipeService, aiService) {
+		h.queryTypes.Register(queryType)
+	}
+	return h
+}
+
+// RegisterQueryType 注册新的查询类型或替换已有类型
+func (h *AgentHandler) RegisterQueryType(queryType QueryType) {
+	h.queryTypes.Register(queryType)
 }
 
-// GetRecipes 处理食谱请求
+// GetRecipes 处理食谱请求，按 format 参数或 Accept 头返回JSON、Markdown、HTML或纯文本
 func (h *AgentHandler) GetRecipes(c *gin.Context) {
+	// 在调用数据源之前确定输出格式
+	format, formatErr := responseFormat(c)
+	if formatErr != nil {
+		abortWithProblem(c, formatErr)
+		return
+	}
+
 	var req RecipeRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, RecipeResponse{
-			Success: false,
-			Message: "请求格式错误: " + err.Error(),
-		})
+		abortWithProblem(c, &APIError{Code: CodeValidationError, Detail: err.Error()})
 		return
 	}
+	// 错误文案使用请求的语言
+	c.Set(localeContextKey, req.Locale)
 
 	// 验证请求
 	if err := h.validateRequest(&req); err != nil {
-		c.JSON(http.StatusBadRequest, RecipeResponse{
-			Success: false,
-			Message: err.Error(),
-		})
+		abortWithProblem(c, err)
 		return
 	}
+	c.Set(queryTypeContextKey, req.QueryType)
 
 	// 处理请求
-	result, supplementaryData, err := h.processRequest(&req)
+	response, err := h.buildResponse(c.Request.Context(), &req)
 	if err != nil {
-		log.Printf("处理请求失败: %v", err)
-		c.JSON(http.StatusInternalServerError, RecipeResponse{
-			Success: false,
-			Message: "服务处理失败，请稍后重试",
-		})
+		abortWithProblem(c, err)
 		return
 	}
 
-	// 返回结果
-	response := RecipeResponse{
-		Result:           result,
-		Type:             req.QueryType,
-		Timestamp:        time.Now(),
-		SupplementaryData: supplementaryData,
-		Success:          true,
-	}
-
-	c.JSON(http.StatusOK, response)
+	h.writeRecipeResponse(c, format, response)
 }
 
-// validateRequest 验证请求
-func (h *AgentHandler) validateRequest(req *RecipeRequest) error {
-	// 验证queryType
-	if req.QueryType != "ingredients" && req.QueryType != "dish" {
-		return fmt.Errorf("无效的查询类型，必须是 'ingredients' 或 'dish'")
-	}
-
-	// 根据查询类型验证相应字段
-	switch req.QueryType {
-	case "ingredients":
-		if len(req.Ingredients) == 0 {
-			return fmt.Errorf("按食材查询时必须提供食材列表")
-		}
-		// 清理和验证食材
-		var cleanedIngredients []string
-		for _, ingredient := range req.Ingredients {
-			ingredient = strings.TrimSpace(ingredient)
-			if ingredient != "" {
-				cleanedIngredients = append(cleanedIngredients, ingredient)
-			}
-		}
-		if len(cleanedIngredients) == 0 {
-			return fmt.Errorf("食材列表不能为空")
-		}
-		req.Ingredients = cleanedIngredients
-
-	case "dish":
-		if strings.TrimSpace(req.DishName) == "" {
-			return fmt.Errorf("按菜名查询时必须提供菜名")
-		}
-		req.DishName = strings.TrimSpace(req.DishName)
+// buildResponse 处理已校验的请求并生成响应，同步接口、异步任务和批量查询共用
+func (h *AgentHandler) buildResponse(ctx context.Context, req *RecipeRequest) (*RecipeResponse, error) {
+	result, supplementary, err := h.processRequest(ctx, req)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
-}
-
-// processRequest 处理具体的食谱请求
-func (h *AgentHandler) processRequest(req *RecipeRequest) (string, map[string]interface{}, error) {
-	var result string
-	var supplementaryData map[string]interface{}
-	var err error
-
-	// 处理不同类型的请求
-	switch req.QueryType {
-	case "ingredients":
-		result, supplementaryData, err = h.processIngredientsRequest(req.Ingredients)
-	case "dish":
-		result, supplementaryData, err = h.processDishRequest(req.DishName)
-	default:
-		return "", nil, fmt.Errorf("不支持的查询类型: %s", req.QueryType)
-	}
+	// 记录查询热度，供自动补全排序
+	h.recordQuery(req, supplementary)
 
+	html, err := h.renderer.HTML(result)
 	if err != nil {
-		return "", nil, err
-	}
-
-	return result, supplementaryData, nil
+		return nil, fmt.Errorf("渲染结果失败: %w", err)
+	}
+
+	return &RecipeResponse{
+		Result:            result,
+		ResultHTML:        html,
+		Document:          h.renderer.Document(result),
+		Corrections:       req.Corrections,
+		Type:              req.QueryType,
+		Timestamp:         time.Now(),
+		SupplementaryData: supplementary,
+		Success:           true,
+	}, nil
 }
 
-// processIngredientsRequest 处理食材请求
-func (h *AgentHandler) processIngredientsRequest(ingredients []string) (string, map[string]interface{}, error) {
-	log.Printf("处理食材查询请求: %v", ingredients)
+// validateRequest 验证请求，返回所有字段的校验错误；只有名称歧义时返回 AMBIGUOUS_INPUT
+func (h *AgentHandler) validateRequest(req *RecipeRequest) *APIError {
+	var fields []FieldError
+	req.Corrections = nil
 
-	// 并行获取AI分析和API数据
-	type apiResult struct {
-		recipes []services.SpoonacularRecipe
-		err     error
+	// 未指定语言时默认返回简体中文
+	if strings.TrimSpace(req.Locale) == "" {
+		req.Locale = services.DefaultLocale
 	}
 
-	aiChan := make(chan string, 1)
-	apiChan := make(chan apiResult, 1)
-
-	// 异步调用AI服务
-	go func() {
-		aiResult, err := h.aiService.AnalyzeIngredients(ingredients)
-		if err != nil {
-			log.Printf("AI服务调用失败: %v", err)
-			aiChan <- "" // 发送空结果表示失败
-		} else {
-			aiChan <- aiResult
-		}
-	}()
-
-	// 异步调用API服务
-	go func() {
-		recipes, err := h.recipeService.SearchByIngredients(ingredients)
-		apiChan <- apiResult{recipes: recipes, err: err}
-	}()
-
-	// 等待AI结果
-	aiResult := <-aiChan
-	var aiError error
-	if aiResult == "" {
-		aiError = fmt.Errorf("AI服务不可用")
+	// 根据查询类型的字段规则验证相应字段
+	if req.QueryType == "" {
+		fields = append(fields, newFieldError("queryType", FieldRequired))
+	} else if queryType, exists := h.queryTypes.Get(req.QueryType); !exists {
+		fields = append(fields, newFieldError("queryType", FieldInvalid))
+	} else {
+		fields = append(fields, h.validateInputs(queryType, req)...)
 	}
+	fields = append(fields, h.validateConstraints(req)...)
 
-	// 等待API结果
-	apiRes := <-apiChan
-	var apiError error
-	if apiRes.err != nil {
-		apiError = fmt.Errorf("API服务不可用")
+	if len(fields) == 0 {
+		return nil
 	}
-
-	// 构建最终结果
-	var finalResult string
-	var supplementaryData map[string]interface{}
-
-	if aiError != nil && apiError != nil {
-		// 两个服务都失败
-		return "", nil, fmt.Errorf("所有服务都不可用")
-	} else if aiError == nil && apiError != nil {
-		// 只有AI服务可用
-		finalResult = aiResult
-		supplementaryData = map[string]interface{}{
-			"ai_available":    true,
-			"api_available":   false,
-			"nutrition_tips":  "营养分析暂不可用",
-		}
-	} else if aiError != nil && apiError == nil {
-		// 只有API服务可用
-		recipesText := h.recipeService.FormatRecipesForAI(apiRes.recipes)
-		finalResult = h.generateFallbackIngredientResult(ingredients, recipesText)
-		supplementaryData = map[string]interface{}{
-			"ai_available":    false,
-			"api_available":   true,
-			"api_recipes":     apiRes.recipes,
-			"nutrition_tips":  "获得" + fmt.Sprintf("%d", len(apiRes.recipes)) + "个食谱参考",
-		}
-	} else {
-		// 两个服务都可用，整合结果
-		finalResult = h.combineIngredientResults(aiResult, apiRes.recipes)
-		supplementaryData = map[string]interface{}{
-			"ai_available":    true,
-			"api_available":   true,
-			"api_recipes":     apiRes.recipes,
-			"nutrition_tips":  "AI分析完成，包含" + fmt.Sprintf("%d", len(apiRes.recipes)) + "个食谱参考",
+	for _, field := range fields {
+		if field.Code != FieldAmbiguous {
+			return &APIError{Code: CodeValidationError, Fields: fields}
 		}
 	}
-
-	return finalResult, supplementaryData, nil
+	return &APIError{Code: CodeAmbiguousInput, Fields: fields}
 }
 
-// processDishRequest 处理菜品请求
-func (h *AgentHandler) processDishRequest(dishName string) (string, map[string]interface{}, error) {
-	log.Printf("处理菜品查询请求: %s", dishName)
-
-	// 并行获取AI详细分析和API数据
-	type apiResult struct {
-		recipes []services.SpoonacularRecipe
-		err     error
+// processRequest 通过查询类型的聚合管道并行查询所有数据源并合并结果
+func (h *AgentHandler) processRequest(ctx context.Context, req *RecipeRequest) (string, *Supplementary, error) {
+	queryType, exists := h.queryTypes.Get(req.QueryType)
+	if !exists {
+		return "", nil, fmt.Errorf("不支持的查询类型: %s", req.QueryType)
 	}
-
-	aiChan := make(chan string, 1)
-	apiChan := make(chan apiResult, 1)
-
-	// 异步调用AI服务
-	go func() {
-		aiResult, err := h.aiService.GetDishDetails(dishName)
-		if err != nil {
-			log.Printf("AI服务调用失败: %v", err)
-			aiChan <- "" // 发送空结果表示失败
-		} else {
-			aiChan <- aiResult
-		}
-	}()
-
-	// 异步调用API服务
-	go func() {
-		recipes, err := h.recipeService.SearchByDishName(dishName)
-		apiChan <- apiResult{recipes: recipes, err: err}
-	}()
-
-	// 等待AI结果
-	aiResult := <-aiChan
-	var aiError error
-	if aiResult == "" {
-		aiError = fmt.Errorf("AI服务不可用")
+	slog.InfoContext(ctx, "处理查询请求", "query_type", req.QueryType, "ingredients", req.Ingredients, "dish", req.DishName, "question", req.Question)
+
+	result, err := queryType.Pipeline.Run(ctx, services.PipelineQuery{
+		Type:        req.QueryType,
+		Ingredients: req.Ingredients,
+		DishName:    req.DishName,
+		Question:    req.Question,
+		Locale:      req.Locale,
+		Constraints: req.Constraints,
+	})
+	if errors.Is(err, services.ErrNoUsableSource) {
+		// 没有任何数据源提供可用的结果
+		metrics.ObservePipeline(req.QueryType, metrics.ModeAllFailed)
+		return "", nil, sourcesError(result.Results)
 	}
-
-	// 等待API结果
-	apiRes := <-apiChan
-	var apiError error
-	if apiRes.err != nil {
-		apiError = fmt.Errorf("API服务不可用")
+	if err != nil {
+		return "", nil, err
 	}
+	metrics.ObservePipeline(req.QueryType, pipelineMode(result.Results))
 
-	// 构建最终结果
-	var finalResult string
-	var supplementaryData map[string]interface{}
-
-	if aiError != nil && apiError != nil {
-		// 两个服务都失败
-		return "", nil, fmt.Errorf("所有服务都不可用")
-	} else if aiError == nil && apiError != nil {
-		// 只有AI服务可用
-		finalResult = aiResult
-		supplementaryData = map[string]interface{}{
-			"ai_available":    true,
-			"api_available":   false,
-			"reference_count": 0,
-		}
-	} else if aiError != nil && apiError == nil {
-		// 只有API服务可用
-		recipesText := h.recipeService.FormatRecipesForAI(apiRes.recipes)
-		finalResult = h.generateFallbackDishResult(dishName, recipesText)
-		supplementaryData = map[string]interface{}{
-			"ai_available":    false,
-			"api_available":   true,
-			"api_recipes":     apiRes.recipes,
-			"reference_count": len(apiRes.recipes),
-		}
-	} else {
-		// 两个服务都可用，整合结果
-		finalResult = h.combineDishResults(aiResult, apiRes.recipes)
-		supplementaryData = map[string]interface{}{
-			"ai_available":    true,
-			"api_available":   true,
-			"api_recipes":     apiRes.recipes,
-			"reference_count": len(apiRes.recipes),
-		}
-	}
+	supplementary := newSupplementary(result)
+	// 报告检测到的输入语言
+	supplementary.InputLanguage = h.detectInputLanguage(req)
 
-	return finalResult, supplementaryData, nil
+	return result.Content, supplementary, nil
 }
 
-// generateFallbackIngredientResult 生成食材请求的备选结果
-func (h *AgentHandler) generateFallbackIngredientResult(ingredients []string, recipesText string) string {
-	ingredientsStr := strings.Join(ingredients, "、")
-	return fmt.Sprintf(`# 食材分析与推荐
-
-## 食材概述
-您提供的食材：%s
-
-这些食材搭配很有创意，可以制作出营养丰富、口感多样的菜品。
-
-%s
-
-## 简单制作建议
-
-### 推荐制作方式
-1. **清炒类**：将主要食材切块，大火快炒，保持食材营养和口感
-2. **汤品类**：制作营养汤品，适合全家享用
-3. **焖烧类**：慢火焖煮，让食材充分融合味道
-
-### 烹饪小贴士
-- 食材新鲜度是成功的关键
-- 控制火候，避免过度烹饪
-- 适当调味，突显食材本味
+// recordQuery 记录查询中的食材、菜名和返回的食谱标题到自动补全索引
+func (h *AgentHandler) recordQuery(req *RecipeRequest, supplementary *Supplementary) {
+	for _, ingredient := range req.Ingredients {
+		h.suggestService.RecordQuery(services.SuggestTypeIngredient, ingredient)
+	}
+	if req.DishName != "" {
+		h.suggestService.RecordQuery(services.SuggestTypeDish, req.DishName)
+	}
 
-*注：当前显示基础推荐，如需个性化专业建议，请配置完整服务。*`, ingredientsStr, recipesText)
+	h.suggestService.RecordRecipes(supplementary.ReferenceRecipes)
 }
 
-// generateFallbackDishResult 生成菜品请求的备选结果
-func (h *AgentHandler) generateFallbackDishResult(dishName, recipesText string) string {
-	return fmt.Sprintf(`# %s 制作指南
-
-## 菜品介绍
-%s是一道经典菜品，具有独特的风味和文化特色。
-
-## 基础制作方法
-
-### 食材准备
-- 主要食材（根据菜品特点选择）
-- 调料：盐、生抽、料酒等基础调料
-- 辅料：姜、蒜、葱等增香材料
-
-### 制作步骤
-1. **准备工作**
-   - 清洗和处理所有食材
-   - 按需要改刀切配
-
-2. **烹饪过程**
-   - 热锅下油，控制油温
-   - 按顺序下入食材
-   - 适时调味，注意火候
-
-3. **完成装盘**
-   - 调整最终口味
-   - 适当装饰，提升视觉效果
-
-### 成功要点
-- 食材处理要均匀一致
-- 火候控制要精准
-- 调味要层次分明
-
-%s
+// detectInputLanguage 检测请求中食材、菜名和问题的语言
+func (h *AgentHandler) detectInputLanguage(req *RecipeRequest) InputLanguage {
+	texts := append([]string{}, req.Ingredients...)
+	for _, text := range []string{req.DishName, req.Question} {
+		if text != "" {
+			texts = append(texts, text)
+		}
+	}
 
-*注：当前显示基础制作指南，如需详细专业指导，请配置完整服务。*`, dishName, dishName, recipesText)
-}
+	items := make(map[string]string, len(texts))
+	for _, text := range texts {
+		items[text] = services.DetectLanguage(text).Language
+	}
+	overall := services.DetectLanguage(strings.Join(texts, " "))
 
-// combineIngredientResults 整合食材分析结果
-func (h *AgentHandler) combineIngredientResults(aiResult string, recipes []services.SpoonacularRecipe) string {
-	recipesText := h.recipeService.FormatRecipesForAI(recipes)
-	return aiResult + "\n\n" + "## API食谱参考\n\n" + recipesText
+	return InputLanguage{
+		Language: overall.Language,
+		Mixed:    overall.Mixed,
+		Items:    items,
+	}
 }
-
-// combineDishResults 整合菜品详情结果
-func (h *AgentHandler) combineDishResults(aiResult string, recipes []services.SpoonacularRecipe) string {
-	recipesText := h.recipeService.FormatRecipesForAI(recipes)
-	return aiResult + "\n\n" + "## 参考食谱信息\n\n" + recipesText
-}
\ No newline at end of file
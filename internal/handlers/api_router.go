@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"recipe-agent/internal/openapi"
+)
+
+// APIRouter 注册路由的同时将接口描述写入OpenAPI文档，保证文档与路由一致
+type APIRouter struct {
+	group  *gin.RouterGroup
+	spec   *openapi.Generator
+	prefix string
+}
+
+// NewAPIRouter 创建带文档的路由组
+func NewAPIRouter(group *gin.RouterGroup, spec *openapi.Generator) *APIRouter {
+	return &APIRouter{
+		group:  group,
+		spec:   spec,
+		prefix: group.BasePath(),
+	}
+}
+
+// Handle 注册路由并记录接口描述
+func (r *APIRouter) Handle(method, path string, op openapi.Operation, handlers ...gin.HandlerFunc) {
+	r.group.Handle(method, path, handlers...)
+	r.spec.Add(method, r.prefix+path, op)
+}
+
+// GET 注册GET路由
+func (r *APIRouter) GET(path string, op openapi.Operation, handlers ...gin.HandlerFunc) {
+	r.Handle(http.MethodGet, path, op, handlers...)
+}
+
+// POST 注册POST路由
+func (r *APIRouter) POST(path string, op openapi.Operation, handlers ...gin.HandlerFunc) {
+	r.Handle(http.MethodPost, path, op, handlers...)
+}
+
+// PUT 注册PUT路由
+func (r *APIRouter) PUT(path string, op openapi.Operation, handlers ...gin.HandlerFunc) {
+	r.Handle(http.MethodPut, path, op, handlers...)
+}
+
+// DELETE 注册DELETE路由
+func (r *APIRouter) DELETE(path string, op openapi.Operation, handlers ...gin.HandlerFunc) {
+	r.Handle(http.MethodDelete, path, op, handlers...)
+}
+
+// OpenAPIHandler 返回生成的OpenAPI文档
+func OpenAPIHandler(spec *openapi.Generator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, spec.Document())
+	}
+}
+
+// APIDocsHandler 返回内置的接口文档页面
+func APIDocsHandler(c *gin.Context) {
+	c.HTML(http.StatusOK, "api_docs.html", gin.H{
+		"title":   "智能食谱助手 API 文档",
+		"specURL": "/api/v1/openapi.json",
+	})
+}
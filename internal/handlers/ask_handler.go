@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+
+	"recipe-agent/internal/services"
+)
+
+// maxAskLength 自然语言查询的最大字符数
+const maxAskLength = 500
+
+// AskHandler 自然语言查询处理器
+type AskHandler struct {
+	intentService *services.IntentService
+	agentHandler  *AgentHandler
+}
+
+// AskRequest 自然语言查询请求结构
+type AskRequest struct {
+	Text   string `json:"text" description:"自然语言描述，如：冰箱里有两个鸡蛋和半颗白菜，想做个20分钟的快手菜，不要辣"`
+	Locale string `json:"locale,omitempty" description:"响应语言，默认zh-CN"`
+	DryRun bool   `json:"dryRun,omitempty" description:"只返回解析结果，不执行查询"`
+}
+
+// AskResponse 自然语言查询响应结构
+type AskResponse struct {
+	Success        bool                    `json:"success"`
+	Interpretation services.Interpretation `json:"interpretation"`
+	// Request 解析得到的结构化请求，修改后可直接提交到 /api/v1/recipes
+	Request  RecipeRequest   `json:"request"`
+	Response *RecipeResponse `json:"response,omitempty"`
+}
+
+// NewAskHandler 创建自然语言查询处理器实例
+func NewAskHandler(intentService *services.IntentService, agentHandler *AgentHandler) *AskHandler {
+	return &AskHandler{
+		intentService: intentService,
+		agentHandler:  agentHandler,
+	}
+}
+
+// Ask 解析自然语言查询并执行，同时返回解析结果供用户确认或修改
+func (h *AskHandler) Ask(c *gin.Context) {
+	var ask AskRequest
+	if err := c.ShouldBindJSON(&ask); err != nil {
+		abortWithProblem(c, &APIError{Code: CodeValidationError, Detail: err.Error()})
+		return
+	}
+	c.Set(localeContextKey, ask.Locale)
+
+	ask.Text = strings.TrimSpace(ask.Text)
+	if ask.Text == "" {
+		abortWithProblem(c, &APIError{Code: CodeValidationError, Fields: []FieldError{newFieldError("text", FieldRequired)}})
+		return
+	}
+	if utf8.RuneCountInString(ask.Text) > maxAskLength {
+		abortWithProblem(c, &APIError{Code: CodeValidationError, Fields: []FieldError{newFieldError("text", FieldInvalid)}})
+		return
+	}
+
+	ctx := c.Request.Context()
+	interpretation := h.intentService.Parse(ctx, ask.Text, h.agentHandler.queryTypes.Names())
+
+	req := RecipeRequest{
+		QueryType:   interpretation.QueryType,
+		Ingredients: interpretation.IngredientNames(),
+		DishName:    interpretation.DishName,
+		Question:    interpretation.Question,
+		Locale:      ask.Locale,
+	}
+	if !interpretation.Constraints.Empty() {
+		constraints := interpretation.Constraints
+		req.Constraints = &constraints
+	}
+	if err := h.agentHandler.validateRequest(&req); err != nil {
+		err.Detail = "无法根据解析结果生成请求：" + interpretation.Summary
+		abortWithProblem(c, err)
+		return
+	}
+	c.Set(queryTypeContextKey, req.QueryType)
+
+	response := AskResponse{Success: true, Interpretation: interpretation, Request: req}
+	if !ask.DryRun {
+		result, err := h.agentHandler.buildResponse(ctx, &req)
+		if err != nil {
+			abortWithProblem(c, err)
+			return
+		}
+		response.Response = result
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+
+	"recipe-agent/internal/metrics"
+	"recipe-agent/internal/services"
+)
+
+// BatchRequest 批量查询请求结构
+type BatchRequest struct {
+	Requests []RecipeRequest `json:"requests" description:"食谱请求列表，数量不超过BATCH_MAX_ITEMS"`
+}
+
+// BatchItemResult 单个请求的处理结果，成功时包含Response，失败时包含Error
+type BatchItemResult struct {
+	Index    int             `json:"index"`
+	Success  bool            `json:"success"`
+	Response *RecipeResponse `json:"response,omitempty"`
+	Error    *Problem        `json:"error,omitempty"`
+}
+
+// BatchResponse 批量查询响应结构，单个请求失败不影响其他请求
+type BatchResponse struct {
+	Success   bool              `json:"success"`
+	Total     int               `json:"total"`
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+	Results   []BatchItemResult `json:"results"`
+}
+
+// GetRecipesBatch 批量处理食谱请求，以有限并发执行，相同的请求只处理一次
+func (h *AgentHandler) GetRecipesBatch(c *gin.Context) {
+	var batch BatchRequest
+	if err := c.ShouldBindJSON(&batch); err != nil {
+		abortWithProblem(c, &APIError{Code: CodeValidationError, Detail: err.Error()})
+		return
+	}
+
+	maxItems, concurrency := h.batch.MaxItems, h.batch.Concurrency
+	if len(batch.Requests) == 0 {
+		abortWithProblem(c, &APIError{
+			Code:   CodeValidationError,
+			Fields: []FieldError{newFieldError("requests", FieldRequired)},
+		})
+		return
+	}
+	if len(batch.Requests) > maxItems {
+		abortWithProblem(c, &APIError{
+			Code:   CodeValidationError,
+			Detail: "批量请求数量不能超过 " + strconv.Itoa(maxItems),
+			Fields: []FieldError{newFieldError("requests", FieldInvalid)},
+		})
+		return
+	}
+
+	results := make([]BatchItemResult, len(batch.Requests))
+
+	// 校验每个请求，并按规范化后的内容合并相同请求
+	groups := make(map[string][]int)
+	var order []string
+	var ingredients, dishNames []string
+	for i := range batch.Requests {
+		req := &batch.Requests[i]
+		results[i].Index = i
+		if err := h.validateRequest(req); err != nil {
+			problem := newItemProblem(c, err, req.Locale)
+			results[i].Error = &problem
+			continue
+		}
+
+		key := batchKey(req)
+		if _, exists := groups[key]; !exists {
+			order = append(order, key)
+			// 只有查询Spoonacular的类型需要翻译
+			switch req.QueryType {
+			case services.QueryTypeIngredients:
+				ingredients = append(ingredients, req.Ingredients...)
+			case services.QueryTypeDish:
+				dishNames = append(dishNames, req.DishName)
+			}
+		}
+		groups[key] = append(groups[key], i)
+	}
+
+	// 预先翻译全部食材和菜名，各请求的搜索共享翻译记忆和缓存
+	ctx := c.Request.Context()
+	h.recipeService.WarmTranslations(ctx, ingredients, dishNames)
+
+	semaphore := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for _, key := range order {
+		indexes := groups[key]
+		wg.Add(1)
+		go func(indexes []int) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			defer metrics.BatchItemStarted()()
+
+			response, problem := h.runBatchItem(ctx, c, &batch.Requests[indexes[0]])
+			for _, i := range indexes {
+				results[i].Success = problem == nil
+				results[i].Response = response
+				results[i].Error = problem
+			}
+		}(indexes)
+	}
+	wg.Wait()
+
+	summary := BatchResponse{Success: true, Total: len(results), Results: results}
+	for _, result := range results {
+		if result.Success {
+			summary.Succeeded++
+		} else {
+			summary.Failed++
+		}
+	}
+	c.JSON(http.StatusOK, summary)
+}
+
+// runBatchItem 处理批量查询中的单个请求，错误转换为 problem
+func (h *AgentHandler) runBatchItem(ctx context.Context, c *gin.Context, req *RecipeRequest) (*RecipeResponse, *Problem) {
+	response, err := h.buildResponse(ctx, req)
+	if err != nil {
+		problem := newItemProblem(c, err, req.Locale)
+		return nil, &problem
+	}
+	return response, nil
+}
+
+// batchKey 生成请求的合并键，食材顺序不影响结果；校验时已清空查询类型不使用的字段
+func batchKey(req *RecipeRequest) string {
+	ingredients := append([]string{}, req.Ingredients...)
+	sort.Strings(ingredients)
+	return strings.Join([]string{
+		req.QueryType,
+		strings.ToLower(req.Locale),
+		req.DishName,
+		req.Question,
+		strings.Join(ingredients, ","),
+		req.Constraints.Key(),
+	}, "|")
+}
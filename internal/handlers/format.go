@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"recipe-agent/internal/render"
+)
+
+// 非JSON格式的媒体类型
+const (
+	mimeMarkdown = "text/markdown"
+	mimeHTML     = "text/html"
+	mimePlain    = "text/plain"
+)
+
+// formatMIME 输出格式对应的媒体类型，按协商优先级排列
+var formatMIME = []struct {
+	format string
+	mime   string
+}{
+	{render.FormatJSON, gin.MIMEJSON},
+	{render.FormatMarkdown, mimeMarkdown},
+	{render.FormatHTML, mimeHTML},
+	{render.FormatText, mimePlain},
+}
+
+// responseFormat 根据 format 查询参数或 Accept 头选择输出格式，未指定时返回JSON
+func responseFormat(c *gin.Context) (string, *APIError) {
+	if format := c.Query("format"); format != "" {
+		for _, candidate := range formatMIME {
+			if candidate.format == format {
+				return format, nil
+			}
+		}
+		return "", &APIError{
+			Code:   CodeValidationError,
+			Fields: []FieldError{newFieldError("format", FieldInvalid)},
+		}
+	}
+
+	offered := make([]string, len(formatMIME))
+	for i, candidate := range formatMIME {
+		offered[i] = candidate.mime
+	}
+	negotiated := c.NegotiateFormat(offered...)
+	for _, candidate := range formatMIME {
+		if candidate.mime == negotiated {
+			return candidate.format, nil
+		}
+	}
+	return "", &APIError{Code: CodeNotAcceptable, Detail: "支持的格式: application/json, text/markdown, text/html, text/plain"}
+}
+
+// writeRecipeResponse 按输出格式返回食谱结果，补充数据只包含在JSON格式中
+func (h *AgentHandler) writeRecipeResponse(c *gin.Context, format string, response *RecipeResponse) {
+	c.Header("Vary", "Accept")
+	switch format {
+	case render.FormatMarkdown:
+		c.Data(http.StatusOK, mimeMarkdown+"; charset=utf-8", []byte(response.Result))
+	case render.FormatHTML:
+		c.Data(http.StatusOK, mimeHTML+"; charset=utf-8", []byte(response.ResultHTML))
+	case render.FormatText:
+		c.Data(http.StatusOK, mimePlain+"; charset=utf-8", []byte(h.renderer.Text(response.Result)))
+	default:
+		c.JSON(http.StatusOK, response)
+	}
+}
@@ -2,25 +2,122 @@ package handlers
 
 import (
 	"net/http"
-	"os"
+	"sync/atomic"
 
 	"github.com/gin-gonic/gin"
+
+	"recipe-agent/internal/buildinfo"
+	"recipe-agent/internal/health"
 )
 
+// SuccessResponse 无返回数据的成功响应结构
+type SuccessResponse struct {
+	Success bool `json:"success"`
+}
+
+// HealthResponse 健康检查响应结构
+type HealthResponse struct {
+	Status      string `json:"status" enum:"ok,shutting_down"`
+	Service     string `json:"service"`
+	Version     string `json:"version"`
+	Environment string `json:"environment"`
+}
+
+// LivenessResponse 存活检查响应结构
+type LivenessResponse struct {
+	Status      string         `json:"status" enum:"ok"`
+	Service     string         `json:"service"`
+	Environment string         `json:"environment"`
+	Build       buildinfo.Info `json:"build"`
+}
+
+// ReadinessResponse 就绪检查响应结构，关闭中时不执行检查
+type ReadinessResponse struct {
+	Status  string          `json:"status" enum:"ready,degraded,not_ready,shutting_down"`
+	Version string          `json:"version"`
+	Checks  []health.Result `json:"checks"`
+}
+
 // IndexHandler 处理首页请求
 func IndexHandler(c *gin.Context) {
 	c.HTML(http.StatusOK, "index.html", gin.H{
-		"title": "智能食谱助手",
-		"version": "2.1.0",
+		"title":   "智能食谱助手",
+		"version": buildinfo.Get().Version,
 	})
 }
 
-// HealthHandler 处理健康检查请求
-func HealthHandler(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"status": "ok",
-		"service": "recipe-agent",
-		"version": "2.1.0",
-		"environment": os.Getenv("GIN_MODE"),
-	})
-}
\ No newline at end of file
+// Readiness 服务是否就绪，关闭前置为未就绪，使负载均衡器停止转发新请求
+type Readiness struct {
+	ready atomic.Bool
+}
+
+// NewReadiness 创建未就绪的状态，服务开始监听后再置为就绪
+func NewReadiness() *Readiness {
+	return &Readiness{}
+}
+
+// SetReady 设置就绪状态
+func (r *Readiness) SetReady(ready bool) {
+	r.ready.Store(ready)
+}
+
+// Ready 是否就绪
+func (r *Readiness) Ready() bool {
+	return r.ready.Load()
+}
+
+// HealthHandler 返回健康检查处理函数，environment 为运行模式；未就绪时返回503
+func HealthHandler(environment string, readiness *Readiness) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		status, code := "ok", http.StatusOK
+		if !readiness.Ready() {
+			status, code = "shutting_down", http.StatusServiceUnavailable
+		}
+		c.JSON(code, HealthResponse{
+			Status:      status,
+			Service:     "recipe-agent",
+			Version:     buildinfo.Get().Version,
+			Environment: environment,
+		})
+	}
+}
+
+// LivenessHandler 返回存活检查处理函数：进程能处理请求即返回200，关闭过程中也不失败，避免被重启
+func LivenessHandler(environment string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, LivenessResponse{
+			Status:      "ok",
+			Service:     "recipe-agent",
+			Environment: environment,
+			Build:       buildinfo.Get(),
+		})
+	}
+}
+
+// ReadinessHandler 返回就绪检查处理函数：关闭中或关键检查失败时返回503，
+// 只有非关键检查（如上游API）失败时返回200和 degraded 状态
+func ReadinessHandler(readiness *Readiness, checker *health.Checker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		version := buildinfo.Get().Version
+		if !readiness.Ready() {
+			c.JSON(http.StatusServiceUnavailable, ReadinessResponse{Status: "shutting_down", Version: version, Checks: []health.Result{}})
+			return
+		}
+
+		report := checker.Report(c.Request.Context())
+		code := http.StatusOK
+		if report.Status == health.StatusNotReady {
+			code = http.StatusServiceUnavailable
+		}
+		public := report.Public()
+		c.JSON(code, ReadinessResponse{Status: public.Status, Version: version, Checks: public.Checks})
+	}
+}
+
+// AdminReadinessHandler 返回管理接口的就绪检查处理函数，结果包含失败的完整原因（目录路径、上游错误）
+func AdminReadinessHandler(checker *health.Checker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report := checker.Report(c.Request.Context())
+		c.JSON(http.StatusOK, ReadinessResponse{Status: report.Status, Version: buildinfo.Get().Version, Checks: report.Checks})
+	}
+}
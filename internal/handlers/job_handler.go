@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"recipe-agent/internal/services"
+)
+
+// JobKindRecipes 食谱分析任务类型
+const JobKindRecipes = "recipes"
+
+// JobHandler 异步任务处理器
+type JobHandler struct {
+	jobService     *services.JobService
+	webhookService *services.WebhookService
+	agentHandler   *AgentHandler
+}
+
+// JobRequest 异步任务请求结构，在食谱请求基础上可指定回调地址
+type JobRequest struct {
+	RecipeRequest
+	CallbackURL string `json:"callbackUrl,omitempty" description:"任务结束后接收签名回调的地址，需配置WEBHOOK_SECRET"`
+}
+
+// JobResponse 任务响应结构
+type JobResponse struct {
+	Success bool          `json:"success"`
+	Job     *services.Job `json:"job"`
+}
+
+// WebhookResponse 回调及投递记录响应结构
+type WebhookResponse struct {
+	Success bool              `json:"success"`
+	Webhook *services.Webhook `json:"webhook"`
+}
+
+// WebhookListResponse 回调列表响应结构
+type WebhookListResponse struct {
+	Success  bool                `json:"success"`
+	Total    int                 `json:"total"`
+	Webhooks []*services.Webhook `json:"webhooks"`
+}
+
+// NewJobHandler 创建异步任务处理器实例，注册食谱分析任务的执行函数和任务结束回调
+func NewJobHandler(jobService *services.JobService, webhookService *services.WebhookService, agentHandler *AgentHandler) *JobHandler {
+	jobService.Register(JobKindRecipes, agentHandler.runRecipeJob)
+	jobService.OnFinish(webhookService.JobFinished)
+	return &JobHandler{
+		jobService:     jobService,
+		webhookService: webhookService,
+		agentHandler:   agentHandler,
+	}
+}
+
+// CreateJob 提交食谱分析任务，立即返回任务ID
+func (h *JobHandler) CreateJob(c *gin.Context) {
+	var req JobRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortWithProblem(c, &APIError{Code: CodeValidationError, Detail: err.Error()})
+		return
+	}
+	c.Set(localeContextKey, req.Locale)
+
+	// 提交前完成校验，任务中只保存规范化后的请求
+	if err := h.agentHandler.validateRequest(&req.RecipeRequest); err != nil {
+		abortWithProblem(c, err)
+		return
+	}
+	if req.CallbackURL != "" {
+		if err := h.webhookService.ValidateURL(req.CallbackURL); err != nil {
+			abortWithProblem(c, &APIError{
+				Code:   CodeValidationError,
+				Detail: err.Error(),
+				Fields: []FieldError{newFieldError("callbackUrl", FieldInvalid)},
+			})
+			return
+		}
+	}
+
+	job, err := h.jobService.Submit(c.Request.Context(), JobKindRecipes, req.RecipeRequest, req.CallbackURL)
+	if err != nil {
+		abortWithProblem(c, jobError(err))
+		return
+	}
+
+	c.Header("Location", "/api/v1/jobs/"+job.ID)
+	c.JSON(http.StatusAccepted, JobResponse{Success: true, Job: job})
+}
+
+// GetJob 查询任务状态、执行阶段和结果
+func (h *JobHandler) GetJob(c *gin.Context) {
+	job, err := h.jobService.Get(c.Param("id"))
+	if err != nil {
+		abortWithProblem(c, jobError(err))
+		return
+	}
+	c.JSON(http.StatusOK, JobResponse{Success: true, Job: job})
+}
+
+// CancelJob 取消排队中或执行中的任务
+func (h *JobHandler) CancelJob(c *gin.Context) {
+	job, err := h.jobService.Cancel(c.Param("id"))
+	if err != nil {
+		abortWithProblem(c, jobError(err))
+		return
+	}
+	c.JSON(http.StatusOK, JobResponse{Success: true, Job: job})
+}
+
+// GetJobWebhook 查询任务回调的投递记录
+func (h *JobHandler) GetJobWebhook(c *gin.Context) {
+	webhook, err := h.webhookService.Get(c.Param("id"))
+	if err != nil {
+		abortWithProblem(c, jobError(err))
+		return
+	}
+	c.JSON(http.StatusOK, WebhookResponse{Success: true, Webhook: webhook})
+}
+
+// ListWebhooks 列出回调，status=dead 返回死信列表
+func (h *JobHandler) ListWebhooks(c *gin.Context) {
+	status := c.Query("status")
+	switch status {
+	case "", services.WebhookStatusPending, services.WebhookStatusDelivered, services.WebhookStatusDead:
+	default:
+		abortWithProblem(c, &APIError{
+			Code:   CodeValidationError,
+			Fields: []FieldError{newFieldError("status", FieldInvalid)},
+		})
+		return
+	}
+
+	webhooks := h.webhookService.List(status)
+	c.JSON(http.StatusOK, WebhookListResponse{Success: true, Total: len(webhooks), Webhooks: webhooks})
+}
+
+// RedeliverWebhook 重新投递回调
+func (h *JobHandler) RedeliverWebhook(c *gin.Context) {
+	webhook, err := h.webhookService.Redeliver(c.Param("id"))
+	if err != nil {
+		abortWithProblem(c, jobError(err))
+		return
+	}
+	c.JSON(http.StatusAccepted, WebhookResponse{Success: true, Webhook: webhook})
+}
+
+// jobError 将任务和回调服务错误转换为带错误码的处理错误
+func jobError(err error) *APIError {
+	switch {
+	case errors.Is(err, services.ErrJobNotFound), errors.Is(err, services.ErrWebhookNotFound):
+		return &APIError{Code: CodeNotFound, Detail: err.Error()}
+	case errors.Is(err, services.ErrJobFinished), errors.Is(err, services.ErrWebhookNotDeliverable):
+		return &APIError{Code: CodeConflict, Detail: err.Error()}
+	case errors.Is(err, services.ErrJobQueueFull):
+		return &APIError{Code: CodeQueueFull, Cause: err}
+	case errors.Is(err, services.ErrJobShutdown):
+		return &APIError{Code: CodeShuttingDown, Cause: err}
+	}
+	return &APIError{Code: CodeInternalError, Cause: err}
+}
+
+// runRecipeJob 执行食谱分析任务，结果与同步接口的响应一致
+func (h *AgentHandler) runRecipeJob(ctx context.Context, request json.RawMessage) (interface{}, error) {
+	var req RecipeRequest
+	if err := json.Unmarshal(request, &req); err != nil {
+		slog.ErrorContext(ctx, "解析任务请求失败", "error", err)
+		return nil, &services.JobError{Code: CodeInternalError, Message: localize(problemMessages[CodeInternalError], localeLanguage(""))}
+	}
+
+	response, err := h.buildResponse(ctx, &req)
+	if err != nil {
+		// 非 APIError 的错误可能包含上游地址等内部细节，原因只记录在日志中，任务结果中返回本地化的通用提示
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) {
+			slog.ErrorContext(ctx, "任务执行失败", "error", err)
+			apiErr = &APIError{Code: CodeInternalError, Cause: err}
+		}
+		message := localize(problemMessages[apiErr.Code], localeLanguage(req.Locale))
+		return nil, &services.JobError{Code: apiErr.Code, Message: message}
+	}
+
+	return response, nil
+}
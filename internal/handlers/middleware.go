@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"recipe-agent/internal/logging"
+	"recipe-agent/internal/metrics"
+	"recipe-agent/internal/tracing"
+)
+
+// RequestIDHeader 请求ID的请求头和响应头
+const RequestIDHeader = logging.RequestIDHeader
+
+// TraceIDHeader 链路ID的响应头，可用于在追踪后端或 /admin/traces 中查找本次请求
+const TraceIDHeader = "X-Trace-ID"
+
+// 请求上下文中保存的键
+const (
+	requestIDContextKey = "requestID"
+	localeContextKey    = "locale"
+	queryTypeContextKey = "queryType"
+)
+
+// RequestID 为每个请求分配请求ID，优先沿用客户端传入的X-Request-ID；
+// 请求ID同时写入请求上下文，服务中的日志、后台任务和上游请求都沿用该ID
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := strings.TrimSpace(c.GetHeader(RequestIDHeader))
+		if requestID == "" || len(requestID) > 128 {
+			requestID = newRequestID()
+		}
+		c.Set(requestIDContextKey, requestID)
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), requestID))
+		c.Header(RequestIDHeader, requestID)
+		c.Next()
+	}
+}
+
+// AccessLog 请求结束后记录访问日志，5xx为error级别，4xx为warn级别；
+// 静态资源和 quietPaths 中的路径（如健康检查、指标抓取）使用debug级别。只记录路径，不记录查询参数
+func AccessLog(quietPaths ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		path := c.Request.URL.Path
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		case strings.HasPrefix(path, "/static/"):
+			level = slog.LevelDebug
+		default:
+			for _, quiet := range quietPaths {
+				if path == quiet {
+					level = slog.LevelDebug
+				}
+			}
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", path),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", status),
+			slog.Int64("duration_ms", time.Since(start).Milliseconds()),
+			slog.Int("bytes", c.Writer.Size()),
+			slog.String("client_ip", c.ClientIP()),
+		}
+		if queryType := c.GetString(queryTypeContextKey); queryType != "" {
+			attrs = append(attrs, slog.String("query_type", queryType))
+		}
+		slog.LogAttrs(c.Request.Context(), level, "请求完成", attrs...)
+	}
+}
+
+// Recovery 捕获处理过程中的panic，记录日志后返回 INTERNAL_ERROR
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, recovered any) {
+		slog.ErrorContext(c.Request.Context(), "请求处理异常", "panic", recovered, "stack", string(debug.Stack()))
+		abortWithProblem(c, &APIError{Code: CodeInternalError})
+	})
+}
+
+// Metrics 记录每个请求的路由、状态码、耗时和查询类型，未匹配路由的请求合并统计，避免标签数量无限增长
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(c.Writer.Status())
+		metrics.ObserveRequest(route, c.Request.Method, status, c.GetString(queryTypeContextKey), time.Since(start))
+	}
+}
+
+// Tracing 为每个请求创建服务端span，沿用上游通过 traceparent 传入的链路；
+// 静态资源和 skipPaths 中的路径（如指标抓取）不记录。span 中附带请求ID和查询类型，响应头返回链路ID
+func Tracing(serviceName string, skipPaths ...string) []gin.HandlerFunc {
+	server := otelgin.Middleware(serviceName, otelgin.WithGinFilter(func(c *gin.Context) bool {
+		path := c.Request.URL.Path
+		if strings.HasPrefix(path, "/static/") {
+			return false
+		}
+		for _, skip := range skipPaths {
+			if path == skip {
+				return false
+			}
+		}
+		return true
+	}))
+
+	annotate := func(c *gin.Context) {
+		span := trace.SpanFromContext(c.Request.Context())
+		if !span.SpanContext().IsValid() {
+			c.Next()
+			return
+		}
+		c.Header(TraceIDHeader, tracing.TraceID(c.Request.Context()))
+		span.SetAttributes(attribute.String("request.id", GetRequestID(c)))
+		c.Next()
+		if queryType := c.GetString(queryTypeContextKey); queryType != "" {
+			span.SetAttributes(attribute.String("query.type", queryType))
+		}
+	}
+	return []gin.HandlerFunc{server, annotate}
+}
+
+// GetRequestID 返回当前请求的ID
+func GetRequestID(c *gin.Context) string {
+	return c.GetString(requestIDContextKey)
+}
+
+// newRequestID 生成随机请求ID
+func newRequestID() string {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(buf)
+}
+
+// AdminAuth 管理接口鉴权中间件，要求请求携带 Authorization: Bearer <token>，token 为空时管理接口不可用
+func AdminAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			abortWithProblem(c, &APIError{Code: CodeAdminDisabled})
+			return
+		}
+
+		// 认证方案不区分大小写，缺少 Bearer 方案的请求头一律拒绝
+		scheme, provided, found := strings.Cut(strings.TrimSpace(c.GetHeader("Authorization")), " ")
+		if !found || !strings.EqualFold(scheme, "Bearer") ||
+			subtle.ConstantTimeCompare([]byte(strings.TrimSpace(provided)), []byte(token)) != 1 {
+			abortWithProblem(c, &APIError{Code: CodeUnauthorized})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// Deprecated 为已弃用的路由添加 Deprecation 和 Link 响应头，指向新版本接口
+func Deprecated(successor string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Deprecation", "true")
+		c.Header("Link", "<"+successor+">; rel=\"successor-version\"")
+		c.Next()
+	}
+}
@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"recipe-agent/internal/services"
+)
+
+// ProblemContentType RFC 7807 错误响应的媒体类型
+const ProblemContentType = "application/problem+json"
+
+// 稳定的错误码，客户端应依据错误码而不是文案处理错误
+const (
+	CodeValidationError  = "VALIDATION_ERROR"
+	CodeUnauthorized     = "UNAUTHORIZED"
+	CodeAdminDisabled    = "ADMIN_DISABLED"
+	CodeNotFound         = "NOT_FOUND"
+	CodeConflict         = "CONFLICT"
+	CodeNotAcceptable    = "NOT_ACCEPTABLE"
+	CodeAmbiguousInput   = "AMBIGUOUS_INPUT"
+	CodeQueueFull        = "QUEUE_FULL"
+	CodeShuttingDown     = "SHUTTING_DOWN"
+	CodeAIUnavailable    = "AI_UNAVAILABLE"
+	CodeQuotaExceeded    = "QUOTA_EXCEEDED"
+	CodeAllSourcesFailed = "ALL_SOURCES_FAILED"
+	CodeInternalError    = "INTERNAL_ERROR"
+)
+
+// 字段校验错误码
+const (
+	FieldRequired  = "required"
+	FieldInvalid   = "invalid"
+	FieldEmpty     = "empty"
+	FieldAmbiguous = "ambiguous"
+)
+
+// problemMessages 错误码对应的标题，按语言区分
+var problemMessages = map[string]map[string]string{
+	CodeValidationError:  {"zh": "请求参数校验失败", "en": "The request failed validation"},
+	CodeUnauthorized:     {"zh": "管理令牌无效", "en": "The admin token is invalid"},
+	CodeAdminDisabled:    {"zh": "管理接口未启用，请配置ADMIN_TOKEN", "en": "Admin API is disabled; set ADMIN_TOKEN to enable it"},
+	CodeNotFound:         {"zh": "资源不存在", "en": "The resource was not found"},
+	CodeConflict:         {"zh": "资源当前状态不允许该操作", "en": "The resource is in a state that does not allow this operation"},
+	CodeNotAcceptable:    {"zh": "不支持请求的响应格式", "en": "None of the requested response formats is supported"},
+	CodeAmbiguousInput:   {"zh": "输入的名称无法确定，请从建议中选择", "en": "Some names are ambiguous; please choose from the suggestions"},
+	CodeQueueFull:        {"zh": "任务队列已满，请稍后重试", "en": "The job queue is full; please retry later"},
+	CodeShuttingDown:     {"zh": "服务正在重启，请稍后重试", "en": "The service is restarting; please retry later"},
+	CodeAIUnavailable:    {"zh": "AI服务暂不可用，且没有可用的食谱数据", "en": "The AI service is unavailable and no recipe data could be found"},
+	CodeQuotaExceeded:    {"zh": "上游服务调用额度已用尽，请稍后重试", "en": "An upstream quota has been exceeded; please retry later"},
+	CodeAllSourcesFailed: {"zh": "所有数据源都不可用，请稍后重试", "en": "All data sources failed; please retry later"},
+	CodeInternalError:    {"zh": "服务处理失败，请稍后重试", "en": "Internal server error; please retry later"},
+}
+
+// fieldMessages 字段校验错误的说明，按语言区分
+var fieldMessages = map[string]map[string]string{
+	FieldRequired:  {"zh": "该字段为必填项", "en": "This field is required"},
+	FieldInvalid:   {"zh": "字段取值无效", "en": "This field has an invalid value"},
+	FieldEmpty:     {"zh": "清理后内容为空", "en": "This field is empty after normalization"},
+	FieldAmbiguous: {"zh": "有多个相近的名称", "en": "This value matches several known names"},
+}
+
+// problemStatus 错误码对应的HTTP状态码
+var problemStatus = map[string]int{
+	CodeValidationError:  http.StatusBadRequest,
+	CodeUnauthorized:     http.StatusUnauthorized,
+	CodeAdminDisabled:    http.StatusForbidden,
+	CodeNotFound:         http.StatusNotFound,
+	CodeConflict:         http.StatusConflict,
+	CodeNotAcceptable:    http.StatusNotAcceptable,
+	CodeAmbiguousInput:   http.StatusUnprocessableEntity,
+	CodeQueueFull:        http.StatusServiceUnavailable,
+	CodeShuttingDown:     http.StatusServiceUnavailable,
+	CodeAIUnavailable:    http.StatusServiceUnavailable,
+	CodeQuotaExceeded:    http.StatusTooManyRequests,
+	CodeAllSourcesFailed: http.StatusBadGateway,
+	CodeInternalError:    http.StatusInternalServerError,
+}
+
+// FieldError 单个字段的校验错误
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code" enum:"required,invalid,empty,ambiguous"`
+	Message string `json:"message"`
+	// Suggestions 名称有歧义时可选的已知名称
+	Suggestions []string `json:"suggestions,omitempty"`
+}
+
+// Problem RFC 7807 错误响应
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      string       `json:"code" enum:"VALIDATION_ERROR,UNAUTHORIZED,ADMIN_DISABLED,NOT_FOUND,CONFLICT,NOT_ACCEPTABLE,AMBIGUOUS_INPUT,QUEUE_FULL,SHUTTING_DOWN,AI_UNAVAILABLE,QUOTA_EXCEEDED,ALL_SOURCES_FAILED,INTERNAL_ERROR"`
+	RequestID string       `json:"requestId,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// ContentType 文档中使用的媒体类型
+func (Problem) ContentType() string {
+	return ProblemContentType
+}
+
+// APIError 带错误码的处理错误，Cause 只记录日志，不返回给客户端
+type APIError struct {
+	Code   string
+	Detail string
+	Fields []FieldError
+	Cause  error
+}
+
+// Error 实现error接口
+func (e *APIError) Error() string {
+	if e.Cause != nil {
+		return e.Code + ": " + e.Cause.Error()
+	}
+	return e.Code + ": " + e.Detail
+}
+
+// Unwrap 返回原始错误
+func (e *APIError) Unwrap() error {
+	return e.Cause
+}
+
+// newFieldError 创建字段校验错误，说明在响应时按语言填充
+func newFieldError(field, code string) FieldError {
+	return FieldError{Field: field, Code: code}
+}
+
+// sourcesError 根据各数据源的失败原因选择错误码：任一数据源额度用尽时为 QUOTA_EXCEEDED，
+// 有数据源未发起查询（如未配置、置信度过低）或只有AI一个数据源时为 AI_UNAVAILABLE，否则为 ALL_SOURCES_FAILED
+func sourcesError(results []services.SourceResult) *APIError {
+	causes := make([]string, 0, len(results))
+	quota, skipped := false, false
+	for _, result := range results {
+		if result.Err == nil && result.Output.Skipped != "" {
+			skipped = true
+			causes = append(causes, fmt.Sprintf("%s: 未查询(%s)", result.Source, result.Output.Skipped))
+			continue
+		}
+		causes = append(causes, fmt.Sprintf("%s: %v", result.Source, result.Err))
+		if services.SourceErrorCode(result.Err) == services.SourceErrorQuotaExceeded {
+			quota = true
+		}
+	}
+
+	cause := errors.New(strings.Join(causes, "; "))
+	switch {
+	case quota:
+		return &APIError{Code: CodeQuotaExceeded, Cause: cause}
+	case skipped || len(results) == 1:
+		return &APIError{Code: CodeAIUnavailable, Cause: cause}
+	default:
+		return &APIError{Code: CodeAllSourcesFailed, Cause: cause}
+	}
+}
+
+// abortWithProblem 返回 problem+json 错误响应并记录原始错误
+func abortWithProblem(c *gin.Context, err error) {
+	problem := newProblem(c, err)
+	c.Header("Content-Type", ProblemContentType)
+	c.AbortWithStatusJSON(problem.Status, problem)
+}
+
+// newProblem 将错误转换为 problem 响应并记录原始错误，文案语言取自请求
+func newProblem(c *gin.Context, err error) Problem {
+	return newLocalizedProblem(c, err, requestLanguage(c))
+}
+
+// newItemProblem 将批量查询中单个请求的错误转换为 problem，文案语言取自该请求的 locale，未指定时取自外层请求
+func newItemProblem(c *gin.Context, err error, locale string) Problem {
+	language := requestLanguage(c)
+	if strings.TrimSpace(locale) != "" {
+		language = localeLanguage(locale)
+	}
+	return newLocalizedProblem(c, err, language)
+}
+
+// newLocalizedProblem 将错误转换为指定语言的 problem 并记录原始错误，
+// 错误链中没有 APIError 时视为内部错误
+func newLocalizedProblem(c *gin.Context, err error, language string) Problem {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		apiErr = &APIError{Code: CodeInternalError, Cause: err}
+	}
+
+	requestID := GetRequestID(c)
+	status, exists := problemStatus[apiErr.Code]
+	if !exists {
+		status = http.StatusInternalServerError
+	}
+
+	if apiErr.Cause != nil {
+		level := slog.LevelWarn
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		slog.Log(c.Request.Context(), level, "请求失败", "code", apiErr.Code, "status", status, "error", apiErr.Cause)
+	}
+
+	problem := Problem{
+		Type:      "urn:recipe-agent:problem:" + strings.ToLower(strings.ReplaceAll(apiErr.Code, "_", "-")),
+		Title:     localize(problemMessages[apiErr.Code], language),
+		Status:    status,
+		Detail:    apiErr.Detail,
+		Instance:  c.Request.URL.Path,
+		Code:      apiErr.Code,
+		RequestID: requestID,
+	}
+	for _, field := range apiErr.Fields {
+		if field.Message == "" {
+			field.Message = localize(fieldMessages[field.Code], language)
+		}
+		problem.Errors = append(problem.Errors, field)
+	}
+	return problem
+}
+
+// requestLanguage 根据请求中的locale或Accept-Language选择错误文案语言，默认中文
+func requestLanguage(c *gin.Context) string {
+	locale := c.GetString(localeContextKey)
+	if locale == "" {
+		locale = c.GetHeader("Accept-Language")
+	}
+	return localeLanguage(locale)
+}
+
+// localeLanguage 返回locale对应的文案语言，目前支持中文和英文
+func localeLanguage(locale string) string {
+	if strings.HasPrefix(strings.ToLower(strings.TrimSpace(locale)), "en") {
+		return "en"
+	}
+	return "zh"
+}
+
+// localize 返回指定语言的文案，缺失时使用中文
+func localize(messages map[string]string, language string) string {
+	if message, exists := messages[language]; exists {
+		return message
+	}
+	return messages["zh"]
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newTestContext 创建带有 Accept-Language 请求头的测试上下文
+func newTestContext(acceptLanguage string) *gin.Context {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/recipes/batch", nil)
+	c.Request.Header.Set("Accept-Language", acceptLanguage)
+	return c
+}
+
+func TestNewProblemUnwrapsAPIError(t *testing.T) {
+	c := newTestContext("zh-CN")
+	wrapped := fmt.Errorf("构建响应失败: %w", &APIError{Code: CodeNotFound, Detail: "缓存不存在"})
+
+	problem := newProblem(c, wrapped)
+	if problem.Code != CodeNotFound || problem.Status != http.StatusNotFound {
+		t.Errorf("problem = %s %d, want %s %d", problem.Code, problem.Status, CodeNotFound, http.StatusNotFound)
+	}
+	if problem.Detail != "缓存不存在" {
+		t.Errorf("detail = %q, want 缓存不存在", problem.Detail)
+	}
+
+	problem = newProblem(c, errors.New("dial tcp: connection refused"))
+	if problem.Code != CodeInternalError || problem.Detail != "" {
+		t.Errorf("problem = %s %q, want %s without detail", problem.Code, problem.Detail, CodeInternalError)
+	}
+}
+
+func TestNewItemProblemUsesItemLocale(t *testing.T) {
+	c := newTestContext("zh-CN")
+	err := &APIError{Code: CodeValidationError, Fields: []FieldError{newFieldError("dishName", FieldRequired)}}
+
+	tests := []struct {
+		locale   string
+		language string
+	}{
+		{"en-US", "en"},
+		{"zh-CN", "zh"},
+		{"", "zh"},
+	}
+	for _, tt := range tests {
+		problem := newItemProblem(c, err, tt.locale)
+		if want := localize(problemMessages[CodeValidationError], tt.language); problem.Title != want {
+			t.Errorf("locale %q: title = %q, want %q", tt.locale, problem.Title, want)
+		}
+		if want := localize(fieldMessages[FieldRequired], tt.language); problem.Errors[0].Message != want {
+			t.Errorf("locale %q: field message = %q, want %q", tt.locale, problem.Errors[0].Message, want)
+		}
+	}
+
+	// 未指定 locale 时沿用外层请求的语言
+	problem := newItemProblem(newTestContext("en"), err, "")
+	if want := localize(problemMessages[CodeValidationError], "en"); problem.Title != want {
+		t.Errorf("title = %q, want %q from Accept-Language", problem.Title, want)
+	}
+}
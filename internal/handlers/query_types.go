@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"recipe-agent/internal/services"
+)
+
+// maxQuestionLength 问答类型问题的最大字符数
+const maxQuestionLength = 500
+
+// maxIngredients 单个请求最多包含的食材数，maxNameLength 食材名和菜名的最大字符数
+const (
+	maxIngredients = 20
+	maxNameLength  = 30
+)
+
+// InputRule 查询类型对请求字段的要求
+type InputRule int
+
+const (
+	// InputUnused 不使用该字段，校验时清空
+	InputUnused InputRule = iota
+	// InputOptional 可选字段
+	InputOptional
+	// InputRequired 必填字段
+	InputRequired
+)
+
+// QueryType 查询类型：声明使用的请求字段，可附加自定义校验，并由自己的聚合管道
+// （提示词模板、数据源和合并策略）生成结果
+type QueryType struct {
+	Name        string
+	Ingredients InputRule
+	DishName    InputRule
+	Question    InputRule
+	// Validate 字段规则检查通过后的自定义校验，可为空
+	Validate func(req *RecipeRequest) []FieldError
+	Pipeline *services.Pipeline
+}
+
+// QueryTypeRegistry 查询类型注册表
+type QueryTypeRegistry struct {
+	types map[string]QueryType
+	mutex sync.RWMutex
+}
+
+// NewQueryTypeRegistry 创建空的查询类型注册表
+func NewQueryTypeRegistry() *QueryTypeRegistry {
+	return &QueryTypeRegistry{types: make(map[string]QueryType)}
+}
+
+// Register 注册查询类型，同名类型会被替换
+func (r *QueryTypeRegistry) Register(queryType QueryType) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.types[queryType.Name] = queryType
+}
+
+// Get 返回查询类型
+func (r *QueryTypeRegistry) Get(name string) (QueryType, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	queryType, exists := r.types[name]
+	return queryType, exists
+}
+
+// Names 返回已注册的查询类型名称
+func (r *QueryTypeRegistry) Names() []string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	names := make([]string, 0, len(r.types))
+	for name := range r.types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// builtinQueryTypes 内置查询类型：食材推荐和菜品详情同时查询AI和Spoonacular，其余类型只使用AI
+func builtinQueryTypes(recipeService *services.RecipeService, aiService *services.AIService) []QueryType {
+	aiOnly := func(template services.PromptTemplate) *services.Pipeline {
+		return services.NewPipeline(
+			services.NewRecipeCombiner(recipeService, "", nil),
+			services.NewAISource(aiService, template),
+		)
+	}
+
+	return []QueryType{
+		{
+			Name:        services.QueryTypeIngredients,
+			Ingredients: InputRequired,
+			Pipeline: services.NewPipeline(
+				services.NewRecipeCombiner(recipeService, services.IngredientsReferenceHeading, services.IngredientsFallback),
+				services.NewAISource(aiService, services.IngredientsPrompt),
+				services.NewSpoonacularSource(recipeService, services.SearchByIngredients),
+			),
+		},
+		{
+			Name:     services.QueryTypeDish,
+			DishName: InputRequired,
+			Pipeline: services.NewPipeline(
+				services.NewRecipeCombiner(recipeService, services.DishReferenceHeading, services.DishFallback),
+				services.NewAISource(aiService, services.DishPrompt),
+				services.NewSpoonacularSource(recipeService, services.SearchByDishName),
+			),
+		},
+		{
+			// 替代食材X，可选填写准备制作的菜品
+			Name:        services.QueryTypeSubstitute,
+			Ingredients: InputRequired,
+			DishName:    InputOptional,
+			Pipeline:    aiOnly(services.SubstitutePrompt),
+		},
+		{
+			// 菜品Y的搭配
+			Name:     services.QueryTypePairing,
+			DishName: InputRequired,
+			Pipeline: aiOnly(services.PairingPrompt),
+		},
+		{
+			// 改造剩菜，可选填写家里现有的食材
+			Name:        services.QueryTypeLeftover,
+			DishName:    InputRequired,
+			Ingredients: InputOptional,
+			Pipeline:    aiOnly(services.LeftoverPrompt),
+		},
+		{
+			Name:     services.QueryTypeQuestion,
+			Question: InputRequired,
+			Validate: func(req *RecipeRequest) []FieldError {
+				if utf8.RuneCountInString(req.Question) > maxQuestionLength {
+					return []FieldError{newFieldError("question", FieldInvalid)}
+				}
+				return nil
+			},
+			Pipeline: aiOnly(services.QuestionPrompt),
+		},
+	}
+}
+
+// validateInputs 按查询类型的字段规则校验并规范化请求，不使用的字段会被清空
+func (h *AgentHandler) validateInputs(queryType QueryType, req *RecipeRequest) []FieldError {
+	var fields []FieldError
+
+	switch queryType.Ingredients {
+	case InputUnused:
+		req.Ingredients = nil
+	default:
+		if len(req.Ingredients) == 0 {
+			if queryType.Ingredients == InputRequired {
+				fields = append(fields, newFieldError("ingredients", FieldRequired))
+			}
+			break
+		}
+		if len(req.Ingredients) > maxIngredients {
+			fields = append(fields, newFieldError("ingredients", FieldInvalid))
+			break
+		}
+		// 纠正拼写错误后按食材本体规范化（同义词合并、去重）
+		for i, ingredient := range req.Ingredients {
+			field := fmt.Sprintf("ingredients[%d]", i)
+			if utf8.RuneCountInString(strings.TrimSpace(ingredient)) > maxNameLength {
+				fields = append(fields, newFieldError(field, FieldInvalid))
+				continue
+			}
+			corrected, fieldErr := h.correct(req, field, services.CorrectionKindIngredient, ingredient)
+			if fieldErr != nil {
+				fields = append(fields, *fieldErr)
+			}
+			req.Ingredients[i] = corrected
+		}
+		cleanedIngredients := h.ontology.CanonicalizeAll(req.Ingredients)
+		if len(cleanedIngredients) == 0 {
+			fields = append(fields, newFieldError("ingredients", FieldEmpty))
+			break
+		}
+		req.Ingredients = cleanedIngredients
+	}
+
+	req.DishName = strings.TrimSpace(req.DishName)
+	switch {
+	case queryType.DishName == InputUnused:
+		req.DishName = ""
+	case queryType.DishName == InputRequired && req.DishName == "":
+		fields = append(fields, newFieldError("dishName", FieldRequired))
+	case utf8.RuneCountInString(req.DishName) > maxNameLength:
+		fields = append(fields, newFieldError("dishName", FieldInvalid))
+	case req.DishName != "":
+		corrected, fieldErr := h.correct(req, "dishName", services.CorrectionKindDish, req.DishName)
+		if fieldErr != nil {
+			fields = append(fields, *fieldErr)
+		}
+		req.DishName = corrected
+	}
+
+	req.Question = strings.TrimSpace(req.Question)
+	switch {
+	case queryType.Question == InputUnused:
+		req.Question = ""
+	case queryType.Question == InputRequired && req.Question == "":
+		fields = append(fields, newFieldError("question", FieldRequired))
+	}
+
+	if len(fields) == 0 && queryType.Validate != nil {
+		fields = queryType.Validate(req)
+	}
+	return fields
+}
+
+// correct 纠正拼写错误的名称并记录到请求中；有多个相近名称时返回带建议的字段错误，名称保持不变
+func (h *AgentHandler) correct(req *RecipeRequest, field, kind, name string) (string, *FieldError) {
+	result := h.corrector.Correct(kind, name)
+	switch result.Status {
+	case services.CorrectionCorrected:
+		req.Corrections = append(req.Corrections, services.Correction{
+			Field:      field,
+			Original:   name,
+			Corrected:  result.Corrected,
+			Similarity: result.Similarity,
+		})
+		return result.Corrected, nil
+	case services.CorrectionAmbiguous:
+		fieldErr := newFieldError(field, FieldAmbiguous)
+		fieldErr.Suggestions = result.Suggestions
+		return name, &fieldErr
+	default:
+		return name, nil
+	}
+}
+
+// validateConstraints 校验并清理附加要求，没有任何要求时置为空
+func (h *AgentHandler) validateConstraints(req *RecipeRequest) []FieldError {
+	constraints := req.Constraints
+	if constraints == nil {
+		return nil
+	}
+
+	var fields []FieldError
+	if constraints.MaxMinutes < 0 {
+		fields = append(fields, newFieldError("constraints.maxMinutes", FieldInvalid))
+	}
+	if constraints.Servings < 0 {
+		fields = append(fields, newFieldError("constraints.servings", FieldInvalid))
+	}
+
+	constraints.Avoid = cleanStrings(constraints.Avoid)
+	constraints.Diet = cleanStrings(constraints.Diet)
+	if len(constraints.Quantities) > 0 {
+		quantities := make(map[string]string, len(constraints.Quantities))
+		for name, quantity := range constraints.Quantities {
+			name = h.ontology.Canonicalize(name)
+			quantity = strings.TrimSpace(quantity)
+			if name != "" && quantity != "" {
+				quantities[name] = quantity
+			}
+		}
+		constraints.Quantities = quantities
+	}
+
+	if constraints.Empty() {
+		req.Constraints = nil
+	}
+	return fields
+}
+
+// cleanStrings 去除空白、空值和重复项并保持顺序
+func cleanStrings(items []string) []string {
+	var result []string
+	seen := make(map[string]bool)
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if item == "" || seen[item] {
+			continue
+		}
+		seen[item] = true
+		result = append(result, item)
+	}
+	return result
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"recipe-agent/internal/config"
+	"recipe-agent/internal/services"
+)
+
+// newTestAgentHandler 创建只包含校验所需依赖的处理器
+func newTestAgentHandler() *AgentHandler {
+	data := config.Data{
+		IngredientsFile: filepath.Join("..", "..", "data", "ingredients.json"),
+		DishesFile:      filepath.Join("..", "..", "data", "dishes.json"),
+	}
+	ontology := services.NewIngredientOntology(data)
+	return &AgentHandler{ontology: ontology, corrector: services.NewCorrectionService(data, ontology)}
+}
+
+func TestValidateInputsLimits(t *testing.T) {
+	h := newTestAgentHandler()
+	queryType := QueryType{Ingredients: InputOptional, DishName: InputOptional}
+
+	tests := []struct {
+		name  string
+		req   RecipeRequest
+		field string
+	}{
+		{"too many ingredients", RecipeRequest{Ingredients: make([]string, maxIngredients+1)}, "ingredients"},
+		{"overlong ingredient", RecipeRequest{Ingredients: []string{"鸡蛋", strings.Repeat("蛋", maxNameLength+1)}}, "ingredients[1]"},
+		{"overlong dish name", RecipeRequest{DishName: strings.Repeat("菜", maxNameLength+1)}, "dishName"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := h.validateInputs(queryType, &tt.req)
+			if len(fields) != 1 || fields[0].Field != tt.field || fields[0].Code != FieldInvalid {
+				t.Errorf("fields = %+v, want %s invalid", fields, tt.field)
+			}
+		})
+	}
+
+	req := RecipeRequest{Ingredients: []string{"鸡蛋", "番茄"}, DishName: "西红柿炒鸡蛋"}
+	if fields := h.validateInputs(queryType, &req); len(fields) != 0 {
+		t.Errorf("fields = %+v, want none for request within limits", fields)
+	}
+}
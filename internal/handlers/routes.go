@@ -0,0 +1,328 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"recipe-agent/internal/config"
+	"recipe-agent/internal/health"
+	"recipe-agent/internal/openapi"
+	"recipe-agent/internal/services"
+)
+
+// RegisterAPIRoutes 注册 /api/v1 和 /admin 路由并生成对应的OpenAPI文档
+// 旧的 /api/* 路由保留为已弃用的别名
+func RegisterAPIRoutes(r *gin.Engine, spec *openapi.Generator, cfg *config.Config, agentHandler *AgentHandler, suggestHandler *SuggestHandler, translationHandler *TranslationHandler, jobHandler *JobHandler, askHandler *AskHandler, traceHandler *TraceHandler, adminHandler *AdminHandler, readiness *Readiness, checker *health.Checker) {
+	v1 := NewAPIRouter(r.Group("/api/v1"), spec)
+
+	v1.POST("/recipes", openapi.Operation{
+		Summary:     "获取食谱分析",
+		Description: "按食材推荐菜品或按菜名获取制作方法，整合AI分析和Spoonacular食谱。拼写错误的食材和菜名会自动纠正并在 corrections 中列出，有多个相近名称时返回 AMBIGUOUS_INPUT 和建议。通过 format 参数或 Accept 头选择返回JSON（默认，含安全HTML和结构化食谱）、Markdown、过滤后的HTML或纯文本。",
+		Tags:        []string{"recipes"},
+		Request:     RecipeRequest{},
+		Query: []openapi.Param{
+			{Name: "format", Description: "输出格式，优先于Accept头", Enum: []string{"json", "markdown", "html", "text"}},
+		},
+		Produces: []string{mimeMarkdown, mimeHTML, mimePlain},
+		Responses: map[int]interface{}{
+			http.StatusOK:                  RecipeResponse{},
+			http.StatusBadRequest:          Problem{},
+			http.StatusNotAcceptable:       Problem{},
+			http.StatusUnprocessableEntity: Problem{},
+			http.StatusTooManyRequests:     Problem{},
+			http.StatusInternalServerError: Problem{},
+			http.StatusBadGateway:          Problem{},
+			http.StatusServiceUnavailable:  Problem{},
+		},
+	}, agentHandler.GetRecipes)
+
+	v1.POST("/recipes/batch", openapi.Operation{
+		Summary:     "批量获取食谱分析",
+		Description: "以有限并发处理多个食谱请求，食材和菜名预先统一翻译，相同请求只处理一次。单个请求失败时在对应结果中返回错误，不影响其他请求。",
+		Tags:        []string{"recipes"},
+		Request:     BatchRequest{},
+		Responses: map[int]interface{}{
+			http.StatusOK:         BatchResponse{},
+			http.StatusBadRequest: Problem{},
+		},
+	}, agentHandler.GetRecipesBatch)
+
+	v1.POST("/ask", openapi.Operation{
+		Summary:     "自然语言查询",
+		Description: "将一句话（如“冰箱里有两个鸡蛋和半颗白菜，想做个20分钟的快手菜，不要辣”）解析为结构化请求：查询类型、带用量的食材和附加要求。先按规则解析，无法判断时由AI解析。响应中返回解析结果和生成的请求，用户可修改后提交到 /api/v1/recipes；dryRun 为true时只解析不查询。",
+		Tags:        []string{"recipes"},
+		Request:     AskRequest{},
+		Responses: map[int]interface{}{
+			http.StatusOK:                  AskResponse{},
+			http.StatusBadRequest:          Problem{},
+			http.StatusUnprocessableEntity: Problem{},
+			http.StatusTooManyRequests:     Problem{},
+			http.StatusInternalServerError: Problem{},
+			http.StatusBadGateway:          Problem{},
+			http.StatusServiceUnavailable:  Problem{},
+		},
+	}, askHandler.Ask)
+
+	v1.POST("/jobs", openapi.Operation{
+		Summary:     "提交异步食谱分析任务",
+		Description: "立即返回任务ID，通过 GET /api/v1/jobs/{id} 查询执行阶段和结果。结果在任务结束后保留 JOB_RESULT_TTL。",
+		Tags:        []string{"jobs"},
+		Request:     JobRequest{},
+		Responses: map[int]interface{}{
+			http.StatusAccepted:            JobResponse{},
+			http.StatusBadRequest:          Problem{},
+			http.StatusUnprocessableEntity: Problem{},
+			http.StatusServiceUnavailable:  Problem{},
+		},
+	}, jobHandler.CreateJob)
+
+	v1.GET("/jobs/:id", openapi.Operation{
+		Summary: "查询任务",
+		Tags:    []string{"jobs"},
+		Responses: map[int]interface{}{
+			http.StatusOK:       JobResponse{},
+			http.StatusNotFound: Problem{},
+		},
+	}, jobHandler.GetJob)
+
+	v1.POST("/jobs/:id/cancel", openapi.Operation{
+		Summary: "取消任务",
+		Tags:    []string{"jobs"},
+		Responses: map[int]interface{}{
+			http.StatusOK:       JobResponse{},
+			http.StatusNotFound: Problem{},
+			http.StatusConflict: Problem{},
+		},
+	}, jobHandler.CancelJob)
+
+	v1.GET("/jobs/:id/webhook", openapi.Operation{
+		Summary:     "查询任务回调投递记录",
+		Description: "回调请求头 X-Webhook-Signature 为 sha256=HMAC-SHA256(WEBHOOK_SECRET, X-Webhook-Timestamp + \".\" + 请求体)。",
+		Tags:        []string{"jobs"},
+		Responses: map[int]interface{}{
+			http.StatusOK:       WebhookResponse{},
+			http.StatusNotFound: Problem{},
+		},
+	}, jobHandler.GetJobWebhook)
+
+	v1.GET("/suggest", openapi.Operation{
+		Summary:     "自动补全",
+		Description: "支持前缀、拼音首字母和全拼匹配。",
+		Tags:        []string{"suggest"},
+		Query: []openapi.Param{
+			{Name: "type", Description: "建议类型", Enum: []string{services.SuggestTypeIngredient, services.SuggestTypeDish}},
+			{Name: "q", Description: "查询词", Required: true},
+			{Name: "limit", Description: "返回数量，默认8，最大50", Type: "integer"},
+		},
+		Responses: map[int]interface{}{
+			http.StatusOK:         SuggestResponse{},
+			http.StatusBadRequest: Problem{},
+		},
+	}, suggestHandler.Suggest)
+
+	v1.GET("/health", openapi.Operation{
+		Summary:     "健康检查",
+		Description: "服务收到退出信号后返回503，负载均衡器应停止转发新请求。",
+		Tags:        []string{"system"},
+		Responses: map[int]interface{}{
+			http.StatusOK:                 HealthResponse{},
+			http.StatusServiceUnavailable: HealthResponse{},
+		},
+	}, HealthHandler(cfg.Server.Mode, readiness))
+
+	// Kubernetes 风格的存活和就绪探针
+	r.GET("/healthz", LivenessHandler(cfg.Server.Mode))
+	spec.Add(http.MethodGet, "/healthz", openapi.Operation{
+		Summary:     "存活检查",
+		Description: "进程能处理请求即返回200，附带版本、提交和构建时间；关闭过程中也返回200。",
+		Tags:        []string{"system"},
+		Responses:   map[int]interface{}{http.StatusOK: LivenessResponse{}},
+	})
+	r.GET("/readyz", ReadinessHandler(readiness, checker))
+	spec.Add(http.MethodGet, "/readyz", openapi.Operation{
+		Summary:     "就绪检查",
+		Description: "检查持久化目录、任务工作池、缓存和上游API。关闭中或关键检查失败时返回503；上游API不可达只降级为 degraded，仍返回200。上游探测结果缓存 HEALTH_PROBE_INTERVAL。",
+		Tags:        []string{"system"},
+		Responses: map[int]interface{}{
+			http.StatusOK:                 ReadinessResponse{},
+			http.StatusServiceUnavailable: ReadinessResponse{},
+		},
+	})
+
+	r.GET("/api/v1/openapi.json", OpenAPIHandler(spec))
+	r.GET("/api/v1/docs", APIDocsHandler)
+
+	// 已弃用的旧版路由
+	r.POST("/api/recipes", Deprecated("/api/v1/recipes"), agentHandler.GetRecipes)
+	r.GET("/api/suggest", Deprecated("/api/v1/suggest"), suggestHandler.Suggest)
+	r.GET("/api/health", Deprecated("/api/v1/health"), HealthHandler(cfg.Server.Mode, readiness))
+	spec.Add(http.MethodPost, "/api/recipes", openapi.Operation{
+		Summary:     "获取食谱分析（已弃用）",
+		Description: "请使用 POST /api/v1/recipes。",
+		Tags:        []string{"recipes"},
+		Request:     RecipeRequest{},
+		Responses:   map[int]interface{}{http.StatusOK: RecipeResponse{}},
+		Deprecated:  true,
+	})
+
+	// 管理接口
+	admin := NewAPIRouter(r.Group("/admin", AdminAuth(cfg.Admin.Token)), spec)
+	adminResponses := func(ok interface{}) map[int]interface{} {
+		return map[int]interface{}{
+			http.StatusOK:           ok,
+			http.StatusBadRequest:   Problem{},
+			http.StatusUnauthorized: Problem{},
+			http.StatusForbidden:    Problem{},
+			http.StatusNotFound:     Problem{},
+		}
+	}
+
+	admin.GET("/translations", openapi.Operation{
+		Summary:   "列出翻译条目",
+		Tags:      []string{"admin"},
+		Query:     []openapi.Param{{Name: "kind", Description: "翻译类型", Enum: []string{services.TranslationKindIngredient, services.TranslationKindDish}}},
+		Responses: adminResponses(TranslationListResponse{}),
+		Security:  "bearerAuth",
+	}, translationHandler.ListTranslations)
+
+	admin.GET("/translations/:kind/:source", openapi.Operation{
+		Summary:   "查看翻译条目",
+		Tags:      []string{"admin"},
+		Responses: adminResponses(TranslationEntryResponse{}),
+		Security:  "bearerAuth",
+	}, translationHandler.GetTranslation)
+
+	admin.PUT("/translations/:kind/:source", openapi.Operation{
+		Summary:   "修正译文",
+		Tags:      []string{"admin"},
+		Request:   TranslationCorrection{},
+		Responses: adminResponses(TranslationEntryResponse{}),
+		Security:  "bearerAuth",
+	}, translationHandler.CorrectTranslation)
+
+	admin.POST("/translations/:kind/:source/approve", openapi.Operation{
+		Summary:   "审核通过翻译条目",
+		Tags:      []string{"admin"},
+		Responses: adminResponses(TranslationEntryResponse{}),
+		Security:  "bearerAuth",
+	}, translationHandler.ApproveTranslation)
+
+	admin.GET("/webhooks", openapi.Operation{
+		Summary:   "列出任务回调",
+		Tags:      []string{"admin"},
+		Query:     []openapi.Param{{Name: "status", Description: "回调状态，dead 为死信列表", Enum: []string{services.WebhookStatusPending, services.WebhookStatusDelivered, services.WebhookStatusDead}}},
+		Responses: adminResponses(WebhookListResponse{}),
+		Security:  "bearerAuth",
+	}, jobHandler.ListWebhooks)
+
+	admin.POST("/webhooks/:id/redeliver", openapi.Operation{
+		Summary:   "重新投递回调",
+		Tags:      []string{"admin"},
+		Responses: adminResponses(WebhookResponse{}),
+		Security:  "bearerAuth",
+	}, jobHandler.RedeliverWebhook)
+
+	admin.GET("/traces", openapi.Operation{
+		Summary:     "查看最近的链路",
+		Description: "列出内存导出器保存的span，按开始时间排列。只在 TRACING_EXPORTER=memory 时可用，链路ID见响应头 X-Trace-ID。",
+		Tags:        []string{"admin"},
+		Query:       []openapi.Param{{Name: "traceId", Description: "链路ID，为空时返回全部span"}},
+		Responses:   adminResponses(TraceListResponse{}),
+		Security:    "bearerAuth",
+	}, traceHandler.ListSpans)
+
+	admin.DELETE("/translations/:kind/:source", openapi.Operation{
+		Summary:   "删除翻译条目",
+		Tags:      []string{"admin"},
+		Responses: adminResponses(SuccessResponse{}),
+		Security:  "bearerAuth",
+	}, translationHandler.DeleteTranslation)
+
+	admin.GET("/health", openapi.Operation{
+		Summary:     "查看就绪检查详情",
+		Description: "执行与 /readyz 相同的检查，结果额外包含失败的完整原因（cause），如不可写的目录路径和上游连接错误。始终返回200。",
+		Tags:        []string{"admin"},
+		Responses:   adminResponses(ReadinessResponse{}),
+		Security:    "bearerAuth",
+	}, AdminReadinessHandler(checker))
+
+	admin.GET("/caches", openapi.Operation{
+		Summary:   "查看缓存统计",
+		Tags:      []string{"admin"},
+		Responses: adminResponses(CacheStatsResponse{}),
+		Security:  "bearerAuth",
+	}, adminHandler.ListCaches)
+
+	admin.POST("/caches/warm", openapi.Operation{
+		Summary:     "预热缓存",
+		Description: "按食材组合和菜名预先翻译并搜索，结果写入翻译和食谱缓存。查询数量不超过 BATCH_MAX_ITEMS，并发数为 BATCH_CONCURRENCY。",
+		Tags:        []string{"admin"},
+		Request:     CacheWarmRequest{},
+		Responses:   adminResponses(CacheWarmResponse{}),
+		Security:    "bearerAuth",
+	}, adminHandler.WarmCache)
+
+	admin.GET("/caches/:name/entries", openapi.Operation{
+		Summary:     "列出缓存条目",
+		Description: "缓存名称为 recipe 或 translation。食谱缓存的键以 ingredients:、dish:、recipe_info_ 开头，翻译缓存的键以 ingredient:、dish: 开头。",
+		Tags:        []string{"admin"},
+		Query: []openapi.Param{
+			{Name: "prefix", Description: "键前缀，为空时返回全部条目"},
+			{Name: "limit", Description: "返回数量，默认100，最大1000", Type: "integer"},
+		},
+		Responses: adminResponses(CacheEntriesResponse{}),
+		Security:  "bearerAuth",
+	}, adminHandler.ListCacheEntries)
+
+	admin.DELETE("/caches/:name/entries", openapi.Operation{
+		Summary:     "清理缓存条目",
+		Description: "删除键以 prefix 开头的条目；expired=true 时只清理过期条目；不带 prefix 时需要 all=true 才清空整个缓存。翻译记忆不受影响。",
+		Tags:        []string{"admin"},
+		Query: []openapi.Param{
+			{Name: "prefix", Description: "键前缀"},
+			{Name: "expired", Description: "只清理过期条目", Type: "boolean"},
+			{Name: "all", Description: "清空整个缓存", Type: "boolean"},
+		},
+		Responses: adminResponses(CachePurgeResponse{}),
+		Security:  "bearerAuth",
+	}, adminHandler.PurgeCache)
+
+	admin.GET("/upstreams", openapi.Operation{
+		Summary:     "查看上游熔断状态和调用额度",
+		Description: "DeepSeek（AI与翻译共用）和Spoonacular的熔断器状态。Spoonacular的额度来自响应头 X-API-Quota-*，单位为点数。",
+		Tags:        []string{"admin"},
+		Responses:   adminResponses(UpstreamListResponse{}),
+		Security:    "bearerAuth",
+	}, adminHandler.ListUpstreams)
+
+	admin.POST("/upstreams/:name/reset", openapi.Operation{
+		Summary:   "恢复上游熔断器",
+		Tags:      []string{"admin"},
+		Responses: adminResponses(UpstreamResponse{}),
+		Security:  "bearerAuth",
+	}, adminHandler.ResetUpstream)
+
+	admin.GET("/log-level", openapi.Operation{
+		Summary:   "查看日志级别",
+		Tags:      []string{"admin"},
+		Responses: adminResponses(LogLevelResponse{}),
+		Security:  "bearerAuth",
+	}, adminHandler.GetLogLevel)
+
+	admin.PUT("/log-level", openapi.Operation{
+		Summary:     "修改日志级别",
+		Description: "立即生效，重启后恢复为配置中的 LOG_LEVEL。",
+		Tags:        []string{"admin"},
+		Request:     LogLevelRequest{},
+		Responses:   adminResponses(LogLevelResponse{}),
+		Security:    "bearerAuth",
+	}, adminHandler.SetLogLevel)
+
+	admin.GET("/runtime", openapi.Operation{
+		Summary:   "查看运行状态",
+		Tags:      []string{"admin"},
+		Responses: adminResponses(RuntimeResponse{}),
+		Security:  "bearerAuth",
+	}, adminHandler.Runtime)
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"recipe-agent/internal/services"
+)
+
+// SuggestHandler 自动补全处理器
+type SuggestHandler struct {
+	suggestService *services.SuggestService
+}
+
+// SuggestResponse 自动补全响应结构
+type SuggestResponse struct {
+	Success     bool                  `json:"success"`
+	Type        string                `json:"type"`
+	Query       string                `json:"query"`
+	Suggestions []services.Suggestion `json:"suggestions"`
+}
+
+// NewSuggestHandler 创建自动补全处理器实例
+func NewSuggestHandler(suggestService *services.SuggestService) *SuggestHandler {
+	return &SuggestHandler{
+		suggestService: suggestService,
+	}
+}
+
+// Suggest 处理自动补全请求 GET /api/suggest?type=ingredient|dish&q=&limit=
+func (h *SuggestHandler) Suggest(c *gin.Context) {
+	suggestType := c.DefaultQuery("type", services.SuggestTypeIngredient)
+	if suggestType != services.SuggestTypeIngredient && suggestType != services.SuggestTypeDish {
+		abortWithProblem(c, &APIError{
+			Code:   CodeValidationError,
+			Fields: []FieldError{newFieldError("type", FieldInvalid)},
+		})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "8"))
+	if err != nil || limit <= 0 || limit > 50 {
+		limit = 8
+	}
+
+	query := c.Query("q")
+	c.JSON(http.StatusOK, SuggestResponse{
+		Success:     true,
+		Type:        suggestType,
+		Query:       query,
+		Suggestions: h.suggestService.Suggest(suggestType, query, limit),
+	})
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"recipe-agent/internal/metrics"
+	"recipe-agent/internal/services"
+)
+
+// SourceStatus 单个数据源的调用状态
+type SourceStatus struct {
+	Available bool  `json:"available"`
+	LatencyMs int64 `json:"latencyMs"`
+	// ErrorCode 不可用或降级的原因，例如 not_configured、low_confidence、timeout
+	ErrorCode string `json:"errorCode,omitempty" description:"不可用或降级的原因"`
+	CacheHit  bool   `json:"cacheHit"`
+	Priority  int    `json:"priority"`
+	// Contributed 该数据源的结果是否用于生成最终结果
+	Contributed bool `json:"contributed"`
+}
+
+// TranslationDetails 查询Spoonacular时使用的翻译
+type TranslationDetails struct {
+	// Items 每个原文的翻译结果，Terms 为实际参与查询的英文词
+	Items []services.Translation `json:"items"`
+	Terms []string               `json:"terms"`
+}
+
+// InputLanguage 请求中食材或菜名的语言检测结果
+type InputLanguage struct {
+	Language string            `json:"language"`
+	Mixed    bool              `json:"mixed"`
+	Items    map[string]string `json:"items"`
+}
+
+// Supplementary 食谱响应的补充数据，客户端据此判断结果是否降级及原因
+type Supplementary struct {
+	// Sources 各数据源的调用状态，以数据源名称（ai、spoonacular）为键
+	Sources map[string]SourceStatus `json:"sources"`
+	// Contributors 实际参与生成结果的数据源，按优先级从高到低排序
+	Contributors     []string                     `json:"contributors"`
+	ReferenceRecipes []services.SpoonacularRecipe `json:"referenceRecipes"`
+	ReferenceCount   int                          `json:"referenceCount"`
+	Translation      TranslationDetails           `json:"translation"`
+	InputLanguage    InputLanguage                `json:"inputLanguage"`
+	// Degraded 任一数据源不可用时为true
+	Degraded bool `json:"degraded"`
+}
+
+// sourceStatus 生成单个数据源的调用状态
+func sourceStatus(result services.SourceResult, contributed bool) SourceStatus {
+	status := SourceStatus{
+		Available:   result.Usable(),
+		LatencyMs:   result.Latency.Milliseconds(),
+		ErrorCode:   result.ErrorCode(),
+		Priority:    result.Priority,
+		Contributed: contributed,
+	}
+	if result.Output.Search != nil {
+		status.CacheHit = result.Output.Search.CacheHit
+	}
+	return status
+}
+
+// pipelineMode 根据未降级的数据源判断聚合结果的降级模式，只有AI或只有Spoonacular正常时分别记为 ai_only、api_only
+func pipelineMode(results []services.SourceResult) string {
+	healthy := make(map[string]bool, len(results))
+	for _, result := range results {
+		if result.Usable() && result.ErrorCode() == "" {
+			healthy[result.Source] = true
+		}
+	}
+
+	switch {
+	case len(healthy) == len(results):
+		return metrics.ModeFull
+	case len(healthy) == 0:
+		return metrics.ModeAllFailed
+	case healthy[services.SourceAI]:
+		return metrics.ModeAIOnly
+	default:
+		return metrics.ModeAPIOnly
+	}
+}
+
+// newSupplementary 根据聚合结果生成补充数据
+func newSupplementary(result *services.PipelineResult) *Supplementary {
+	contributed := make(map[string]bool, len(result.Contributors))
+	for _, name := range result.Contributors {
+		contributed[name] = true
+	}
+
+	supplementary := &Supplementary{
+		Sources:          make(map[string]SourceStatus, len(result.Results)),
+		Contributors:     append([]string{}, result.Contributors...),
+		ReferenceRecipes: result.Recipes,
+		ReferenceCount:   len(result.Recipes),
+		Translation: TranslationDetails{
+			Items: []services.Translation{},
+			Terms: []string{},
+		},
+	}
+	if supplementary.ReferenceRecipes == nil {
+		supplementary.ReferenceRecipes = []services.SpoonacularRecipe{}
+	}
+
+	for _, sourceResult := range result.Results {
+		status := sourceStatus(sourceResult, contributed[sourceResult.Source])
+		supplementary.Sources[sourceResult.Source] = status
+		if status.ErrorCode != "" {
+			supplementary.Degraded = true
+		}
+
+		// 翻译详情来自执行了食谱搜索的数据源
+		if search := sourceResult.Output.Search; search != nil {
+			supplementary.Translation.Items = append(supplementary.Translation.Items, search.Translations...)
+			supplementary.Translation.Terms = append(supplementary.Translation.Terms, search.Terms...)
+		}
+	}
+	return supplementary
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"recipe-agent/internal/tracing"
+)
+
+// TraceHandler 内存导出器中链路的查询处理器
+type TraceHandler struct {
+	memory *tracing.MemoryExporter
+}
+
+// TraceListResponse span列表响应结构
+type TraceListResponse struct {
+	Success bool                 `json:"success"`
+	Total   int                  `json:"total"`
+	Spans   []tracing.SpanRecord `json:"spans"`
+}
+
+// NewTraceHandler 创建链路查询处理器实例，memory 为nil表示未使用内存导出器
+func NewTraceHandler(memory *tracing.MemoryExporter) *TraceHandler {
+	return &TraceHandler{memory: memory}
+}
+
+// ListSpans 列出内存中保存的span，可按链路ID过滤；只在 TRACING_EXPORTER=memory 时可用
+func (h *TraceHandler) ListSpans(c *gin.Context) {
+	if h.memory == nil {
+		abortWithProblem(c, &APIError{Code: CodeNotFound, Detail: "未使用内存导出器，请设置 TRACING_EXPORTER=memory"})
+		return
+	}
+
+	spans := h.memory.Spans(c.Query("traceId"))
+	c.JSON(http.StatusOK, TraceListResponse{Success: true, Total: len(spans), Spans: spans})
+}
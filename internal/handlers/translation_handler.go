@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"recipe-agent/internal/services"
+)
+
+// TranslationHandler 翻译记忆管理处理器
+type TranslationHandler struct {
+	translationService *services.TranslationService
+}
+
+// TranslationCorrection 翻译修正请求结构
+type TranslationCorrection struct {
+	Translation string `json:"translation"`
+}
+
+// TranslationListResponse 翻译条目列表响应结构
+type TranslationListResponse struct {
+	Success bool                         `json:"success"`
+	Total   int                          `json:"total"`
+	Entries []*services.TranslationEntry `json:"entries"`
+}
+
+// TranslationEntryResponse 单个翻译条目响应结构
+type TranslationEntryResponse struct {
+	Success bool                       `json:"success"`
+	Entry   *services.TranslationEntry `json:"entry"`
+}
+
+// NewTranslationHandler 创建翻译记忆管理处理器实例
+func NewTranslationHandler(translationService *services.TranslationService) *TranslationHandler {
+	return &TranslationHandler{
+		translationService: translationService,
+	}
+}
+
+// ListTranslations 列出翻译条目，支持 kind 过滤
+func (h *TranslationHandler) ListTranslations(c *gin.Context) {
+	kind := c.Query("kind")
+	if kind != "" && !validTranslationKind(kind) {
+		abortWithProblem(c, &APIError{
+			Code:   CodeValidationError,
+			Fields: []FieldError{newFieldError("kind", FieldInvalid)},
+		})
+		return
+	}
+
+	entries := h.translationService.ListTranslations(kind)
+	c.JSON(http.StatusOK, TranslationListResponse{
+		Success: true,
+		Total:   len(entries),
+		Entries: entries,
+	})
+}
+
+// GetTranslation 查看单个翻译条目，食材按本体规范名称查找
+func (h *TranslationHandler) GetTranslation(c *gin.Context) {
+	kind, source, ok := translationParams(c)
+	if !ok {
+		return
+	}
+
+	entry, exists := h.translationService.GetTranslation(kind, source)
+	if !exists {
+		abortWithProblem(c, &APIError{Code: CodeNotFound, Detail: "翻译条目不存在: " + source})
+		return
+	}
+	c.JSON(http.StatusOK, TranslationEntryResponse{Success: true, Entry: entry})
+}
+
+// CorrectTranslation 人工修正翻译条目
+func (h *TranslationHandler) CorrectTranslation(c *gin.Context) {
+	kind, source, ok := translationParams(c)
+	if !ok {
+		return
+	}
+
+	var req TranslationCorrection
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortWithProblem(c, &APIError{Code: CodeValidationError, Detail: err.Error()})
+		return
+	}
+	if strings.TrimSpace(req.Translation) == "" {
+		abortWithProblem(c, &APIError{
+			Code:   CodeValidationError,
+			Fields: []FieldError{newFieldError("translation", FieldRequired)},
+		})
+		return
+	}
+
+	entry, err := h.translationService.CorrectTranslation(kind, source, req.Translation)
+	if err != nil {
+		abortWithProblem(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, TranslationEntryResponse{Success: true, Entry: entry})
+}
+
+// ApproveTranslation 审核通过翻译条目
+func (h *TranslationHandler) ApproveTranslation(c *gin.Context) {
+	kind, source, ok := translationParams(c)
+	if !ok {
+		return
+	}
+
+	entry, err := h.translationService.ApproveTranslation(kind, source)
+	if err != nil {
+		abortWithProblem(c, translationError(err))
+		return
+	}
+	c.JSON(http.StatusOK, TranslationEntryResponse{Success: true, Entry: entry})
+}
+
+// DeleteTranslation 删除翻译条目
+func (h *TranslationHandler) DeleteTranslation(c *gin.Context) {
+	kind, source, ok := translationParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.translationService.DeleteTranslation(kind, source); err != nil {
+		abortWithProblem(c, translationError(err))
+		return
+	}
+	c.JSON(http.StatusOK, SuccessResponse{Success: true})
+}
+
+// translationError 将翻译记忆错误转换为带错误码的处理错误，写入失败等内部错误不返回详情
+func translationError(err error) *APIError {
+	if errors.Is(err, services.ErrTranslationNotFound) {
+		return &APIError{Code: CodeNotFound, Detail: err.Error()}
+	}
+	return &APIError{Code: CodeInternalError, Cause: err}
+}
+
+// translationParams 解析并校验路径中的类型和原文
+func translationParams(c *gin.Context) (string, string, bool) {
+	kind := c.Param("kind")
+	source := strings.TrimSpace(c.Param("source"))
+	if !validTranslationKind(kind) {
+		abortWithProblem(c, &APIError{
+			Code:   CodeValidationError,
+			Fields: []FieldError{newFieldError("kind", FieldInvalid)},
+		})
+		return "", "", false
+	}
+	if source == "" {
+		abortWithProblem(c, &APIError{
+			Code:   CodeValidationError,
+			Fields: []FieldError{newFieldError("source", FieldRequired)},
+		})
+		return "", "", false
+	}
+	return kind, source, true
+}
+
+// validTranslationKind 校验翻译类型
+func validTranslationKind(kind string) bool {
+	return kind == services.TranslationKindIngredient || kind == services.TranslationKindDish
+}
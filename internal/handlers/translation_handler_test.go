@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"recipe-agent/internal/services"
+)
+
+func TestTranslationError(t *testing.T) {
+	notFound := translationError(fmt.Errorf("%w: 宫保鸡丁", services.ErrTranslationNotFound))
+	if notFound.Code != CodeNotFound {
+		t.Errorf("code = %s, want %s", notFound.Code, CodeNotFound)
+	}
+
+	writeErr := errors.New("rename /srv/data/translation_memory.json.tmp: read-only file system")
+	internal := translationError(writeErr)
+	if internal.Code != CodeInternalError || internal.Detail != "" || internal.Cause != writeErr {
+		t.Errorf("error = %+v, want %s without detail", internal, CodeInternalError)
+	}
+}
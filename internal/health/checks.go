@@ -0,0 +1,118 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"recipe-agent/internal/logging"
+	"recipe-agent/internal/metrics"
+)
+
+// probeClient 上游探测使用的客户端，不跟随重定向
+var probeClient = &http.Client{
+	Transport: logging.Transport(nil),
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// HTTPProbe 探测上游地址是否可达：不携带API密钥发送GET请求，不消耗调用额度；
+// 收到5xx以外的任何响应（包括401、404）都视为可达。configured 为false时跳过。
+// 上游不可达时只降级，结果缓存 interval
+func HTTPProbe(name, url string, configured bool, interval time.Duration) Check {
+	return Check{
+		Name:     name,
+		Interval: interval,
+		Run: func(ctx context.Context) (string, error) {
+			if !configured {
+				return "未配置API密钥", ErrSkipped
+			}
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+			if err != nil {
+				return "", Fail("上游地址无效", err)
+			}
+			resp, err := probeClient.Do(req)
+			if err != nil {
+				return "", Fail("上游不可达", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode >= http.StatusInternalServerError {
+				return "", Fail(fmt.Sprintf("上游返回 %d", resp.StatusCode), nil)
+			}
+			return fmt.Sprintf("HTTP %d", resp.StatusCode), nil
+		},
+	}
+}
+
+// WritableFiles 检查持久化文件所在的目录存在且可写。服务的任务、回调和翻译记忆保存在JSON文件中，
+// 目录不可写时数据无法保存，属于关键检查。目录路径只出现在失败原因中，不出现在公开的结果里
+func WritableFiles(name string, paths ...string) Check {
+	return Check{
+		Name:     name,
+		Critical: true,
+		Run: func(context.Context) (string, error) {
+			dirs := make(map[string]bool)
+			for _, path := range paths {
+				dirs[filepath.Dir(path)] = true
+			}
+			names := make([]string, 0, len(dirs))
+			for dir := range dirs {
+				names = append(names, dir)
+			}
+			sort.Strings(names)
+
+			for _, dir := range names {
+				file, err := os.CreateTemp(dir, ".health-*")
+				if err != nil {
+					return "", Fail("持久化目录不可写", fmt.Errorf("目录 %s 不可写: %v", dir, err))
+				}
+				file.Close()
+				os.Remove(file.Name())
+			}
+			return fmt.Sprintf("%d 个文件，%d 个目录可写", len(paths), len(names)), nil
+		},
+	}
+}
+
+// Caches 报告内存缓存的条目数。缓存位于进程内，没有需要连接的外部后端，始终可用
+func Caches(name string, caches map[string]func() metrics.CacheStatus) Check {
+	return Check{
+		Name: name,
+		Run: func(context.Context) (string, error) {
+			names := make([]string, 0, len(caches))
+			for cache := range caches {
+				names = append(names, cache)
+			}
+			sort.Strings(names)
+
+			parts := make([]string, 0, len(names))
+			for _, cache := range names {
+				status := caches[cache]()
+				parts = append(parts, fmt.Sprintf("%s %d 条", cache, status.ActiveEntries))
+			}
+			return "内存缓存: " + strings.Join(parts, "，"), nil
+		},
+	}
+}
+
+// Pool 检查工作池容量，排队已满时新任务会被拒绝，属于关键检查
+func Pool(name string, status func() metrics.PoolStatus) Check {
+	return Check{
+		Name:     name,
+		Critical: true,
+		Run: func(context.Context) (string, error) {
+			pool := status()
+			detail := fmt.Sprintf("%d 个worker，执行中 %d，排队 %d/%d", pool.Workers, pool.Busy, pool.Queued, pool.QueueCapacity)
+			if pool.Queued >= pool.QueueCapacity {
+				return detail, Fail("任务队列已满", nil)
+			}
+			return detail, nil
+		},
+	}
+}
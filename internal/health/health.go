@@ -0,0 +1,185 @@
+// Package health 执行就绪检查。每项检查可设置结果的缓存时间，
+// 上游探测等开销较大的检查在缓存期内直接返回上次结果，避免探针频繁请求外部服务
+package health
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// 单项检查的状态
+const (
+	StatusUp      = "up"
+	StatusDown    = "down"
+	StatusSkipped = "skipped"
+)
+
+// 整体就绪状态
+const (
+	// StatusReady 全部检查通过
+	StatusReady = "ready"
+	// StatusDegraded 非关键检查失败，服务仍可处理请求但部分功能不可用
+	StatusDegraded = "degraded"
+	// StatusNotReady 关键检查失败
+	StatusNotReady = "not_ready"
+)
+
+// ErrSkipped 检查返回该错误时记为跳过，例如依赖未配置
+var ErrSkipped = errors.New("skipped")
+
+// failedMessage 检查返回的错误不是 Fail 创建时公开的错误说明
+const failedMessage = "检查失败"
+
+// failure 带公开说明的检查错误，cause 可能包含内部路径或上游错误，只记录日志和在管理接口中返回
+type failure struct {
+	message string
+	cause   error
+}
+
+func (f *failure) Error() string {
+	if f.cause == nil {
+		return f.message
+	}
+	return f.message + ": " + f.cause.Error()
+}
+
+func (f *failure) Unwrap() error {
+	return f.cause
+}
+
+// Fail 创建检查错误，message 会出现在公开的就绪检查结果中，cause 不会
+func Fail(message string, cause error) error {
+	return &failure{message: message, cause: cause}
+}
+
+// Check 一项就绪检查
+type Check struct {
+	Name string
+	// Critical 关键检查失败时服务不就绪，非关键检查失败时只降级
+	Critical bool
+	// Interval 结果的缓存时间，为0时每次都重新检查
+	Interval time.Duration
+	// Run 执行检查，返回说明；返回 ErrSkipped 时记为跳过
+	Run func(ctx context.Context) (detail string, err error)
+}
+
+// Result 单项检查结果
+type Result struct {
+	Name     string `json:"name"`
+	Status   string `json:"status" enum:"up,down,skipped"`
+	Critical bool   `json:"critical"`
+	Detail   string `json:"detail,omitempty"`
+	Error    string `json:"error,omitempty"`
+	// Cause 失败的完整原因，只在管理接口中返回
+	Cause     string    `json:"cause,omitempty"`
+	LatencyMs int64     `json:"latencyMs"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+// Report 就绪检查报告
+type Report struct {
+	Status string   `json:"status" enum:"ready,degraded,not_ready"`
+	Checks []Result `json:"checks"`
+}
+
+// Public 返回去除失败原因的报告，用于无需鉴权的就绪探针
+func (r Report) Public() Report {
+	checks := make([]Result, len(r.Checks))
+	for i, result := range r.Checks {
+		result.Cause = ""
+		checks[i] = result
+	}
+	return Report{Status: r.Status, Checks: checks}
+}
+
+// Checker 并发执行就绪检查并缓存结果
+type Checker struct {
+	timeout time.Duration
+	entries []*entry
+}
+
+// entry 检查及其上次结果
+type entry struct {
+	check  Check
+	result *Result
+	mutex  sync.Mutex
+}
+
+// NewChecker 创建检查器，timeout 为单项检查的超时时间
+func NewChecker(timeout time.Duration, checks ...Check) *Checker {
+	c := &Checker{timeout: timeout}
+	for _, check := range checks {
+		c.entries = append(c.entries, &entry{check: check})
+	}
+	return c
+}
+
+// Report 执行全部检查（缓存期内的检查使用上次结果）并汇总整体状态
+func (c *Checker) Report(ctx context.Context) Report {
+	results := make([]Result, len(c.entries))
+	var wg sync.WaitGroup
+	for i, e := range c.entries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = e.run(ctx, c.timeout)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusReady, Checks: results}
+	for _, result := range results {
+		if result.Status != StatusDown {
+			continue
+		}
+		if result.Critical {
+			report.Status = StatusNotReady
+			break
+		}
+		report.Status = StatusDegraded
+	}
+	return report
+}
+
+// run 执行检查，同一项检查同时只执行一次，并发的请求等待并共用结果
+func (e *entry) run(ctx context.Context, timeout time.Duration) Result {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.result != nil && e.check.Interval > 0 && time.Since(e.result.CheckedAt) < e.check.Interval {
+		return *e.result
+	}
+
+	// 探测不随单个请求取消，结果会被后续请求复用
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+
+	start := time.Now()
+	detail, err := e.check.Run(ctx)
+	result := Result{
+		Name:      e.check.Name,
+		Status:    StatusUp,
+		Critical:  e.check.Critical,
+		Detail:    detail,
+		LatencyMs: time.Since(start).Milliseconds(),
+		CheckedAt: start,
+	}
+	switch {
+	case errors.Is(err, ErrSkipped):
+		result.Status = StatusSkipped
+	case err != nil:
+		result.Status = StatusDown
+		result.Error = failedMessage
+		var f *failure
+		if errors.As(err, &f) {
+			result.Error = f.message
+		}
+		result.Cause = err.Error()
+		slog.WarnContext(ctx, "就绪检查失败", "check", e.check.Name, "error", err)
+	}
+	e.result = &result
+	return result
+}
@@ -0,0 +1,44 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReportHidesFailureCause(t *testing.T) {
+	// 父路径是普通文件，目录不可写
+	blocked := filepath.Join(t.TempDir(), "blocked")
+	if err := os.WriteFile(blocked, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	checker := NewChecker(time.Second,
+		WritableFiles("store", filepath.Join(blocked, "jobs.json")),
+		Check{Name: "raw", Run: func(context.Context) (string, error) {
+			return "", errors.New("dial tcp 10.0.0.3:443: connection refused")
+		}},
+	)
+
+	report := checker.Report(context.Background())
+	if report.Status != StatusNotReady {
+		t.Errorf("status = %s, want %s", report.Status, StatusNotReady)
+	}
+	if store := report.Checks[0]; !strings.Contains(store.Cause, blocked) {
+		t.Errorf("store cause = %q, want the directory path", store.Cause)
+	}
+
+	public := report.Public()
+	want := []string{"持久化目录不可写", failedMessage}
+	for i, result := range public.Checks {
+		if result.Error != want[i] || result.Cause != "" {
+			t.Errorf("public %s = error %q cause %q, want error %q without cause", result.Name, result.Error, result.Cause, want[i])
+		}
+		if strings.Contains(result.Detail+result.Error, blocked) || strings.Contains(result.Error, "10.0.0.3") {
+			t.Errorf("public %s leaks internal details: %+v", result.Name, result)
+		}
+	}
+}
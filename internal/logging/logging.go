@@ -0,0 +1,138 @@
+// Package logging 配置基于 log/slog 的结构化日志。Setup 按配置输出JSON或文本格式，
+// 每条日志自动附带上下文中的请求ID和链路ID，并隐藏其中的密钥；标准库 log 的输出也经过同一处理器
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"recipe-agent/internal/config"
+)
+
+// RequestIDHeader 请求ID的请求头，出站请求也携带该请求头以便上下游关联日志
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDKey 上下文中保存请求ID的键
+type requestIDKey struct{}
+
+// level 当前的最低输出级别，可在运行时通过管理接口修改
+var level = new(slog.LevelVar)
+
+// Setup 按配置创建日志处理器并设为默认，secrets 中的值（如API密钥）在日志中被替换为 ******
+func Setup(cfg config.Log, secrets ...string) {
+	if err := SetLevel(cfg.Level); err != nil {
+		level.Set(slog.LevelInfo)
+	}
+
+	options := &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: newRedactor(secrets).replaceAttr,
+	}
+	var handler slog.Handler
+	if cfg.Format == "text" {
+		handler = slog.NewTextHandler(os.Stderr, options)
+	} else {
+		handler = slog.NewJSONHandler(os.Stderr, options)
+	}
+	slog.SetDefault(slog.New(contextHandler{handler}))
+}
+
+// Level 返回当前的最低输出级别
+func Level() slog.Level {
+	return level.Level()
+}
+
+// SetLevel 修改最低输出级别（debug、info、warn 或 error），立即对所有日志生效
+func SetLevel(name string) error {
+	var parsed slog.Level
+	if err := parsed.UnmarshalText([]byte(name)); err != nil {
+		return fmt.Errorf("无效的日志级别 %q，必须是 debug、info、warn 或 error", name)
+	}
+	level.Set(parsed)
+	return nil
+}
+
+// WithRequestID 返回携带请求ID的上下文，之后使用该上下文记录的日志都会附带请求ID
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	if requestID == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID 返回上下文中的请求ID，没有时返回空字符串
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// contextHandler 为日志附加上下文中的请求ID和链路ID
+type contextHandler struct {
+	slog.Handler
+}
+
+// Handle 实现 slog.Handler
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestID := RequestID(ctx); requestID != "" {
+		record.AddAttrs(slog.String("request_id", requestID))
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		record.AddAttrs(slog.String("trace_id", spanContext.TraceID().String()))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+// WithAttrs 实现 slog.Handler
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup 实现 slog.Handler
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// Transport 包装出站请求的 RoundTripper：携带上下文中的请求ID，并以debug级别记录每次调用；
+// 日志只包含地址的主机和路径，不包含查询参数和请求头。base 为nil时使用默认传输
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{base: base}
+}
+
+// transport 出站请求的日志传输层
+type transport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip 实现 http.RoundTripper
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if requestID := RequestID(ctx); requestID != "" && req.Header.Get(RequestIDHeader) == "" {
+		// RoundTripper 不能修改传入的请求
+		req = req.Clone(ctx)
+		req.Header.Set(RequestIDHeader, requestID)
+	}
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("host", req.URL.Host),
+		slog.String("path", req.URL.Path),
+		slog.Int64("duration_ms", time.Since(start).Milliseconds()),
+	}
+	if err != nil {
+		slog.LogAttrs(ctx, slog.LevelDebug, "上游请求失败", append(attrs, slog.Any("error", err))...)
+		return nil, err
+	}
+	slog.LogAttrs(ctx, slog.LevelDebug, "上游请求", append(attrs, slog.Int("status", resp.StatusCode))...)
+	return resp, nil
+}
@@ -0,0 +1,83 @@
+package logging
+
+import (
+	"log/slog"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// redactedValue 日志中替换密钥的占位符
+const redactedValue = "******"
+
+// minSecretLength 按值隐藏的密钥最短长度，过短的值容易误伤正常内容
+const minSecretLength = 4
+
+// sensitiveKeys 值一律隐藏的日志字段名（不区分大小写）
+var sensitiveKeys = map[string]bool{
+	"authorization": true,
+	"x-api-key":     true,
+	"api_key":       true,
+	"apikey":        true,
+	"token":         true,
+	"secret":        true,
+	"password":      true,
+}
+
+// sensitivePatterns 文本中的密钥格式：URL查询参数、请求头或JSON字段（如 Authorization、x-api-key、"api_key"）、Bearer 令牌
+var sensitivePatterns = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`(?i)\b(api[_-]?key|access[_-]?token|token|secret|password)=[^&\s"']+`), "${1}=" + redactedValue},
+	{regexp.MustCompile(`(?i)\b(authorization|x-api-key|api[_-]?key|token|secret|password)(["']?\s*[:=]\s*["']?)(?:(?:bearer|basic)\s+)?[^\s"',}]+`), "${1}${2}" + redactedValue},
+	{regexp.MustCompile(`(?i)\b(bearer)\s+[A-Za-z0-9._~+/=-]+`), "${1} " + redactedValue},
+}
+
+// redactor 隐藏日志中的密钥
+type redactor struct {
+	// secrets 配置中的密钥值，按长度从长到短替换
+	secrets []string
+}
+
+// newRedactor 创建隐藏指定密钥值的redactor
+func newRedactor(secrets []string) *redactor {
+	r := &redactor{}
+	for _, secret := range secrets {
+		if len(secret) >= minSecretLength {
+			r.secrets = append(r.secrets, secret)
+		}
+	}
+	sort.Slice(r.secrets, func(i, j int) bool {
+		return len(r.secrets[i]) > len(r.secrets[j])
+	})
+	return r
+}
+
+// replaceAttr 作为 slog.HandlerOptions.ReplaceAttr，处理日志消息、字符串字段和错误
+func (r *redactor) replaceAttr(_ []string, attr slog.Attr) slog.Attr {
+	if sensitiveKeys[strings.ToLower(attr.Key)] {
+		return slog.String(attr.Key, redactedValue)
+	}
+
+	switch attr.Value.Kind() {
+	case slog.KindString:
+		attr.Value = slog.StringValue(r.redact(attr.Value.String()))
+	case slog.KindAny:
+		if err, ok := attr.Value.Any().(error); ok {
+			attr.Value = slog.StringValue(r.redact(err.Error()))
+		}
+	}
+	return attr
+}
+
+// redact 隐藏文本中的密钥值和常见密钥格式
+func (r *redactor) redact(text string) string {
+	for _, secret := range r.secrets {
+		text = strings.ReplaceAll(text, secret, redactedValue)
+	}
+	for _, sensitive := range sensitivePatterns {
+		text = sensitive.pattern.ReplaceAllString(text, sensitive.replacement)
+	}
+	return text
+}
@@ -0,0 +1,140 @@
+package metrics
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// CacheStatus 缓存状态，命中率为命中次数占查询次数的比例
+type CacheStatus struct {
+	TotalEntries   int     `json:"total_entries"`
+	ActiveEntries  int     `json:"active_entries"`
+	ExpiredEntries int     `json:"expired_entries"`
+	Hits           uint64  `json:"hits"`
+	Misses         uint64  `json:"misses"`
+	HitRatio       float64 `json:"hit_ratio"`
+}
+
+// NewCacheStatus 根据条目数和命中次数计算缓存状态
+func NewCacheStatus(active, expired int, hits, misses uint64) CacheStatus {
+	status := CacheStatus{
+		TotalEntries:   active + expired,
+		ActiveEntries:  active,
+		ExpiredEntries: expired,
+		Hits:           hits,
+		Misses:         misses,
+	}
+	if lookups := hits + misses; lookups > 0 {
+		status.HitRatio = float64(hits) / float64(lookups)
+	}
+	return status
+}
+
+// PoolStatus 工作池状态
+type PoolStatus struct {
+	Workers       int `json:"workers"`
+	Busy          int `json:"busy"`
+	Queued        int `json:"queued"`
+	QueueCapacity int `json:"queue_capacity"`
+}
+
+var (
+	cacheEntriesDesc = prometheus.NewDesc(namespace+"_cache_entries", "缓存条目数，按缓存和状态（active/expired）区分", []string{"cache", "state"}, nil)
+	cacheHitsDesc    = prometheus.NewDesc(namespace+"_cache_hits_total", "缓存命中次数", []string{"cache"}, nil)
+	cacheMissesDesc  = prometheus.NewDesc(namespace+"_cache_misses_total", "缓存未命中次数", []string{"cache"}, nil)
+	cacheRatioDesc   = prometheus.NewDesc(namespace+"_cache_hit_ratio", "启动以来的缓存命中率", []string{"cache"}, nil)
+
+	poolWorkersDesc  = prometheus.NewDesc(namespace+"_pool_workers", "工作池的worker数", []string{"pool"}, nil)
+	poolBusyDesc     = prometheus.NewDesc(namespace+"_pool_busy_workers", "正在执行任务的worker数", []string{"pool"}, nil)
+	poolQueuedDesc   = prometheus.NewDesc(namespace+"_pool_queue_length", "排队等待执行的任务数", []string{"pool"}, nil)
+	poolCapacityDesc = prometheus.NewDesc(namespace+"_pool_queue_capacity", "任务队列容量", []string{"pool"}, nil)
+)
+
+// caches 已注册的缓存，抓取时读取各缓存的状态
+var caches = &cacheCollector{sources: make(map[string]func() CacheStatus)}
+
+// pools 已注册的工作池，抓取时读取各工作池的状态
+var pools = &poolCollector{sources: make(map[string]func() PoolStatus)}
+
+// cacheCollector 缓存指标收集器
+type cacheCollector struct {
+	sources map[string]func() CacheStatus
+	mutex   sync.RWMutex
+}
+
+// RegisterCache 注册缓存，抓取指标时调用 status 获取当前状态；同名缓存后注册的生效
+func RegisterCache(name string, status func() CacheStatus) {
+	caches.mutex.Lock()
+	defer caches.mutex.Unlock()
+	caches.sources[name] = status
+}
+
+// Describe 实现 prometheus.Collector
+func (c *cacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cacheEntriesDesc
+	ch <- cacheHitsDesc
+	ch <- cacheMissesDesc
+	ch <- cacheRatioDesc
+}
+
+// Collect 实现 prometheus.Collector
+func (c *cacheCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	for _, name := range sortedNames(c.sources) {
+		status := c.sources[name]()
+		ch <- prometheus.MustNewConstMetric(cacheEntriesDesc, prometheus.GaugeValue, float64(status.ActiveEntries), name, "active")
+		ch <- prometheus.MustNewConstMetric(cacheEntriesDesc, prometheus.GaugeValue, float64(status.ExpiredEntries), name, "expired")
+		ch <- prometheus.MustNewConstMetric(cacheHitsDesc, prometheus.CounterValue, float64(status.Hits), name)
+		ch <- prometheus.MustNewConstMetric(cacheMissesDesc, prometheus.CounterValue, float64(status.Misses), name)
+		ch <- prometheus.MustNewConstMetric(cacheRatioDesc, prometheus.GaugeValue, status.HitRatio, name)
+	}
+}
+
+// poolCollector 工作池指标收集器
+type poolCollector struct {
+	sources map[string]func() PoolStatus
+	mutex   sync.RWMutex
+}
+
+// RegisterPool 注册工作池，抓取指标时调用 status 获取当前状态；同名工作池后注册的生效
+func RegisterPool(name string, status func() PoolStatus) {
+	pools.mutex.Lock()
+	defer pools.mutex.Unlock()
+	pools.sources[name] = status
+}
+
+// Describe 实现 prometheus.Collector
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- poolWorkersDesc
+	ch <- poolBusyDesc
+	ch <- poolQueuedDesc
+	ch <- poolCapacityDesc
+}
+
+// Collect 实现 prometheus.Collector
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	for _, name := range sortedNames(c.sources) {
+		status := c.sources[name]()
+		ch <- prometheus.MustNewConstMetric(poolWorkersDesc, prometheus.GaugeValue, float64(status.Workers), name)
+		ch <- prometheus.MustNewConstMetric(poolBusyDesc, prometheus.GaugeValue, float64(status.Busy), name)
+		ch <- prometheus.MustNewConstMetric(poolQueuedDesc, prometheus.GaugeValue, float64(status.Queued), name)
+		ch <- prometheus.MustNewConstMetric(poolCapacityDesc, prometheus.GaugeValue, float64(status.QueueCapacity), name)
+	}
+}
+
+// sortedNames 返回按名称排序的键，保证每次导出的顺序一致
+func sortedNames[T any](sources map[string]T) []string {
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
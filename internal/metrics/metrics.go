@@ -0,0 +1,131 @@
+// Package metrics 定义服务的 Prometheus 指标。指标注册在独立的注册表中，
+// 各服务通过本包的函数记录数据，由 /metrics 接口以文本格式导出
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace 指标名称前缀
+const namespace = "recipe_agent"
+
+// 上游服务名称
+const (
+	ProviderDeepSeek    = "deepseek"
+	ProviderSpoonacular = "spoonacular"
+	ProviderTranslation = "translation"
+)
+
+// 聚合结果的降级模式
+const (
+	ModeFull      = "full"       // 所有数据源均正常
+	ModeAIOnly    = "ai_only"    // 只有AI数据源可用
+	ModeAPIOnly   = "api_only"   // 只有食谱API可用
+	ModeAllFailed = "all_failed" // 没有可用的数据源
+)
+
+// registry 服务指标注册表，包含Go运行时和进程指标
+var registry = prometheus.NewRegistry()
+
+// latencyBuckets 耗时分布的区间，覆盖从缓存命中到AI长文本生成
+var latencyBuckets = []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 20, 30, 60, 120}
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP请求数，按路由、方法、状态码和查询类型区分",
+	}, []string{"route", "method", "status", "query_type"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP请求耗时，按路由、方法、状态码和查询类型区分",
+		Buckets:   latencyBuckets,
+	}, []string{"route", "method", "status", "query_type"})
+
+	upstreamDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "upstream_request_duration_seconds",
+		Help:      "上游服务调用耗时，按服务和结果（success/error）区分",
+		Buckets:   latencyBuckets,
+	}, []string{"provider", "result"})
+
+	upstreamErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "upstream_errors_total",
+		Help:      "上游服务调用失败次数，按服务和错误码区分",
+	}, []string{"provider", "code"})
+
+	llmTokens = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "llm_tokens_total",
+		Help:      "大模型消耗的token数，按服务和类型（prompt/completion）区分",
+	}, []string{"provider", "type"})
+
+	pipelineResults = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "pipeline_results_total",
+		Help:      "聚合查询次数，按查询类型和降级模式（full/ai_only/api_only/all_failed）区分",
+	}, []string{"query_type", "mode"})
+
+	batchInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "batch_items_in_flight",
+		Help:      "批量查询中正在处理的请求数",
+	})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests, httpDuration,
+		upstreamDuration, upstreamErrors, llmTokens,
+		pipelineResults, batchInFlight,
+		caches, pools,
+	)
+}
+
+// Handler 返回以 Prometheus 文本格式导出指标的处理函数
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// ObserveRequest 记录一次HTTP请求，queryType 为空表示非查询类接口
+func ObserveRequest(route, method, status, queryType string, duration time.Duration) {
+	httpRequests.WithLabelValues(route, method, status, queryType).Inc()
+	httpDuration.WithLabelValues(route, method, status, queryType).Observe(duration.Seconds())
+}
+
+// ObserveUpstream 记录一次上游调用，code 为空表示成功，否则为错误码
+func ObserveUpstream(provider string, duration time.Duration, code string) {
+	result := "success"
+	if code != "" {
+		result = "error"
+		upstreamErrors.WithLabelValues(provider, code).Inc()
+	}
+	upstreamDuration.WithLabelValues(provider, result).Observe(duration.Seconds())
+}
+
+// AddTokens 累计大模型消耗的token数
+func AddTokens(provider string, prompt, completion int) {
+	llmTokens.WithLabelValues(provider, "prompt").Add(float64(prompt))
+	llmTokens.WithLabelValues(provider, "completion").Add(float64(completion))
+}
+
+// ObservePipeline 记录一次聚合查询的降级模式
+func ObservePipeline(queryType, mode string) {
+	pipelineResults.WithLabelValues(queryType, mode).Inc()
+}
+
+// BatchItemStarted 批量查询中的单个请求开始处理，返回结束时调用的函数
+func BatchItemStarted() (done func()) {
+	batchInFlight.Inc()
+	return batchInFlight.Dec
+}
@@ -0,0 +1,428 @@
+// Package openapi 根据Go类型生成OpenAPI 3文档
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Document OpenAPI 3 文档
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Servers    []Server             `json:"servers,omitempty"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+}
+
+// Info 文档基本信息
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Server 服务地址
+type Server struct {
+	URL string `json:"url"`
+}
+
+// PathItem 单个路径下的操作
+type PathItem map[string]*OperationObject
+
+// OperationObject 文档中的操作对象
+type OperationObject struct {
+	Summary     string                     `json:"summary,omitempty"`
+	Description string                     `json:"description,omitempty"`
+	OperationID string                     `json:"operationId,omitempty"`
+	Tags        []string                   `json:"tags,omitempty"`
+	Parameters  []*Parameter               `json:"parameters,omitempty"`
+	RequestBody *RequestBody               `json:"requestBody,omitempty"`
+	Responses   map[string]*ResponseObject `json:"responses"`
+	Deprecated  bool                       `json:"deprecated,omitempty"`
+	Security    []map[string][]string      `json:"security,omitempty"`
+}
+
+// Parameter 路径或查询参数
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody 请求体
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// ResponseObject 响应
+type ResponseObject struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType 媒体类型
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components 可复用组件
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme 鉴权方式
+type SecurityScheme struct {
+	Type   string `json:"type"`
+	Scheme string `json:"scheme,omitempty"`
+}
+
+// Schema JSON Schema（OpenAPI 3.0 子集）
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+}
+
+// Operation 描述一个接口，Request和Responses使用Go类型的零值
+type Operation struct {
+	Summary     string
+	Description string
+	Tags        []string
+	Request     interface{}
+	Responses   map[int]interface{}
+	Query       []Param
+	Deprecated  bool
+	// Security 非空时表示需要对应的鉴权方式，例如 "bearerAuth"
+	Security string
+	// Produces 成功响应除JSON外还支持的媒体类型（内容为字符串），例如 text/markdown
+	Produces []string
+}
+
+// ContentTyper 响应类型实现该接口时使用自定义的媒体类型，例如 application/problem+json
+type ContentTyper interface {
+	ContentType() string
+}
+
+// Param 查询参数
+type Param struct {
+	Name        string
+	Description string
+	Type        string
+	Required    bool
+	Enum        []string
+}
+
+// Generator 根据注册的接口和Go类型生成文档
+type Generator struct {
+	doc   *Document
+	names map[reflect.Type]string
+	mutex sync.Mutex
+}
+
+// ginParam 匹配gin的路径参数（:id）
+var ginParam = regexp.MustCompile(`:([A-Za-z0-9_]+)`)
+
+// NewGenerator 创建文档生成器
+func NewGenerator(title, description, version string) *Generator {
+	return &Generator{
+		doc: &Document{
+			OpenAPI: "3.0.3",
+			Info: Info{
+				Title:       title,
+				Description: description,
+				Version:     version,
+			},
+			Paths: make(map[string]*PathItem),
+			Components: Components{
+				Schemas: make(map[string]*Schema),
+				SecuritySchemes: map[string]*SecurityScheme{
+					"bearerAuth": {Type: "http", Scheme: "bearer"},
+				},
+			},
+		},
+		names: make(map[reflect.Type]string),
+	}
+}
+
+// Add 注册接口，path使用gin的路由语法
+func (g *Generator) Add(method, path string, op Operation) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	openapiPath := ginParam.ReplaceAllString(path, "{$1}")
+	item, exists := g.doc.Paths[openapiPath]
+	if !exists {
+		item = &PathItem{}
+		g.doc.Paths[openapiPath] = item
+	}
+
+	operation := &OperationObject{
+		Summary:     op.Summary,
+		Description: op.Description,
+		OperationID: operationID(method, openapiPath),
+		Tags:        op.Tags,
+		Responses:   make(map[string]*ResponseObject),
+		Deprecated:  op.Deprecated,
+	}
+
+	for _, match := range ginParam.FindAllStringSubmatch(path, -1) {
+		operation.Parameters = append(operation.Parameters, &Parameter{
+			Name:     match[1],
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "string"},
+		})
+	}
+	for _, param := range op.Query {
+		paramType := param.Type
+		if paramType == "" {
+			paramType = "string"
+		}
+		operation.Parameters = append(operation.Parameters, &Parameter{
+			Name:        param.Name,
+			In:          "query",
+			Description: param.Description,
+			Required:    param.Required,
+			Schema:      &Schema{Type: paramType, Enum: param.Enum},
+		})
+	}
+
+	if op.Request != nil {
+		operation.RequestBody = &RequestBody{
+			Required: true,
+			Content: map[string]*MediaType{
+				"application/json": {Schema: g.schemaFor(reflect.TypeOf(op.Request))},
+			},
+		}
+	}
+
+	for status, body := range op.Responses {
+		response := &ResponseObject{Description: statusDescription(status)}
+		if body != nil {
+			contentType := "application/json"
+			if typer, ok := body.(ContentTyper); ok {
+				contentType = typer.ContentType()
+			}
+			response.Content = map[string]*MediaType{
+				contentType: {Schema: g.schemaFor(reflect.TypeOf(body))},
+			}
+			if status >= 200 && status < 300 {
+				for _, produces := range op.Produces {
+					response.Content[produces] = &MediaType{Schema: &Schema{Type: "string"}}
+				}
+			}
+		}
+		operation.Responses[strconv.Itoa(status)] = response
+	}
+
+	if op.Security != "" {
+		operation.Security = []map[string][]string{{op.Security: {}}}
+	}
+
+	(*item)[strings.ToLower(method)] = operation
+}
+
+// Document 返回生成的文档
+func (g *Generator) Document() *Document {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	return g.doc
+}
+
+// schemaFor 生成类型的Schema，结构体注册为可复用组件
+func (g *Generator) schemaFor(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == reflect.TypeOf(time.Time{}) {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+	if t == reflect.TypeOf(json.RawMessage{}) {
+		// 任意JSON
+		return &Schema{}
+	}
+	if t == reflect.TypeOf(time.Duration(0)) {
+		return &Schema{Type: "integer", Format: "int64", Description: "纳秒"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schemaFor(t.Elem())}
+	case reflect.Interface:
+		return &Schema{}
+	case reflect.Struct:
+		return g.structRef(t)
+	}
+	return &Schema{}
+}
+
+// structRef 将结构体注册到components并返回引用
+func (g *Generator) structRef(t reflect.Type) *Schema {
+	if name, exists := g.names[t]; exists {
+		return &Schema{Ref: "#/components/schemas/" + name}
+	}
+
+	name := g.componentName(t)
+	g.names[t] = name
+	// 先占位，支持递归类型
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	g.doc.Components.Schemas[name] = schema
+
+	g.addFields(schema, t)
+	sort.Strings(schema.Required)
+
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// addFields 按json标签生成属性，嵌入结构体的字段展开到当前结构体
+func (g *Generator) addFields(schema *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			for embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				g.addFields(schema, embedded)
+				continue
+			}
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		property := g.schemaFor(field.Type)
+		description := field.Tag.Get("description")
+		enum := field.Tag.Get("enum")
+		if description != "" || enum != "" {
+			// OpenAPI 3.0 中 $ref 的同级属性会被忽略，只对非引用类型附加说明
+			if property.Ref == "" {
+				copied := *property
+				copied.Description = description
+				if enum != "" {
+					copied.Enum = strings.Split(enum, ",")
+				}
+				property = &copied
+			}
+		}
+		if field.Type.Kind() == reflect.Ptr {
+			if property.Ref == "" {
+				copied := *property
+				copied.Nullable = true
+				property = &copied
+			}
+		}
+		schema.Properties[name] = property
+
+		if !strings.Contains(options, "omitempty") && field.Type.Kind() != reflect.Ptr {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+// componentName 生成组件名称，同名类型使用包名区分
+func (g *Generator) componentName(t reflect.Type) string {
+	name := t.Name()
+	if name == "" {
+		name = "Anonymous"
+	}
+	if _, exists := g.doc.Components.Schemas[name]; exists {
+		pkg := t.PkgPath()
+		if i := strings.LastIndex(pkg, "/"); i >= 0 {
+			pkg = pkg[i+1:]
+		}
+		if pkg != "" {
+			name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+		}
+	}
+	return name
+}
+
+// operationID 根据方法和路径生成操作ID，例如 post_api_v1_recipes
+func operationID(method, path string) string {
+	replacer := strings.NewReplacer("/", "_", "{", "", "}", "", "-", "_", ".", "_")
+	return strings.ToLower(method) + strings.TrimRight(replacer.Replace(path), "_")
+}
+
+// statusDescription 返回HTTP状态码的说明
+func statusDescription(status int) string {
+	switch status {
+	case 200:
+		return "成功"
+	case 201:
+		return "已创建"
+	case 202:
+		return "已接受"
+	case 204:
+		return "无内容"
+	case 400:
+		return "请求参数错误"
+	case 401:
+		return "未授权"
+	case 403:
+		return "禁止访问"
+	case 404:
+		return "资源不存在"
+	case 406:
+		return "不支持请求的响应格式"
+	case 409:
+		return "状态冲突"
+	case 422:
+		return "输入有歧义"
+	case 429:
+		return "请求过多或额度用尽"
+	case 500:
+		return "服务内部错误"
+	case 502:
+		return "上游服务错误"
+	case 503:
+		return "服务不可用"
+	}
+	return "HTTP " + strconv.Itoa(status)
+}
@@ -0,0 +1,239 @@
+// Package render 将AI生成的Markdown渲染为安全的HTML、纯文本或结构化文档
+package render
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	east "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// 支持的输出格式
+const (
+	FormatJSON     = "json"
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+	FormatText     = "text"
+)
+
+// Document 从Markdown解析出的结构化食谱，一级标题作为Title，其余标题各自成为一个章节
+type Document struct {
+	Title    string    `json:"title"`
+	Sections []Section `json:"sections"`
+}
+
+// Section 文档章节，标题之前的内容放在Heading为空的章节中
+type Section struct {
+	Heading    string   `json:"heading"`
+	Level      int      `json:"level"`
+	Paragraphs []string `json:"paragraphs,omitempty"`
+	// Items 无序列表（如食材、要点），Steps 有序列表（如制作步骤）
+	Items []Item `json:"items,omitempty"`
+	Steps []Item `json:"steps,omitempty"`
+}
+
+// Item 列表项，嵌套列表放在Items中
+type Item struct {
+	Text  string `json:"text"`
+	Items []Item `json:"items,omitempty"`
+}
+
+// Renderer Markdown渲染器，可并发使用
+type Renderer struct {
+	markdown goldmark.Markdown
+	policy   *bluemonday.Policy
+}
+
+// NewRenderer 创建渲染器：支持GFM（表格、删除线等），不输出原始HTML，渲染结果再经过白名单过滤
+func NewRenderer() *Renderer {
+	policy := bluemonday.UGCPolicy()
+	policy.AddTargetBlankToFullyQualifiedLinks(true)
+
+	return &Renderer{
+		markdown: goldmark.New(goldmark.WithExtensions(extension.GFM)),
+		policy:   policy,
+	}
+}
+
+// HTML 将Markdown渲染为过滤后的HTML片段，模型输出的脚本、事件属性等会被移除
+func (r *Renderer) HTML(markdown string) (string, error) {
+	var buf bytes.Buffer
+	if err := r.markdown.Convert([]byte(markdown), &buf); err != nil {
+		return "", err
+	}
+	return r.policy.Sanitize(buf.String()), nil
+}
+
+// Text 将Markdown转换为纯文本，保留标题、段落和列表的结构
+func (r *Renderer) Text(markdown string) string {
+	source := []byte(markdown)
+	root := r.parse(source)
+
+	var blocks []string
+	for node := root.FirstChild(); node != nil; node = node.NextSibling() {
+		if block := blockText(node, source, ""); block != "" {
+			blocks = append(blocks, block)
+		}
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+// Document 将Markdown解析为结构化文档
+func (r *Renderer) Document(markdown string) Document {
+	source := []byte(markdown)
+	root := r.parse(source)
+
+	doc := Document{Sections: []Section{}}
+	var current *Section
+	section := func() *Section {
+		if current == nil {
+			doc.Sections = append(doc.Sections, Section{})
+			current = &doc.Sections[len(doc.Sections)-1]
+		}
+		return current
+	}
+
+	for node := root.FirstChild(); node != nil; node = node.NextSibling() {
+		switch n := node.(type) {
+		case *ast.Heading:
+			heading := inlineText(n, source)
+			if n.Level == 1 && doc.Title == "" {
+				doc.Title = heading
+				continue
+			}
+			doc.Sections = append(doc.Sections, Section{Heading: heading, Level: n.Level})
+			current = &doc.Sections[len(doc.Sections)-1]
+		case *ast.List:
+			items := listItems(n, source)
+			if n.IsOrdered() {
+				section().Steps = append(section().Steps, items...)
+			} else {
+				section().Items = append(section().Items, items...)
+			}
+		case *ast.ThematicBreak:
+		default:
+			if block := blockText(n, source, ""); block != "" {
+				section().Paragraphs = append(section().Paragraphs, block)
+			}
+		}
+	}
+	return doc
+}
+
+// parse 解析Markdown为语法树
+func (r *Renderer) parse(source []byte) ast.Node {
+	return r.markdown.Parser().Parse(text.NewReader(source))
+}
+
+// listItems 提取列表项及其嵌套列表
+func listItems(list *ast.List, source []byte) []Item {
+	var items []Item
+	for child := list.FirstChild(); child != nil; child = child.NextSibling() {
+		item := Item{}
+		var texts []string
+		for node := child.FirstChild(); node != nil; node = node.NextSibling() {
+			if nested, ok := node.(*ast.List); ok {
+				item.Items = append(item.Items, listItems(nested, source)...)
+				continue
+			}
+			if block := blockText(node, source, ""); block != "" {
+				texts = append(texts, block)
+			}
+		}
+		item.Text = strings.Join(texts, " ")
+		items = append(items, item)
+	}
+	return items
+}
+
+// blockText 将块级节点转换为纯文本，indent 为嵌套列表的缩进
+func blockText(node ast.Node, source []byte, indent string) string {
+	switch n := node.(type) {
+	case *ast.Heading, *ast.Paragraph, *ast.TextBlock:
+		return inlineText(n, source)
+	case *ast.List:
+		var lines []string
+		number := n.Start
+		for child := n.FirstChild(); child != nil; child = child.NextSibling() {
+			marker := "- "
+			if n.IsOrdered() {
+				marker = strconv.Itoa(number) + ". "
+				number++
+			}
+			var parts []string
+			for item := child.FirstChild(); item != nil; item = item.NextSibling() {
+				if nested, ok := item.(*ast.List); ok {
+					parts = append(parts, blockText(nested, source, indent+"  "))
+					continue
+				}
+				if text := blockText(item, source, indent); text != "" {
+					if len(parts) == 0 {
+						text = indent + marker + text
+					}
+					parts = append(parts, text)
+				}
+			}
+			lines = append(lines, strings.Join(parts, "\n"))
+		}
+		return strings.Join(lines, "\n")
+	case *ast.FencedCodeBlock, *ast.CodeBlock:
+		var buf strings.Builder
+		segments := n.Lines()
+		for i := 0; i < segments.Len(); i++ {
+			segment := segments.At(i)
+			buf.Write(segment.Value(source))
+		}
+		return strings.TrimRight(buf.String(), "\n")
+	case *ast.Blockquote:
+		var blocks []string
+		for child := n.FirstChild(); child != nil; child = child.NextSibling() {
+			if block := blockText(child, source, indent); block != "" {
+				blocks = append(blocks, block)
+			}
+		}
+		return strings.Join(blocks, "\n")
+	case *east.Table:
+		var rows []string
+		for row := n.FirstChild(); row != nil; row = row.NextSibling() {
+			var cells []string
+			for cell := row.FirstChild(); cell != nil; cell = cell.NextSibling() {
+				cells = append(cells, inlineText(cell, source))
+			}
+			rows = append(rows, strings.Join(cells, " | "))
+		}
+		return strings.Join(rows, "\n")
+	}
+	return ""
+}
+
+// inlineText 提取行内节点的文本，去掉强调、链接等标记
+func inlineText(node ast.Node, source []byte) string {
+	var buf strings.Builder
+	_ = ast.Walk(node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch t := n.(type) {
+		case *ast.Text:
+			buf.Write(t.Segment.Value(source))
+			if t.HardLineBreak() {
+				buf.WriteString("\n")
+			} else if t.SoftLineBreak() {
+				buf.WriteString(" ")
+			}
+		case *ast.String:
+			buf.Write(t.Value)
+		case *ast.RawHTML, *ast.HTMLBlock:
+			// 原始HTML不输出
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	return strings.TrimSpace(buf.String())
+}
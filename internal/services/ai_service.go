@@ -2,18 +2,31 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
-	"os"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"recipe-agent/internal/config"
+	"recipe-agent/internal/metrics"
+	"recipe-agent/internal/tracing"
 )
 
 // AIService AI服务结构
 type AIService struct {
-	apiKey     string
-	baseURL    string
-	model      string
+	apiKey        string
+	baseURL       string
+	model         string
+	sourceTimeout time.Duration
+	// 请求DeepSeek的客户端，生成客户端span并传递追踪上下文和请求ID
+	client *http.Client
+	// DeepSeek熔断器，与翻译服务共用
+	breaker *Breaker
 }
 
 // DeepSeekAPIRequest DeepSeek API请求结构
@@ -54,36 +67,49 @@ type APIUsage struct {
 }
 
 // NewAIService 创建AI服务实例
-func NewAIService() *AIService {
+func NewAIService(cfg config.DeepSeek, breaker *Breaker) *AIService {
 	return &AIService{
-		apiKey:  os.Getenv("DEEPSEEK_API_KEY"),
-		baseURL: "https://api.deepseek.com/v1/chat/completions",
-		model:   "deepseek-chat",
+		apiKey:        cfg.APIKey,
+		baseURL:       cfg.BaseURL,
+		model:         cfg.Model,
+		sourceTimeout: cfg.SourceTimeout.Duration,
+		client:        &http.Client{Transport: upstreamTransport()},
+		breaker:       breaker,
 	}
 }
 
-// AnalyzeIngredients 根据食材分析菜品
-func (s *AIService) AnalyzeIngredients(ingredients []string) (string, error) {
-	if s.apiKey == "" {
-		return s.generateDefaultRecipe(ingredients), nil
-	}
+// Configured 是否配置了API密钥，未配置时返回内置的默认内容
+func (s *AIService) Configured() bool {
+	return s.apiKey != ""
+}
 
-	prompt := s.buildIngredientsPrompt(ingredients)
-	return s.callDeepSeekAPI(prompt)
+// AnalyzeIngredients 根据食材分析菜品
+func (s *AIService) AnalyzeIngredients(ctx context.Context, ingredients []string) (string, error) {
+	return s.Generate(ctx, IngredientsPrompt, PipelineQuery{Type: QueryTypeIngredients, Ingredients: ingredients})
 }
 
 // GetDishDetails 获取菜品详细制作方法
-func (s *AIService) GetDishDetails(dishName string) (string, error) {
+func (s *AIService) GetDishDetails(ctx context.Context, dishName string) (string, error) {
+	return s.Generate(ctx, DishPrompt, PipelineQuery{Type: QueryTypeDish, DishName: dishName})
+}
+
+// Generate 按提示词模板生成内容并附上查询的附加要求，未配置API密钥时返回模板的内置内容
+func (s *AIService) Generate(ctx context.Context, template PromptTemplate, query PipelineQuery) (_ string, err error) {
+	ctx, span := tracing.Start(ctx, "ai.generate",
+		attribute.String("query.type", query.Type),
+		attribute.Bool("ai.configured", s.apiKey != ""),
+	)
+	defer func() { tracing.End(span, err) }()
+
 	if s.apiKey == "" {
-		return s.generateDefaultDishDetails(dishName), nil
+		return template.Default(query), nil
 	}
 
-	prompt := s.buildDishPrompt(dishName)
-	return s.callDeepSeekAPI(prompt)
+	return s.callDeepSeekAPI(ctx, template.Build(query)+query.Constraints.PromptText())
 }
 
 // buildIngredientsPrompt 构建食材分析prompt
-func (s *AIService) buildIngredientsPrompt(ingredients []string) string {
+func buildIngredientsPrompt(ingredients []string) string {
 	ingredientsText := ""
 	for i, ingredient := range ingredients {
 		if i > 0 {
@@ -137,7 +163,7 @@ func (s *AIService) buildIngredientsPrompt(ingredients []string) string {
 }
 
 // buildDishPrompt 构建菜品详情prompt
-func (s *AIService) buildDishPrompt(dishName string) string {
+func buildDishPrompt(dishName string) string {
 	return fmt.Sprintf(`你是一位经验丰富的专业厨师。请为用户提供"%s"的完整、详细的烹饪教程。
 
 请按照以下结构提供信息：
@@ -193,7 +219,13 @@ func (s *AIService) buildDishPrompt(dishName string) string {
 }
 
 // callDeepSeekAPI 调用DeepSeek API
-func (s *AIService) callDeepSeekAPI(prompt string) (string, error) {
+func (s *AIService) callDeepSeekAPI(ctx context.Context, prompt string) (content string, err error) {
+	defer observeUpstream(metrics.ProviderDeepSeek, time.Now(), &err)
+	if err = s.breaker.Allow(); err != nil {
+		return "", err
+	}
+	defer func() { s.breaker.Record(err) }()
+
 	requestBody := DeepSeekAPIRequest{
 		Model: s.model,
 		Messages: []ChatMessage{
@@ -207,48 +239,53 @@ func (s *AIService) callDeepSeekAPI(prompt string) (string, error) {
 
 	jsonData, err := json.Marshal(requestBody)
 	if err != nil {
-		return "", fmt.Errorf("序列化请求失败: %v", err)
+		return "", newSourceError(SourceErrorRequestFailed, "序列化请求失败: %v", err)
 	}
 
-	req, err := http.NewRequest("POST", s.baseURL, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", s.baseURL, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return "", fmt.Errorf("创建请求失败: %v", err)
+		return "", newSourceError(SourceErrorRequestFailed, "创建请求失败: %v", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+s.apiKey)
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := s.client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("API调用失败: %v", err)
+		return "", newSourceError(SourceErrorRequestFailed, "API调用失败: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("API返回错误状态码 %d: %s", resp.StatusCode, string(body))
+		return "", upstreamStatusError(resp.StatusCode, body)
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("读取响应失败: %v", err)
+		return "", newSourceError(SourceErrorInvalidResponse, "读取响应失败: %v", err)
 	}
 
 	var apiResp DeepSeekAPIResponse
 	if err := json.Unmarshal(body, &apiResp); err != nil {
-		return "", fmt.Errorf("解析响应失败: %v", err)
+		return "", newSourceError(SourceErrorInvalidResponse, "解析响应失败: %v", err)
 	}
 
+	metrics.AddTokens(metrics.ProviderDeepSeek, apiResp.Usage.PromptTokens, apiResp.Usage.CompletionTokens)
+	trace.SpanFromContext(ctx).SetAttributes(
+		attribute.Int("llm.prompt_tokens", apiResp.Usage.PromptTokens),
+		attribute.Int("llm.completion_tokens", apiResp.Usage.CompletionTokens),
+	)
+
 	if len(apiResp.Choices) == 0 {
-		return "", fmt.Errorf("API返回空响应")
+		return "", newSourceError(SourceErrorEmptyResponse, "API返回空响应")
 	}
 
 	return apiResp.Choices[0].Message.Content, nil
 }
 
 // generateDefaultRecipe 生成默认食谱（当API不可用时）
-func (s *AIService) generateDefaultRecipe(ingredients []string) string {
+func generateDefaultRecipe(ingredients []string) string {
 	ingredientsText := ""
 	for i, ingredient := range ingredients {
 		if i > 0 {
@@ -299,7 +336,7 @@ func (s *AIService) generateDefaultRecipe(ingredients []string) string {
 }
 
 // generateDefaultDishDetails 生成默认菜品详情（当API不可用时）
-func (s *AIService) generateDefaultDishDetails(dishName string) string {
+func generateDefaultDishDetails(dishName string) string {
 	return fmt.Sprintf(`# %s 制作指南
 
 ## 菜品介绍
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"recipe-agent/internal/config"
+)
+
+// 熔断器状态
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half_open"
+)
+
+// Breaker 上游熔断器：连续失败达到阈值后熔断，冷却期内直接返回 circuit_open 而不请求上游；
+// 冷却期结束后放行一次试探调用，成功则恢复，失败则重新熔断。同时记录上游返回的调用额度
+type Breaker struct {
+	name      string
+	threshold int
+	cooldown  time.Duration
+
+	mutex    sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+	// probing 半开状态下试探调用是否已放行
+	probing         bool
+	lastError       string
+	lastFailureAt   time.Time
+	lastSuccessAt   time.Time
+	quotaExceededAt time.Time
+	quota           *Quota
+}
+
+// Quota 上游响应头中的调用额度，目前只有Spoonacular提供（X-API-Quota-*，单位为点数）
+type Quota struct {
+	Used      float64   `json:"used"`
+	Left      float64   `json:"left"`
+	Request   float64   `json:"request"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// BreakerStatus 熔断器状态
+type BreakerStatus struct {
+	Name                string     `json:"name"`
+	State               string     `json:"state" enum:"closed,open,half_open"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	Threshold           int        `json:"threshold"`
+	Cooldown            string     `json:"cooldown"`
+	OpenedAt            *time.Time `json:"openedAt,omitempty"`
+	RetryAt             *time.Time `json:"retryAt,omitempty"`
+	LastError           string     `json:"lastError,omitempty"`
+	LastFailureAt       *time.Time `json:"lastFailureAt,omitempty"`
+	LastSuccessAt       *time.Time `json:"lastSuccessAt,omitempty"`
+	QuotaExceededAt     *time.Time `json:"quotaExceededAt,omitempty"`
+	Quota               *Quota     `json:"quota,omitempty"`
+}
+
+// NewBreaker 创建熔断器
+func NewBreaker(name string, cfg config.Breaker) *Breaker {
+	return &Breaker{
+		name:      name,
+		threshold: cfg.Threshold,
+		cooldown:  cfg.Cooldown.Duration,
+		state:     BreakerClosed,
+	}
+}
+
+// Name 熔断器名称（上游名称）
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+// Allow 判断是否可以发起调用，熔断期间返回 circuit_open 错误
+func (b *Breaker) Allow() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return newSourceError(SourceErrorCircuitOpen, "%s 已熔断，%s 后重试", b.name, b.openedAt.Add(b.cooldown).Format(time.RFC3339))
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		return nil
+	case BreakerHalfOpen:
+		if b.probing {
+			return newSourceError(SourceErrorCircuitOpen, "%s 正在试探恢复", b.name)
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+// Record 记录一次调用的结果。网络错误、超时、非200状态码和无法解析的响应计为失败，
+// 调用方取消和空结果不影响熔断状态
+func (b *Breaker) Record(err error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	now := time.Now()
+	if SourceErrorCode(err) == SourceErrorQuotaExceeded {
+		b.quotaExceededAt = now
+	}
+	if !breakerFailure(err) {
+		if err == nil || SourceErrorCode(err) == SourceErrorEmptyResponse {
+			b.state = BreakerClosed
+			b.failures = 0
+			b.lastSuccessAt = now
+		}
+		b.probing = false
+		return
+	}
+
+	b.failures++
+	b.lastError = err.Error()
+	b.lastFailureAt = now
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+		b.state = BreakerOpen
+		b.openedAt = now
+	}
+	b.probing = false
+}
+
+// breakerFailure 错误是否说明上游不可用
+func breakerFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	switch SourceErrorCode(err) {
+	case SourceErrorEmptyResponse, SourceErrorNotConfigured, SourceErrorLowConfidence, SourceErrorCircuitOpen:
+		return false
+	}
+	return true
+}
+
+// RecordQuota 从响应头中读取调用额度，没有额度信息时忽略
+func (b *Breaker) RecordQuota(header http.Header) {
+	used, usedErr := strconv.ParseFloat(header.Get("X-API-Quota-Used"), 64)
+	left, leftErr := strconv.ParseFloat(header.Get("X-API-Quota-Left"), 64)
+	if usedErr != nil && leftErr != nil {
+		return
+	}
+	request, _ := strconv.ParseFloat(header.Get("X-API-Quota-Request"), 64)
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.quota = &Quota{Used: used, Left: left, Request: request, UpdatedAt: time.Now()}
+}
+
+// Reset 手动恢复为关闭状态，例如确认上游已恢复或更换了API密钥之后
+func (b *Breaker) Reset() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.state = BreakerClosed
+	b.failures = 0
+	b.probing = false
+}
+
+// Status 返回熔断器状态
+func (b *Breaker) Status() BreakerStatus {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	status := BreakerStatus{
+		Name:                b.name,
+		State:               b.state,
+		ConsecutiveFailures: b.failures,
+		Threshold:           b.threshold,
+		Cooldown:            b.cooldown.String(),
+		LastError:           b.lastError,
+		LastFailureAt:       optionalTime(b.lastFailureAt),
+		LastSuccessAt:       optionalTime(b.lastSuccessAt),
+		QuotaExceededAt:     optionalTime(b.quotaExceededAt),
+	}
+	if b.state != BreakerClosed {
+		status.OpenedAt = optionalTime(b.openedAt)
+		status.RetryAt = optionalTime(b.openedAt.Add(b.cooldown))
+	}
+	if b.quota != nil {
+		quota := *b.quota
+		status.Quota = &quota
+	}
+	return status
+}
+
+// optionalTime 零值时间返回nil，JSON中省略
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CachedEntry 管理接口展示的缓存条目
+type CachedEntry struct {
+	Key string `json:"key"`
+	// Value 翻译缓存的译文，食谱缓存的原始响应较大，只返回 Size
+	Value     string    `json:"value,omitempty"`
+	Size      int       `json:"size"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	Expired   bool      `json:"expired"`
+}
+
+// sortCachedEntries 按键排序
+func sortCachedEntries(entries []CachedEntry) {
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Key < entries[j].Key
+	})
+}
+
+// CacheWarmResult 单个预热查询的结果
+type CacheWarmResult struct {
+	Type     string `json:"type" enum:"ingredients,dish"`
+	Query    string `json:"query"`
+	CacheHit bool   `json:"cacheHit"`
+	Recipes  int    `json:"recipes"`
+	Error    string `json:"error,omitempty"`
+}
+
+// WarmCache 按食材组合和菜名预先查询，翻译和搜索结果写入缓存；concurrency 为同时进行的查询数。
+// 已在缓存中的查询直接命中，不重复请求上游
+func (s *RecipeService) WarmCache(ctx context.Context, ingredientGroups [][]string, dishNames []string, concurrency int) []CacheWarmResult {
+	var ingredients []string
+	for _, group := range ingredientGroups {
+		ingredients = append(ingredients, group...)
+	}
+	dishNames = uniqueStrings(dishNames)
+	s.WarmTranslations(ctx, ingredients, dishNames)
+
+	results := make([]CacheWarmResult, 0, len(ingredientGroups)+len(dishNames))
+	searches := make([]func() (*RecipeSearch, error), 0, cap(results))
+	for _, group := range ingredientGroups {
+		results = append(results, CacheWarmResult{Type: QueryTypeIngredients, Query: strings.Join(group, ",")})
+		searches = append(searches, func() (*RecipeSearch, error) {
+			return s.SearchByIngredientsDetailed(ctx, group)
+		})
+	}
+	for _, dishName := range dishNames {
+		results = append(results, CacheWarmResult{Type: QueryTypeDish, Query: dishName})
+		searches = append(searches, func() (*RecipeSearch, error) {
+			return s.SearchByDishNameDetailed(ctx, dishName)
+		})
+	}
+
+	semaphore := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, search := range searches {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			found, err := search()
+			switch {
+			case err != nil:
+				results[i].Error = err.Error()
+			case found.Skipped != "":
+				results[i].Error = "未查询: " + found.Skipped
+			default:
+				results[i].CacheHit = found.CacheHit
+				results[i].Recipes = len(found.Recipes)
+			}
+		}()
+	}
+	wg.Wait()
+	return results
+}
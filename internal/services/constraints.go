@@ -0,0 +1,73 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Constraints 查询的附加要求，生成提示词时附在末尾
+type Constraints struct {
+	MaxMinutes int `json:"maxMinutes,omitempty" description:"总耗时上限（分钟）"`
+	Servings   int `json:"servings,omitempty" description:"用餐人数"`
+	// Avoid 忌口，如 辣、香菜；Diet 饮食偏好，如 素食、低脂
+	Avoid []string `json:"avoid,omitempty"`
+	Diet  []string `json:"diet,omitempty"`
+	// Quantities 现有食材的用量，键为规范化后的食材名
+	Quantities map[string]string `json:"quantities,omitempty"`
+}
+
+// Empty 是否没有任何要求
+func (c *Constraints) Empty() bool {
+	return c == nil || (c.MaxMinutes == 0 && c.Servings == 0 && len(c.Avoid) == 0 && len(c.Diet) == 0 && len(c.Quantities) == 0)
+}
+
+// Key 生成稳定的文本表示，用于合并相同请求
+func (c *Constraints) Key() string {
+	if c.Empty() {
+		return ""
+	}
+	return fmt.Sprintf("%d;%d;%s;%s;%s", c.MaxMinutes, c.Servings,
+		strings.Join(c.Avoid, ","), strings.Join(c.Diet, ","), c.quantitiesText(","))
+}
+
+// PromptText 生成附加在提示词末尾的要求说明，没有要求时返回空字符串
+func (c *Constraints) PromptText() string {
+	if c.Empty() {
+		return ""
+	}
+
+	var lines []string
+	if c.MaxMinutes > 0 {
+		lines = append(lines, fmt.Sprintf("- 总耗时（准备+烹饪）不超过%d分钟", c.MaxMinutes))
+	}
+	if c.Servings > 0 {
+		lines = append(lines, fmt.Sprintf("- 份量按%d人准备", c.Servings))
+	}
+	if len(c.Avoid) > 0 {
+		lines = append(lines, "- 忌口，不要使用或出现："+strings.Join(c.Avoid, "、"))
+	}
+	if len(c.Diet) > 0 {
+		lines = append(lines, "- 饮食偏好："+strings.Join(c.Diet, "、"))
+	}
+	if len(c.Quantities) > 0 {
+		lines = append(lines, "- 现有食材用量："+c.quantitiesText("、")+"，请按这些用量给出做法")
+	}
+
+	return "\n\n用户的附加要求（必须遵守）：\n" + strings.Join(lines, "\n")
+}
+
+// quantitiesText 按食材名排序输出用量
+func (c *Constraints) quantitiesText(separator string) string {
+	names := make([]string, 0, len(c.Quantities))
+	for name := range c.Quantities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = name + " " + c.Quantities[name]
+	}
+	return strings.Join(parts, separator)
+}
@@ -0,0 +1,311 @@
+package services
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/mozillazg/go-pinyin"
+
+	"recipe-agent/internal/config"
+)
+
+// 纠错对象类型
+const (
+	CorrectionKindIngredient = "ingredient"
+	CorrectionKindDish       = "dish"
+)
+
+// 纠错结果
+const (
+	// CorrectionExact 与已知名称完全一致或包含已知菜名，无需纠正
+	CorrectionExact = "exact"
+	// CorrectionCorrected 只有一个足够接近的名称，已自动纠正
+	CorrectionCorrected = "corrected"
+	// CorrectionAmbiguous 有多个同样接近的名称，需要用户选择
+	CorrectionAmbiguous = "ambiguous"
+	// CorrectionUnknown 没有足够接近的名称，按原样使用
+	CorrectionUnknown = "unknown"
+)
+
+// 相似度阈值：达到 autoCorrectSimilarity 时自动纠正，与最佳结果相差不超过
+// ambiguityMargin 的其他名称视为同样接近；建议列表只包含达到 suggestSimilarity 的名称
+const (
+	autoCorrectSimilarity = 0.8
+	suggestSimilarity     = 0.6
+	ambiguityMargin       = 0.05
+	maxCorrectionHints    = 5
+)
+
+// 替换一个字的代价：同音字（不计声调）、近音字（平翘舌、前后鼻音、n/l/r 不分）和其他字
+const (
+	costHomophone = 0.2
+	costNearSound = 0.5
+	costDifferent = 1.0
+)
+
+// Correction 一次自动纠正记录
+type Correction struct {
+	Field      string  `json:"field" description:"被纠正的字段，如 dishName、ingredients[1]"`
+	Original   string  `json:"original"`
+	Corrected  string  `json:"corrected"`
+	Similarity float64 `json:"similarity" description:"相似度，0到1之间"`
+}
+
+// CorrectionResult 名称纠错结果
+type CorrectionResult struct {
+	Status      string
+	Corrected   string
+	Similarity  float64
+	Suggestions []string
+}
+
+// correctionTerm 可纠正到的名称
+type correctionTerm struct {
+	// text 纠正后使用的名称，key 区分不同的条目（同一食材的别名共用一个key）
+	text  string
+	key   string
+	runes []rune
+	// readings 每个字的不带声调的拼音读法，多音字包含所有读法
+	readings [][]string
+}
+
+// CorrectionService 将拼写错误的食材和菜名纠正为食材本体和菜名数据中的已知名称，
+// 按字编辑距离计算相似度，同音字和近音字的替换代价较低
+type CorrectionService struct {
+	ontology *IngredientOntology
+	terms    map[string][]*correctionTerm
+	dishes   []string
+}
+
+// NewCorrectionService 创建纠错服务实例，索引食材本体中的所有名称和常见菜名
+func NewCorrectionService(cfg config.Data, ontology *IngredientOntology) *CorrectionService {
+	s := &CorrectionService{
+		ontology: ontology,
+		terms:    make(map[string][]*correctionTerm),
+		dishes:   loadDishNames(cfg.DishesFile),
+	}
+
+	for _, ingredient := range ontology.Ingredients() {
+		names := append([]string{ingredient.Chinese, ingredient.English}, ingredient.Synonyms...)
+		for _, name := range names {
+			s.add(CorrectionKindIngredient, ingredient.ID, name)
+		}
+	}
+	for _, dish := range s.dishes {
+		s.add(CorrectionKindDish, dish, dish)
+	}
+	return s
+}
+
+// add 加入可纠正到的名称
+func (s *CorrectionService) add(kind, key, name string) {
+	normalized := normalizeSuggestQuery(name)
+	if normalized == "" {
+		return
+	}
+	runes := []rune(normalized)
+	s.terms[kind] = append(s.terms[kind], &correctionTerm{
+		text:     strings.TrimSpace(name),
+		key:      key,
+		runes:    runes,
+		readings: runeReadings(runes),
+	})
+}
+
+// Correct 查找与名称最接近的已知名称。只有一个条目达到自动纠正阈值时纠正，
+// 多个条目同样接近时返回建议，都不够接近时按原样使用
+func (s *CorrectionService) Correct(kind, name string) CorrectionResult {
+	name = strings.TrimSpace(name)
+	normalized := normalizeSuggestQuery(name)
+	if normalized == "" || s.known(kind, name, normalized) {
+		return CorrectionResult{Status: CorrectionExact, Corrected: name, Similarity: 1}
+	}
+
+	runes := []rune(normalized)
+	readings := runeReadings(runes)
+
+	// 每个条目只保留相似度最高的名称
+	best := make(map[string]candidateTerm)
+	for _, term := range s.terms[kind] {
+		similarity, hardEdits := termSimilarity(runes, readings, term)
+		if similarity < suggestSimilarity {
+			continue
+		}
+		if current, seen := best[term.key]; !seen || similarity > current.similarity {
+			best[term.key] = candidateTerm{term: term, similarity: similarity, hardEdits: hardEdits}
+		}
+	}
+	if len(best) == 0 {
+		return CorrectionResult{Status: CorrectionUnknown, Corrected: name}
+	}
+
+	candidates := make([]candidateTerm, 0, len(best))
+	for _, candidate := range best {
+		candidates = append(candidates, candidate)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].similarity != candidates[j].similarity {
+			return candidates[i].similarity > candidates[j].similarity
+		}
+		return candidates[i].term.text < candidates[j].term.text
+	})
+
+	top := candidates[0]
+	if top.similarity < autoCorrectSimilarity {
+		return CorrectionResult{Status: CorrectionUnknown, Corrected: name}
+	}
+	if len(candidates) == 1 || candidates[1].similarity < top.similarity-ambiguityMargin {
+		// 中文名称只纠正读音相同或相近的错字，增删字或换成读音不同的字（如 西红柿鸡蛋面）可能是另一道菜
+		if top.hardEdits > 0 && containsHan(name) {
+			return CorrectionResult{Status: CorrectionUnknown, Corrected: name}
+		}
+		return CorrectionResult{Status: CorrectionCorrected, Corrected: top.term.text, Similarity: roundSimilarity(top.similarity)}
+	}
+
+	result := CorrectionResult{Status: CorrectionAmbiguous, Corrected: name}
+	for _, candidate := range candidates {
+		if len(result.Suggestions) >= maxCorrectionHints {
+			break
+		}
+		result.Suggestions = append(result.Suggestions, candidate.term.text)
+	}
+	return result
+}
+
+// candidateTerm 候选名称及相似度
+type candidateTerm struct {
+	term       *correctionTerm
+	similarity float64
+	hardEdits  int
+}
+
+// known 名称是否已知：食材在本体中有收录；菜名在数据中有收录，或包含已知菜名（如 西红柿炒鸡蛋盖饭）
+func (s *CorrectionService) known(kind, name, normalized string) bool {
+	if kind == CorrectionKindIngredient {
+		_, exists := s.ontology.Lookup(name)
+		return exists
+	}
+	for _, dish := range s.dishes {
+		if strings.Contains(normalized, normalizeSuggestQuery(dish)) {
+			return true
+		}
+	}
+	return false
+}
+
+// editCell 编辑距离及其中增删字和读音不同的替换次数
+type editCell struct {
+	distance float64
+	hard     int
+}
+
+// less 优先比较距离，距离相同时比较读音不同的编辑次数
+func (c editCell) less(other editCell) bool {
+	if c.distance != other.distance {
+		return c.distance < other.distance
+	}
+	return c.hard < other.hard
+}
+
+// termSimilarity 按加权编辑距离计算相似度（1 表示完全一致），同时返回增删字和读音不同的替换次数
+func termSimilarity(runes []rune, readings [][]string, term *correctionTerm) (float64, int) {
+	longest := len(runes)
+	if len(term.runes) > longest {
+		longest = len(term.runes)
+	}
+	// 长度相差过大时不可能达到建议阈值
+	if diff := len(runes) - len(term.runes); float64(abs(diff)) > float64(longest)*(1-suggestSimilarity) {
+		return 0, 0
+	}
+
+	previous := make([]editCell, len(term.runes)+1)
+	current := make([]editCell, len(term.runes)+1)
+	for j := range previous {
+		previous[j] = editCell{distance: float64(j), hard: j}
+	}
+	for i := 1; i <= len(runes); i++ {
+		current[0] = editCell{distance: float64(i), hard: i}
+		for j := 1; j <= len(term.runes); j++ {
+			cost := substitutionCost(runes[i-1], readings[i-1], term.runes[j-1], term.readings[j-1])
+			cell := editCell{distance: previous[j-1].distance + cost, hard: previous[j-1].hard}
+			if cost == costDifferent {
+				cell.hard++
+			}
+			for _, edit := range []editCell{
+				{distance: previous[j].distance + 1, hard: previous[j].hard + 1},
+				{distance: current[j-1].distance + 1, hard: current[j-1].hard + 1},
+			} {
+				if edit.less(cell) {
+					cell = edit
+				}
+			}
+			current[j] = cell
+		}
+		previous, current = current, previous
+	}
+
+	result := previous[len(term.runes)]
+	return 1 - result.distance/float64(longest), result.hard
+}
+
+// substitutionCost 用字符b替换字符a的代价
+func substitutionCost(a rune, readingsA []string, b rune, readingsB []string) float64 {
+	if a == b {
+		return 0
+	}
+	cost := costDifferent
+	for _, x := range readingsA {
+		for _, y := range readingsB {
+			if x == y {
+				return costHomophone
+			}
+			if foldSyllable(x) == foldSyllable(y) {
+				cost = costNearSound
+			}
+		}
+	}
+	return cost
+}
+
+// foldSyllable 合并容易混淆的读音：平翘舌（zh/z、ch/c、sh/s）、前后鼻音（ng/n）和 n/l/r 声母
+func foldSyllable(syllable string) string {
+	for _, retroflex := range []string{"zh", "ch", "sh"} {
+		if strings.HasPrefix(syllable, retroflex) {
+			syllable = syllable[:1] + syllable[2:]
+			break
+		}
+	}
+	if strings.HasPrefix(syllable, "n") || strings.HasPrefix(syllable, "r") {
+		syllable = "l" + syllable[1:]
+	}
+	return strings.TrimSuffix(syllable, "g")
+}
+
+// runeReadings 返回每个字不带声调的拼音读法，非汉字没有读法
+func runeReadings(runes []rune) [][]string {
+	args := pinyin.NewArgs()
+	args.Heteronym = true
+
+	readings := make([][]string, len(runes))
+	for i, r := range runes {
+		if r >= rune('一') && r <= rune('鿿') {
+			if result := pinyin.Pinyin(string(r), args); len(result) > 0 {
+				readings[i] = uniqueStrings(result[0])
+			}
+		}
+	}
+	return readings
+}
+
+// roundSimilarity 相似度保留两位小数
+func roundSimilarity(similarity float64) float64 {
+	return float64(int(similarity*100+0.5)) / 100
+}
+
+// abs 整数绝对值
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,299 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"recipe-agent/internal/config"
+)
+
+// 食材类别
+const (
+	CategoryVegetable = "vegetable"
+	CategoryMeat      = "meat"
+	CategorySeafood   = "seafood"
+	CategoryEgg       = "egg"
+	CategorySoy       = "soy"
+	CategoryGrain     = "grain"
+	CategoryDairy     = "dairy"
+	CategorySeasoning = "seasoning"
+)
+
+// Ingredient 食材本体条目
+type Ingredient struct {
+	ID       string   `json:"id"`
+	Chinese  string   `json:"zh"`
+	English  string   `json:"en"`
+	Synonyms []string `json:"synonyms"`
+	Category string   `json:"category"`
+	Units    []string `json:"units"`
+}
+
+// IngredientOntology 食材本体（规范ID、中英文名、地区别名）
+type IngredientOntology struct {
+	ingredients []*Ingredient
+	byID        map[string]*Ingredient
+	byName      map[string]*Ingredient
+	// 按名称长度降序排列，用于子串匹配时优先命中更具体的名称
+	namesByLength []string
+	// standaloneOnly 单字别名（如 面、肉、蛋），常出现在其他词语中（里面、蛋糕），
+	// 在文本中只有作为独立的词出现时才匹配
+	standaloneOnly map[string]bool
+}
+
+// 单字别名前后允许出现的字：量词、介词、连词和语气词
+const (
+	standaloneBefore = "个只块根条片斤克两些点碗勺盒袋包把颗瓣杯串有和跟与及用加放"
+	standaloneAfter  = "和跟与及还也吗呢吧啊了的可"
+)
+
+// NewIngredientOntology 从数据文件加载食材本体，加载失败时返回空本体
+func NewIngredientOntology(cfg config.Data) *IngredientOntology {
+	ontology, err := LoadIngredientOntology(cfg.IngredientsFile)
+	if err != nil {
+		slog.Warn("加载食材本体失败，将不做食材归一化", "error", err)
+		return newIngredientOntology(nil)
+	}
+	slog.Info("已加载食材本体", "ingredients", len(ontology.ingredients))
+	return ontology
+}
+
+// LoadIngredientOntology 从JSON文件加载食材本体
+func LoadIngredientOntology(path string) (*IngredientOntology, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取食材数据文件失败: %v", err)
+	}
+
+	var ingredients []*Ingredient
+	if err := json.Unmarshal(data, &ingredients); err != nil {
+		return nil, fmt.Errorf("解析食材数据文件失败: %v", err)
+	}
+
+	for i, ingredient := range ingredients {
+		if ingredient.ID == "" || ingredient.Chinese == "" || ingredient.English == "" {
+			return nil, fmt.Errorf("第%d个食材缺少id、zh或en字段", i+1)
+		}
+	}
+
+	return newIngredientOntology(ingredients), nil
+}
+
+// newIngredientOntology 构建名称索引
+func newIngredientOntology(ingredients []*Ingredient) *IngredientOntology {
+	o := &IngredientOntology{
+		ingredients:    ingredients,
+		byID:           make(map[string]*Ingredient),
+		byName:         make(map[string]*Ingredient),
+		standaloneOnly: make(map[string]bool),
+	}
+
+	for _, ingredient := range ingredients {
+		o.byID[ingredient.ID] = ingredient
+		names := append([]string{ingredient.Chinese, ingredient.English}, ingredient.Synonyms...)
+		for _, name := range names {
+			key := normalizeName(name)
+			if key == "" {
+				continue
+			}
+			// 先出现的条目优先，避免别名覆盖规范名称
+			if _, exists := o.byName[key]; !exists {
+				o.byName[key] = ingredient
+				o.namesByLength = append(o.namesByLength, key)
+				if utf8.RuneCountInString(key) == 1 && key != ingredient.Chinese {
+					o.standaloneOnly[key] = true
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(o.namesByLength, func(i, j int) bool {
+		return utf8.RuneCountInString(o.namesByLength[i]) > utf8.RuneCountInString(o.namesByLength[j])
+	})
+
+	return o
+}
+
+// normalizeName 统一名称格式（去空白、转小写）
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// Lookup 按名称或别名精确查找食材
+func (o *IngredientOntology) Lookup(name string) (*Ingredient, bool) {
+	ingredient, exists := o.byName[normalizeName(name)]
+	return ingredient, exists
+}
+
+// Get 按规范ID查找食材
+func (o *IngredientOntology) Get(id string) (*Ingredient, bool) {
+	ingredient, exists := o.byID[id]
+	return ingredient, exists
+}
+
+// Match 在文本中查找包含的食材名称，优先匹配最长的名称；单字别名只在作为独立的词出现时匹配
+func (o *IngredientOntology) Match(text string) (*Ingredient, bool) {
+	text = normalizeName(text)
+	if text == "" {
+		return nil, false
+	}
+	if ingredient, exists := o.byName[text]; exists {
+		return ingredient, true
+	}
+	for _, name := range o.namesByLength {
+		for offset := 0; ; {
+			index := strings.Index(text[offset:], name)
+			if index < 0 {
+				break
+			}
+			start := offset + index
+			offset = start + len(name)
+			if o.matchesAt(text, name, start, offset) {
+				return o.byName[name], true
+			}
+		}
+	}
+	return nil, false
+}
+
+// matchesAt 名称出现在 text[start:end] 时是否算作匹配。单字别名的前后需要是文本边界、
+// 非文字字符或 standaloneBefore/standaloneAfter 中的字，如“两个蛋”“肉和菜”匹配，“里面有”“蛋糕”不匹配
+func (o *IngredientOntology) matchesAt(text, name string, start, end int) bool {
+	if !o.standaloneOnly[name] {
+		return true
+	}
+	if before, _ := utf8.DecodeLastRuneInString(text[:start]); start > 0 &&
+		unicode.IsLetter(before) && !strings.ContainsRune(standaloneBefore, before) {
+		return false
+	}
+	if after, _ := utf8.DecodeRuneInString(text[end:]); end < len(text) &&
+		unicode.IsLetter(after) && !strings.ContainsRune(standaloneAfter, after) {
+		return false
+	}
+	return true
+}
+
+// IngredientMention 文本中出现的食材，Start 和 End 为字节偏移
+type IngredientMention struct {
+	Ingredient *Ingredient
+	Name       string
+	Start      int
+	End        int
+}
+
+// FindAll 按出现顺序查找文本中的所有食材，较长的名称优先，已匹配的部分不再参与匹配；
+// 单字别名只在作为独立的词出现时匹配
+func (o *IngredientOntology) FindAll(text string) []IngredientMention {
+	lower := strings.ToLower(text)
+	if len(lower) != len(text) {
+		// 大小写转换改变了长度时直接使用转换后的文本，保证偏移一致
+		text = lower
+	}
+	used := make([]bool, len(lower))
+	var mentions []IngredientMention
+
+	for _, name := range o.namesByLength {
+		offset := 0
+		for {
+			index := strings.Index(lower[offset:], name)
+			if index < 0 {
+				break
+			}
+			start := offset + index
+			end := start + len(name)
+			offset = end
+			if !o.matchesAt(lower, name, start, end) {
+				continue
+			}
+
+			overlapped := false
+			for i := start; i < end; i++ {
+				if used[i] {
+					overlapped = true
+					break
+				}
+			}
+			if overlapped {
+				continue
+			}
+			for i := start; i < end; i++ {
+				used[i] = true
+			}
+			mentions = append(mentions, IngredientMention{Ingredient: o.byName[name], Name: text[start:end], Start: start, End: end})
+		}
+	}
+
+	sort.Slice(mentions, func(i, j int) bool {
+		return mentions[i].Start < mentions[j].Start
+	})
+	return mentions
+}
+
+// Canonicalize 将食材名称规范化为本体中的中文名，未收录的食材原样返回
+func (o *IngredientOntology) Canonicalize(name string) string {
+	name = strings.TrimSpace(name)
+	if ingredient, exists := o.Lookup(name); exists {
+		return ingredient.Chinese
+	}
+	return name
+}
+
+// CanonicalizeAll 规范化食材列表，去除空值和重复项并保持原有顺序
+func (o *IngredientOntology) CanonicalizeAll(names []string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, name := range names {
+		canonical := o.Canonicalize(name)
+		if canonical == "" || seen[canonical] {
+			continue
+		}
+		seen[canonical] = true
+		result = append(result, canonical)
+	}
+	return result
+}
+
+// CanonicalKey 返回用于缓存等场景的规范标识，未收录的食材使用规范化后的名称
+func (o *IngredientOntology) CanonicalKey(name string) string {
+	if ingredient, exists := o.Lookup(name); exists {
+		return ingredient.ID
+	}
+	return normalizeName(name)
+}
+
+// Translations 返回中文名及中文别名到英文名的映射
+func (o *IngredientOntology) Translations() map[string]string {
+	translations := make(map[string]string)
+	for _, ingredient := range o.ingredients {
+		translations[ingredient.Chinese] = ingredient.English
+		for _, synonym := range ingredient.Synonyms {
+			if containsHan(synonym) {
+				if _, exists := translations[synonym]; !exists {
+					translations[synonym] = ingredient.English
+				}
+			}
+		}
+	}
+	return translations
+}
+
+// Ingredients 返回所有食材条目
+func (o *IngredientOntology) Ingredients() []*Ingredient {
+	return o.ingredients
+}
+
+// containsHan 检查字符串是否包含中文字符
+func containsHan(str string) bool {
+	for _, r := range str {
+		if r >= rune('\u4e00') && r <= rune('\u9fff') {
+			return true
+		}
+	}
+	return false
+}
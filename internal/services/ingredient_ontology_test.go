@@ -0,0 +1,91 @@
+package services
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"recipe-agent/internal/config"
+)
+
+// newTestOntology 加载仓库中的食材本体
+func newTestOntology(t *testing.T) *IngredientOntology {
+	t.Helper()
+	ontology, err := LoadIngredientOntology(filepath.Join("..", "..", "data", "ingredients.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ontology
+}
+
+func TestOntologyLookupSingleRuneSynonym(t *testing.T) {
+	ontology := newTestOntology(t)
+	for name, want := range map[string]string{"面": "面条", "蛋": "鸡蛋", "肉": "猪肉", "油": "食用油", "虾": "虾"} {
+		ingredient, exists := ontology.Lookup(name)
+		if !exists || ingredient.Chinese != want {
+			t.Errorf("Lookup(%q) = %v, want %s", name, ingredient, want)
+		}
+	}
+}
+
+func TestOntologyFindAllSingleRuneSynonyms(t *testing.T) {
+	ontology := newTestOntology(t)
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"冰箱里面有鸡蛋", []string{"鸡蛋"}},
+		{"蛋糕和油条", nil},
+		{"蚝油生菜", []string{"蚝油"}},
+		{"米饭", []string{"米饭"}},
+		{"两个蛋和一块肉", []string{"鸡蛋", "猪肉"}},
+		{"有面吗", []string{"面条"}},
+		{"鸡、蛋、面", []string{"鸡肉", "鸡蛋", "面条"}},
+		{"冰箱里有虾和葱", []string{"虾", "葱"}},
+		{"洋葱炒蛋", []string{"洋葱"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, mention := range ontology.FindAll(tt.text) {
+			got = append(got, mention.Ingredient.Chinese)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("FindAll(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestOntologyMatchSingleRuneSynonyms(t *testing.T) {
+	ontology := newTestOntology(t)
+	tests := map[string]string{
+		"面":    "面条",
+		"鸡胸肉":  "鸡肉",
+		"里面":   "",
+		"蛋挞皮":  "",
+		"油麦菜":  "",
+		"新鲜的虾": "虾",
+	}
+	for text, want := range tests {
+		ingredient, exists := ontology.Match(text)
+		got := ""
+		if exists {
+			got = ingredient.Chinese
+		}
+		if got != want {
+			t.Errorf("Match(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
+
+func TestParseRulesIgnoresSingleRuneSynonymsInsideWords(t *testing.T) {
+	data := config.Data{
+		IngredientsFile: filepath.Join("..", "..", "data", "ingredients.json"),
+		DishesFile:      filepath.Join("..", "..", "data", "dishes.json"),
+	}
+	s := NewIntentService(data, NewIngredientOntology(data), nil)
+
+	interpretation := s.parseRules("冰箱里面有鸡蛋，能做什么")
+	if names := interpretation.IngredientNames(); strings.Join(names, ",") != "鸡蛋" {
+		t.Errorf("ingredients = %v, want [鸡蛋]", names)
+	}
+}
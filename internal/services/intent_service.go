@@ -0,0 +1,433 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"recipe-agent/internal/config"
+	"recipe-agent/internal/tracing"
+)
+
+// 意图解析方式
+const (
+	IntentMethodRules   = "rules"   // 规则解析
+	IntentMethodAI      = "ai"      // 规则无法判断，由AI解析
+	IntentMethodDefault = "default" // 规则和AI都无法判断，按烹饪问答处理
+)
+
+// ParsedIngredient 从句子中解析出的食材及用量
+type ParsedIngredient struct {
+	Name     string `json:"name"`
+	Quantity string `json:"quantity,omitempty"`
+	Unit     string `json:"unit,omitempty"`
+	// Mention 原文中的写法
+	Mention string `json:"mention"`
+}
+
+// Interpretation 自然语言查询的解析结果，返回给用户确认或修改
+type Interpretation struct {
+	QueryType   string             `json:"queryType"`
+	Ingredients []ParsedIngredient `json:"ingredients"`
+	DishName    string             `json:"dishName,omitempty"`
+	Question    string             `json:"question,omitempty"`
+	Constraints Constraints        `json:"constraints"`
+	Method      string             `json:"method" enum:"rules,ai,default"`
+	// Summary 便于展示的解析摘要
+	Summary string `json:"summary"`
+}
+
+// IngredientNames 返回解析出的食材名称
+func (i *Interpretation) IngredientNames() []string {
+	names := make([]string, len(i.Ingredients))
+	for index, ingredient := range i.Ingredients {
+		names[index] = ingredient.Name
+	}
+	return names
+}
+
+// 意图关键词
+var (
+	substituteKeywords = []string{"代替", "替代", "替换", "换成", "用什么代", "可以用什么"}
+	pairingKeywords    = []string{"配什么", "配啥", "搭配", "配菜", "配点什么"}
+	leftoverKeywords   = []string{"剩", "吃不完", "昨天的", "隔夜"}
+	dishKeywords       = []string{"怎么做", "做法", "怎么烧", "怎么炒", "如何做", "教程", "想吃", "学做"}
+	cookKeywords       = []string{"能做", "做什么", "做点", "做个", "做道", "推荐", "冰箱", "家里有", "快手菜", "想做", "有什么菜"}
+	questionKeywords   = []string{"怎么", "如何", "为什么", "多久", "多少", "能不能", "可不可以", "是否", "吗", "？", "?"}
+	dietKeywords       = []string{"素食", "纯素", "吃素", "低脂", "低糖", "低卡", "减脂", "清淡", "无麸质", "清真", "低盐"}
+)
+
+// 约束条件的匹配规则
+var (
+	chineseNumber   = `[0-9]+|[零一二两三四五六七八九十百半几]+`
+	minutesPattern  = regexp.MustCompile(`(` + chineseNumber + `)\s*(?:分钟|min)`)
+	hoursPattern    = regexp.MustCompile(`(` + chineseNumber + `|半)\s*(?:个)?(?:小时|钟头)`)
+	servingsPattern = regexp.MustCompile(`(` + chineseNumber + `)\s*(?:个|口)?人(?:份|吃|的量)?`)
+	avoidPattern    = regexp.MustCompile(`(?:不要|不吃|不能吃|别放|不放|不加|忌口?|避开)\s*([^，。,.;；！!？?\s和跟及]+)`)
+	allergyPattern  = regexp.MustCompile(`对\s*([^，。,.;；！!？?\s]+?)\s*过敏`)
+	quantityPattern = regexp.MustCompile(`(` + chineseNumber + `)\s*(个|颗|根|片|块|克|g|千克|kg|斤|两|把|勺|汤匙|茶匙|只|条|瓣|杯|盒|袋|包|棵|头|段|碗|毫升|ml|升|朵|串)?\s*$`)
+)
+
+// IntentService 将自然语言查询解析为结构化请求：先按规则解析，规则无法判断查询类型时交给AI
+type IntentService struct {
+	ontology  *IngredientOntology
+	aiService *AIService
+	dishes    []string
+}
+
+// NewIntentService 创建意图解析服务，菜名识别使用 DISH_DATA_FILE 中的常见菜名
+func NewIntentService(cfg config.Data, ontology *IngredientOntology, aiService *AIService) *IntentService {
+	dishes := loadDishNames(cfg.DishesFile)
+	// 较长的菜名优先匹配，例如 西红柿鸡蛋汤 优先于 鸡蛋汤
+	sort.SliceStable(dishes, func(i, j int) bool {
+		return utf8.RuneCountInString(dishes[i]) > utf8.RuneCountInString(dishes[j])
+	})
+	return &IntentService{ontology: ontology, aiService: aiService, dishes: dishes}
+}
+
+// Parse 解析自然语言查询，queryTypes 为允许的查询类型
+func (s *IntentService) Parse(ctx context.Context, text string, queryTypes []string) (interpretation Interpretation) {
+	ctx, span := tracing.Start(ctx, "intent.parse")
+	defer func() {
+		span.SetAttributes(
+			attribute.String("intent.method", interpretation.Method),
+			attribute.String("query.type", interpretation.QueryType),
+		)
+		span.End()
+	}()
+
+	text = strings.TrimSpace(text)
+	interpretation = s.parseRules(text)
+
+	if interpretation.QueryType == "" {
+		if parsed, err := s.parseAI(ctx, text, queryTypes); err == nil {
+			interpretation = parsed
+		} else {
+			if s.aiService.Configured() {
+				slog.WarnContext(ctx, "AI意图解析失败，按烹饪问答处理", "error", err)
+			}
+			interpretation.QueryType = QueryTypeQuestion
+			interpretation.Question = text
+			interpretation.Method = IntentMethodDefault
+		}
+	}
+
+	if interpretation.Ingredients == nil {
+		interpretation.Ingredients = []ParsedIngredient{}
+	}
+	interpretation.Summary = interpretation.summary()
+	return interpretation
+}
+
+// parseRules 按关键词、菜名库和食材本体解析，无法判断查询类型时 QueryType 为空
+func (s *IntentService) parseRules(text string) Interpretation {
+	interpretation := Interpretation{Method: IntentMethodRules}
+
+	// 约束条件中出现的食材（如 不吃香菜）不作为可用食材
+	masked := text
+	masked, interpretation.Constraints = parseConstraints(masked)
+
+	// 先识别菜名，菜名中的食材不单独计入
+	for _, dish := range s.dishes {
+		if index := strings.Index(masked, dish); index >= 0 {
+			interpretation.DishName = dish
+			masked = maskRange(masked, index, index+len(dish))
+			break
+		}
+	}
+
+	for _, mention := range s.ontology.FindAll(masked) {
+		ingredient := ParsedIngredient{Name: mention.Ingredient.Chinese, Mention: mention.Name}
+		if match := quantityPattern.FindStringSubmatch(lastRunes(masked[:mention.Start], 8)); match != nil {
+			ingredient.Quantity = match[1]
+			ingredient.Unit = match[2]
+		}
+		interpretation.Ingredients = appendIngredient(interpretation.Ingredients, ingredient)
+	}
+	for _, ingredient := range interpretation.Ingredients {
+		if ingredient.Quantity == "" {
+			continue
+		}
+		if interpretation.Constraints.Quantities == nil {
+			interpretation.Constraints.Quantities = make(map[string]string)
+		}
+		interpretation.Constraints.Quantities[ingredient.Name] = ingredient.Quantity + ingredient.Unit
+	}
+
+	hasDish := interpretation.DishName != ""
+	hasIngredients := len(interpretation.Ingredients) > 0
+	switch {
+	case containsAny(text, substituteKeywords) && hasIngredients:
+		interpretation.QueryType = QueryTypeSubstitute
+	case containsAny(text, pairingKeywords) && hasDish:
+		interpretation.QueryType = QueryTypePairing
+	case containsAny(text, leftoverKeywords) && hasDish:
+		interpretation.QueryType = QueryTypeLeftover
+	case hasDish && (containsAny(text, dishKeywords) || strings.TrimSpace(text) == interpretation.DishName):
+		interpretation.QueryType = QueryTypeDish
+	case containsAny(text, questionKeywords) && !(hasIngredients && containsAny(text, cookKeywords)):
+		interpretation.QueryType = QueryTypeQuestion
+		interpretation.Question = text
+	case hasIngredients:
+		interpretation.QueryType = QueryTypeIngredients
+	case hasDish:
+		interpretation.QueryType = QueryTypeDish
+	}
+
+	interpretation.clearUnused()
+	return interpretation
+}
+
+// parseConstraints 提取耗时、人数、忌口和饮食偏好，返回去掉这些内容后的文本
+func parseConstraints(text string) (string, Constraints) {
+	var constraints Constraints
+
+	if match := minutesPattern.FindStringSubmatchIndex(text); match != nil {
+		constraints.MaxMinutes = parseChineseNumber(text[match[2]:match[3]])
+		text = maskRange(text, match[0], match[1])
+	} else if match := hoursPattern.FindStringSubmatchIndex(text); match != nil {
+		number := text[match[2]:match[3]]
+		if number == "半" {
+			constraints.MaxMinutes = 30
+		} else {
+			constraints.MaxMinutes = parseChineseNumber(number) * 60
+		}
+		text = maskRange(text, match[0], match[1])
+	}
+
+	if match := servingsPattern.FindStringSubmatchIndex(text); match != nil {
+		constraints.Servings = parseChineseNumber(text[match[2]:match[3]])
+		text = maskRange(text, match[0], match[1])
+	}
+
+	for _, pattern := range []*regexp.Regexp{avoidPattern, allergyPattern} {
+		for _, match := range pattern.FindAllStringSubmatchIndex(text, -1) {
+			constraints.Avoid = appendUnique(constraints.Avoid, text[match[2]:match[3]])
+			text = maskRange(text, match[0], match[1])
+		}
+	}
+
+	for _, keyword := range dietKeywords {
+		if strings.Contains(text, keyword) {
+			constraints.Diet = appendUnique(constraints.Diet, keyword)
+		}
+	}
+
+	return text, constraints
+}
+
+// parseAI 由AI解析查询，返回的查询类型必须在 queryTypes 中
+func (s *IntentService) parseAI(ctx context.Context, text string, queryTypes []string) (Interpretation, error) {
+	if !s.aiService.Configured() {
+		return Interpretation{}, newSourceError(SourceErrorNotConfigured, "未配置AI服务")
+	}
+
+	prompt := fmt.Sprintf(`请把用户的烹饪相关请求解析为JSON，只输出JSON，不要包含任何解释。
+
+可选的queryType：
+- ingredients：根据现有食材推荐菜品
+- dish：某道菜的做法
+- substitute：缺少某种食材，寻找替代品
+- pairing：某道菜配什么
+- leftover：剩菜改造
+- question：其他烹饪问题
+
+JSON格式：
+{"queryType": "...", "ingredients": [{"name": "食材中文名", "quantity": "数量", "unit": "单位"}], "dishName": "菜名", "question": "问题原文", "constraints": {"maxMinutes": 0, "servings": 0, "avoid": ["忌口"], "diet": ["饮食偏好"]}}
+
+用户请求：%s`, text)
+
+	response, err := s.aiService.callDeepSeekAPI(ctx, prompt)
+	if err != nil {
+		return Interpretation{}, err
+	}
+
+	var parsed Interpretation
+	if err := json.Unmarshal([]byte(extractJSON(response)), &parsed); err != nil {
+		return Interpretation{}, newSourceError(SourceErrorInvalidResponse, "解析AI意图失败: %v", err)
+	}
+	if !containsString(queryTypes, parsed.QueryType) {
+		return Interpretation{}, newSourceError(SourceErrorInvalidResponse, "AI返回未知的查询类型: %s", parsed.QueryType)
+	}
+
+	parsed.Method = IntentMethodAI
+	var ingredients []ParsedIngredient
+	for _, ingredient := range parsed.Ingredients {
+		ingredient.Name = s.ontology.Canonicalize(ingredient.Name)
+		if ingredient.Name == "" {
+			continue
+		}
+		if ingredient.Mention == "" {
+			ingredient.Mention = ingredient.Name
+		}
+		if ingredient.Quantity != "" {
+			if parsed.Constraints.Quantities == nil {
+				parsed.Constraints.Quantities = make(map[string]string)
+			}
+			parsed.Constraints.Quantities[ingredient.Name] = ingredient.Quantity + ingredient.Unit
+		}
+		ingredients = appendIngredient(ingredients, ingredient)
+	}
+	parsed.Ingredients = ingredients
+	if parsed.QueryType == QueryTypeQuestion && strings.TrimSpace(parsed.Question) == "" {
+		parsed.Question = text
+	}
+	parsed.clearUnused()
+	return parsed, nil
+}
+
+// clearUnused 清空查询类型不使用的字段，与请求校验的字段规则一致
+func (i *Interpretation) clearUnused() {
+	switch i.QueryType {
+	case QueryTypeIngredients:
+		i.DishName, i.Question = "", ""
+	case QueryTypeDish, QueryTypePairing:
+		i.Ingredients, i.Question = nil, ""
+	case QueryTypeSubstitute, QueryTypeLeftover:
+		i.Question = ""
+	case QueryTypeQuestion:
+		i.Ingredients, i.DishName = nil, ""
+	}
+}
+
+// summary 生成解析摘要，例如 按食材推荐：鸡蛋 两个、白菜 半颗；20分钟内；不要 辣
+func (i *Interpretation) summary() string {
+	labels := map[string]string{
+		QueryTypeIngredients: "按食材推荐",
+		QueryTypeDish:        "菜品做法",
+		QueryTypeSubstitute:  "食材替代",
+		QueryTypePairing:     "菜品搭配",
+		QueryTypeLeftover:    "剩菜改造",
+		QueryTypeQuestion:    "烹饪问答",
+	}
+	label := labels[i.QueryType]
+	if label == "" {
+		label = i.QueryType
+	}
+
+	var subjects []string
+	if i.DishName != "" {
+		subjects = append(subjects, i.DishName)
+	}
+	for _, ingredient := range i.Ingredients {
+		subjects = append(subjects, strings.TrimSpace(ingredient.Name+" "+ingredient.Quantity+ingredient.Unit))
+	}
+	if i.Question != "" {
+		subjects = append(subjects, i.Question)
+	}
+
+	parts := []string{label + "：" + strings.Join(subjects, "、")}
+	if i.Constraints.MaxMinutes > 0 {
+		parts = append(parts, fmt.Sprintf("%d分钟内", i.Constraints.MaxMinutes))
+	}
+	if i.Constraints.Servings > 0 {
+		parts = append(parts, fmt.Sprintf("%d人份", i.Constraints.Servings))
+	}
+	if len(i.Constraints.Avoid) > 0 {
+		parts = append(parts, "不要 "+strings.Join(i.Constraints.Avoid, "、"))
+	}
+	if len(i.Constraints.Diet) > 0 {
+		parts = append(parts, strings.Join(i.Constraints.Diet, "、"))
+	}
+	return strings.Join(parts, "；")
+}
+
+// parseChineseNumber 解析阿拉伯数字或简单的中文数字（如 两、十五、二十），半 视为0
+func parseChineseNumber(text string) int {
+	if value, err := strconv.Atoi(text); err == nil {
+		return value
+	}
+
+	digits := map[rune]int{'零': 0, '一': 1, '二': 2, '两': 2, '三': 3, '四': 4, '五': 5, '六': 6, '七': 7, '八': 8, '九': 9, '几': 3}
+	total, current := 0, 0
+	for _, r := range text {
+		switch r {
+		case '十':
+			if current == 0 {
+				current = 1
+			}
+			total += current * 10
+			current = 0
+		case '百':
+			if current == 0 {
+				current = 1
+			}
+			total += current * 100
+			current = 0
+		default:
+			current = digits[r]
+		}
+	}
+	return total + current
+}
+
+// maskRange 用空格替换文本中的一段，保持其余内容的偏移不变
+func maskRange(text string, start, end int) string {
+	return text[:start] + strings.Repeat(" ", end-start) + text[end:]
+}
+
+// lastRunes 返回文本末尾的最多n个字符
+func lastRunes(text string, n int) string {
+	runes := []rune(text)
+	if len(runes) > n {
+		runes = runes[len(runes)-n:]
+	}
+	return string(runes)
+}
+
+// extractJSON 去掉AI回复中可能包含的代码块标记，返回第一个JSON对象
+func extractJSON(text string) string {
+	start := strings.Index(text, "{")
+	end := strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return text
+	}
+	return text[start : end+1]
+}
+
+// appendIngredient 追加食材，同名食材只保留第一次出现
+func appendIngredient(ingredients []ParsedIngredient, ingredient ParsedIngredient) []ParsedIngredient {
+	for _, existing := range ingredients {
+		if existing.Name == ingredient.Name {
+			return ingredients
+		}
+	}
+	return append(ingredients, ingredient)
+}
+
+// appendUnique 追加不重复的非空字符串
+func appendUnique(items []string, item string) []string {
+	item = strings.TrimSpace(item)
+	if item == "" || containsString(items, item) {
+		return items
+	}
+	return append(items, item)
+}
+
+// containsString 列表中是否包含该字符串
+func containsString(items []string, target string) bool {
+	for _, item := range items {
+		if item == target {
+			return true
+		}
+	}
+	return false
+}
+
+// containsAny 文本中是否包含任一关键词
+func containsAny(text string, keywords []string) bool {
+	for _, keyword := range keywords {
+		if strings.Contains(text, keyword) {
+			return true
+		}
+	}
+	return false
+}
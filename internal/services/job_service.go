@@ -0,0 +1,601 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"recipe-agent/internal/config"
+	"recipe-agent/internal/logging"
+	"recipe-agent/internal/metrics"
+	"recipe-agent/internal/tracing"
+)
+
+// 任务状态
+const (
+	JobStatusQueued    = "queued"
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusFailed    = "failed"
+	JobStatusCancelled = "cancelled"
+)
+
+// 任务执行阶段
+const (
+	JobStageQueued     = "queued"
+	JobStageFetching   = "fetching"   // 并行调用AI和Spoonacular
+	JobStageLocalizing = "localizing" // 翻译食谱
+	JobStageComposing  = "composing"  // 整合结果
+	JobStageDone       = "done"
+)
+
+// 任务服务错误
+var (
+	ErrJobNotFound    = errors.New("任务不存在")
+	ErrJobQueueFull   = errors.New("任务队列已满")
+	ErrJobFinished    = errors.New("任务已结束")
+	ErrJobKindUnknown = errors.New("未知的任务类型")
+	ErrJobShutdown    = errors.New("服务正在关闭，不再接受新任务")
+)
+
+// JobErrorInternal 执行函数返回未本地化的错误、panic 或结果无法序列化时的错误码
+const JobErrorInternal = "INTERNAL_ERROR"
+
+// jobInternalErrorMessage 内部错误返回给客户端的提示，具体原因只记录在日志中
+const jobInternalErrorMessage = "服务处理失败，请稍后重试"
+
+// JobError 任务失败原因
+type JobError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error 实现error接口
+func (e *JobError) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+// Job 异步任务
+type Job struct {
+	ID      string          `json:"id"`
+	Kind    string          `json:"kind"`
+	Status  string          `json:"status" enum:"queued,running,succeeded,failed,cancelled"`
+	Stage   string          `json:"stage" enum:"queued,fetching,localizing,composing,done"`
+	Request json.RawMessage `json:"request"`
+	// CallbackURL 任务结束后接收回调的地址
+	CallbackURL string `json:"callbackUrl,omitempty"`
+	// RequestID 提交任务的请求ID，任务执行时的日志和上游请求沿用该ID
+	RequestID  string          `json:"requestId,omitempty"`
+	Result     json.RawMessage `json:"result,omitempty"`
+	Error      *JobError       `json:"error,omitempty"`
+	CreatedAt  time.Time       `json:"createdAt"`
+	StartedAt  *time.Time      `json:"startedAt,omitempty"`
+	FinishedAt *time.Time      `json:"finishedAt,omitempty"`
+	ExpiresAt  *time.Time      `json:"expiresAt,omitempty"`
+}
+
+// finished 任务是否已结束
+func (j *Job) finished() bool {
+	return j.Status == JobStatusSucceeded || j.Status == JobStatusFailed || j.Status == JobStatusCancelled
+}
+
+// JobExecutor 执行一类任务，返回值序列化后作为任务结果
+type JobExecutor func(ctx context.Context, request json.RawMessage) (interface{}, error)
+
+// JobService 基于有界工作池的异步任务服务，任务持久化到文件，重启后继续执行未完成的任务
+type JobService struct {
+	path      string
+	workers   int
+	resultTTL time.Duration
+	// flushInterval 任务文件的写入间隔
+	flushInterval time.Duration
+	queue         chan string
+	jobs          map[string]*Job
+	cancels       map[string]context.CancelFunc
+	executors     map[string]JobExecutor
+	listeners     []func(Job)
+	mutex         sync.Mutex
+	// dirty 有尚未写入任务文件的状态变化
+	dirty bool
+	// writeMutex 保证按顺序写入任务文件，写文件时不持有 mutex
+	writeMutex sync.Mutex
+	started    bool
+	// stopping 已开始关闭，不再接受和执行新任务
+	stopping bool
+	// interrupted 关闭超时，执行中的任务被中断
+	interrupted bool
+	stop        chan struct{}
+	workerGroup sync.WaitGroup
+	// listenerGroup 尚未返回的任务结束回调
+	listenerGroup sync.WaitGroup
+}
+
+// progressKey 上下文中保存进度回调的键
+type progressKey struct{}
+
+// WithProgress 返回携带进度回调的上下文
+func WithProgress(ctx context.Context, report func(stage string)) context.Context {
+	return context.WithValue(ctx, progressKey{}, report)
+}
+
+// ReportProgress 报告当前执行阶段，上下文中没有进度回调时忽略
+func ReportProgress(ctx context.Context, stage string) {
+	if report, ok := ctx.Value(progressKey{}).(func(string)); ok {
+		report(stage)
+	}
+}
+
+// NewJobService 创建任务服务实例并加载持久化的任务，加载失败时仅记录日志
+func NewJobService(cfg config.Jobs) *JobService {
+	s := &JobService{
+		path:          cfg.StoreFile,
+		workers:       cfg.Workers,
+		resultTTL:     cfg.ResultTTL.Duration,
+		flushInterval: cfg.FlushInterval.Duration,
+		queue:         make(chan string, cfg.QueueSize),
+		jobs:          make(map[string]*Job),
+		cancels:       make(map[string]context.CancelFunc),
+		executors:     make(map[string]JobExecutor),
+		stop:          make(chan struct{}),
+	}
+
+	if s.flushInterval <= 0 {
+		s.flushInterval = time.Second
+	}
+	if err := s.load(); err != nil {
+		slog.Error("加载任务文件失败", "error", err)
+	}
+	return s
+}
+
+// Register 注册任务类型的执行函数，需在Start之前调用
+func (s *JobService) Register(kind string, executor JobExecutor) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.executors[kind] = executor
+}
+
+// OnFinish 注册任务结束（成功、失败或取消）时的回调，回调在独立的goroutine中执行
+func (s *JobService) OnFinish(listener func(Job)) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.listeners = append(s.listeners, listener)
+}
+
+// Start 启动工作池和过期任务清理，并重新排队上次未完成的任务
+func (s *JobService) Start() {
+	s.mutex.Lock()
+	if s.started {
+		s.mutex.Unlock()
+		return
+	}
+	s.started = true
+
+	// 重启前正在执行的任务重新排队
+	var pending []*Job
+	for _, job := range s.jobs {
+		if !job.finished() {
+			job.Status = JobStatusQueued
+			job.Stage = JobStageQueued
+			job.StartedAt = nil
+			pending = append(pending, job)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].CreatedAt.Before(pending[j].CreatedAt)
+	})
+	s.mutex.Unlock()
+
+	s.workerGroup.Add(s.workers)
+	for i := 0; i < s.workers; i++ {
+		go s.worker()
+	}
+	go s.cleanup()
+
+	for _, job := range pending {
+		s.queue <- job.ID
+	}
+	if len(pending) > 0 {
+		slog.Info("已恢复未完成的任务", "jobs", len(pending))
+	}
+}
+
+// Submit 提交任务，callbackURL非空时任务结束后发送回调；队列已满时返回 ErrJobQueueFull
+func (s *JobService) Submit(ctx context.Context, kind string, request interface{}, callbackURL string) (*Job, error) {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("序列化任务请求失败: %v", err)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.stopping {
+		return nil, ErrJobShutdown
+	}
+	if _, exists := s.executors[kind]; !exists {
+		return nil, ErrJobKindUnknown
+	}
+
+	job := &Job{
+		ID:          newJobID(),
+		Kind:        kind,
+		Status:      JobStatusQueued,
+		Stage:       JobStageQueued,
+		Request:     data,
+		CallbackURL: callbackURL,
+		RequestID:   logging.RequestID(ctx),
+		CreatedAt:   time.Now(),
+	}
+
+	select {
+	case s.queue <- job.ID:
+	default:
+		return nil, ErrJobQueueFull
+	}
+
+	s.jobs[job.ID] = job
+	s.dirty = true
+	copied := *job
+	return &copied, nil
+}
+
+// Get 获取任务
+func (s *JobService) Get(id string) (*Job, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	job, exists := s.jobs[id]
+	if !exists {
+		return nil, ErrJobNotFound
+	}
+	copied := *job
+	return &copied, nil
+}
+
+// Cancel 取消排队中或执行中的任务
+func (s *JobService) Cancel(id string) (*Job, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	job, exists := s.jobs[id]
+	if !exists {
+		return nil, ErrJobNotFound
+	}
+	if job.finished() {
+		return nil, ErrJobFinished
+	}
+
+	if cancel, running := s.cancels[id]; running {
+		cancel()
+	}
+	s.finish(job, nil, &JobError{Code: "CANCELLED", Message: "任务已取消"}, JobStatusCancelled)
+
+	copied := *job
+	return &copied, nil
+}
+
+// PoolStatus 返回工作池的worker数、执行中和排队中的任务数
+func (s *JobService) PoolStatus() metrics.PoolStatus {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return metrics.PoolStatus{
+		Workers:       s.workers,
+		Busy:          len(s.cancels),
+		Queued:        len(s.queue),
+		QueueCapacity: cap(s.queue),
+	}
+}
+
+// Shutdown 停止接受新任务并等待执行中的任务完成，ctx到期时中断仍在执行的任务；
+// 被中断和仍在排队的任务保留在任务文件中，下次启动时重新执行
+func (s *JobService) Shutdown(ctx context.Context) error {
+	s.mutex.Lock()
+	if s.stopping {
+		s.mutex.Unlock()
+		return nil
+	}
+	s.stopping = true
+	close(s.stop)
+	s.mutex.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.workerGroup.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		s.mutex.Lock()
+		// 没有执行中的任务时空闲的worker会立即退出，不算超时
+		if len(s.cancels) > 0 {
+			err = ctx.Err()
+		}
+		s.interrupted = true
+		for _, cancel := range s.cancels {
+			cancel()
+		}
+		s.mutex.Unlock()
+		<-done
+	}
+	// 等待任务结束回调（如登记webhook）返回
+	s.listenerGroup.Wait()
+
+	s.mutex.Lock()
+	s.dirty = true
+	s.mutex.Unlock()
+	if flushErr := s.flush(); flushErr != nil {
+		return flushErr
+	}
+	return err
+}
+
+// worker 从队列中取出任务并执行，服务关闭时退出
+func (s *JobService) worker() {
+	defer s.workerGroup.Done()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case id := <-s.queue:
+			s.run(id)
+		}
+	}
+}
+
+// run 执行单个任务
+func (s *JobService) run(id string) {
+	s.mutex.Lock()
+	job, exists := s.jobs[id]
+	if !exists || job.Status != JobStatusQueued || s.stopping {
+		// 任务已被取消或已过期；服务关闭时任务留在队列中，下次启动时执行
+		s.mutex.Unlock()
+		return
+	}
+	executor, registered := s.executors[job.Kind]
+	if !registered {
+		s.finish(job, nil, &JobError{Code: JobErrorInternal, Message: ErrJobKindUnknown.Error()}, JobStatusFailed)
+		s.mutex.Unlock()
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s.cancels[id] = cancel
+
+	now := time.Now()
+	job.Status = JobStatusRunning
+	job.StartedAt = &now
+	request := job.Request
+	kind, requestID := job.Kind, job.RequestID
+	s.dirty = true
+	s.mutex.Unlock()
+
+	ctx = logging.WithRequestID(ctx, requestID)
+	ctx = WithProgress(ctx, func(stage string) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		if job.Status == JobStatusRunning {
+			job.Stage = stage
+		}
+	})
+
+	// 任务在后台执行，每次执行是一条独立的链路
+	ctx, span := tracing.Start(ctx, "job."+kind, attribute.String("job.id", id))
+	result, err := s.execute(ctx, executor, request)
+	tracing.End(span, err)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.cancels, id)
+	if s.interrupted && job.Status == JobStatusRunning {
+		// 因服务关闭被中断，重新排队等待下次启动
+		job.Status = JobStatusQueued
+		job.Stage = JobStageQueued
+		job.StartedAt = nil
+		slog.WarnContext(ctx, "任务因服务关闭被中断，将在下次启动时重新执行", "job_id", id)
+		return
+	}
+	if job.Status != JobStatusRunning {
+		// 执行期间已被取消
+		return
+	}
+
+	if err != nil {
+		// 执行函数应返回已本地化的 JobError；其他错误可能包含内部细节，只记录日志，不返回给客户端
+		var jobErr *JobError
+		if errors.As(err, &jobErr) {
+			slog.WarnContext(ctx, "任务执行失败", "job_id", id, "code", jobErr.Code)
+		} else {
+			jobErr = &JobError{Code: JobErrorInternal, Message: jobInternalErrorMessage}
+			slog.ErrorContext(ctx, "任务执行失败", "job_id", id, "error", err)
+		}
+		s.finish(job, nil, jobErr, JobStatusFailed)
+		return
+	}
+	s.finish(job, result, nil, JobStatusSucceeded)
+}
+
+// execute 调用执行函数并序列化结果，执行函数panic时视为失败
+func (s *JobService) execute(ctx context.Context, executor JobExecutor, request json.RawMessage) (result json.RawMessage, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("任务执行异常: %v", recovered)
+		}
+	}()
+
+	value, err := executor(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(value)
+}
+
+// finish 将任务标记为结束并设置过期时间，调用方需持有锁
+func (s *JobService) finish(job *Job, result json.RawMessage, jobErr *JobError, status string) {
+	now := time.Now()
+	expiresAt := now.Add(s.resultTTL)
+	job.Status = status
+	job.Stage = JobStageDone
+	job.Result = result
+	job.Error = jobErr
+	job.FinishedAt = &now
+	job.ExpiresAt = &expiresAt
+	s.dirty = true
+
+	for _, listener := range s.listeners {
+		s.listenerGroup.Add(1)
+		go func(listener func(Job), job Job) {
+			defer s.listenerGroup.Done()
+			listener(job)
+		}(listener, *job)
+	}
+}
+
+// cleanup 定期写入任务文件并删除已过期的任务结果
+func (s *JobService) cleanup() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	flushTicker := time.NewTicker(s.flushInterval)
+	defer flushTicker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-flushTicker.C:
+			if err := s.flush(); err != nil {
+				slog.Error("保存任务文件失败", "error", err)
+			}
+		case <-ticker.C:
+			s.removeExpired()
+		}
+	}
+}
+
+// removeExpired 删除已过期的任务
+func (s *JobService) removeExpired() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	removed := 0
+	for id, job := range s.jobs {
+		if job.ExpiresAt != nil && now.After(*job.ExpiresAt) {
+			delete(s.jobs, id)
+			removed++
+		}
+	}
+	if removed > 0 {
+		s.dirty = true
+	}
+}
+
+// load 加载持久化的任务，已过期的任务直接丢弃
+func (s *JobService) load() error {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("读取任务文件失败: %v", err)
+	}
+
+	var jobs []*Job
+	if err := json.Unmarshal(data, &jobs); err != nil {
+		return fmt.Errorf("解析任务文件失败: %v", err)
+	}
+
+	now := time.Now()
+	for _, job := range jobs {
+		if job.ExpiresAt != nil && now.After(*job.ExpiresAt) {
+			continue
+		}
+		s.jobs[job.ID] = job
+	}
+	return nil
+}
+
+// flush 有未写入的状态变化时将任务写入文件。持有锁时只序列化任务，写文件时不阻塞任务的提交和执行
+func (s *JobService) flush() error {
+	s.writeMutex.Lock()
+	defer s.writeMutex.Unlock()
+
+	s.mutex.Lock()
+	if !s.dirty {
+		s.mutex.Unlock()
+		return nil
+	}
+	data, err := s.marshalLocked()
+	s.dirty = false
+	s.mutex.Unlock()
+
+	if err == nil {
+		err = s.write(data)
+	}
+	if err != nil {
+		s.mutex.Lock()
+		s.dirty = true
+		s.mutex.Unlock()
+	}
+	return err
+}
+
+// marshalLocked 序列化所有任务，调用方需持有锁
+func (s *JobService) marshalLocked() ([]byte, error) {
+	jobs := make([]*Job, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, job)
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].CreatedAt.Before(jobs[j].CreatedAt)
+	})
+
+	data, err := json.MarshalIndent(jobs, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("序列化任务失败: %v", err)
+	}
+	return data, nil
+}
+
+// write 将序列化后的任务写入文件
+func (s *JobService) write(data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("创建任务目录失败: %v", err)
+	}
+
+	// 先写临时文件再重命名，避免写入中断导致文件损坏
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o644); err != nil {
+		return fmt.Errorf("写入任务文件失败: %v", err)
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return fmt.Errorf("写入任务文件失败: %v", err)
+	}
+	return nil
+}
+
+// newJobID 生成随机任务ID
+func newJobID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"recipe-agent/internal/config"
+)
+
+// newTestJobService 创建使用临时任务文件的任务服务并注册执行函数
+func newTestJobService(t *testing.T, path string, flushInterval time.Duration, executor JobExecutor) *JobService {
+	t.Helper()
+	s := NewJobService(config.Jobs{
+		StoreFile:     path,
+		Workers:       2,
+		QueueSize:     10,
+		ResultTTL:     config.Duration{Duration: time.Hour},
+		FlushInterval: config.Duration{Duration: flushInterval},
+	})
+	s.Register("test", executor)
+	s.Start()
+	t.Cleanup(func() { s.Shutdown(context.Background()) })
+	return s
+}
+
+// waitJob 等待任务结束
+func waitJob(t *testing.T, s *JobService, id string) *Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := s.Get(id)
+		if err != nil {
+			t.Fatalf("Get(%q) error: %v", id, err)
+		}
+		if job.finished() {
+			return job
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("job %s not finished", id)
+	return nil
+}
+
+func TestJobErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		executor JobExecutor
+		code     string
+		message  string
+	}{
+		{
+			name: "job error kept",
+			executor: func(context.Context, json.RawMessage) (interface{}, error) {
+				return nil, &JobError{Code: "UPSTREAM_UNAVAILABLE", Message: "upstream unavailable"}
+			},
+			code:    "UPSTREAM_UNAVAILABLE",
+			message: "upstream unavailable",
+		},
+		{
+			name: "raw error hidden",
+			executor: func(context.Context, json.RawMessage) (interface{}, error) {
+				return nil, errors.New("dial tcp 10.0.0.3:5432: connection refused")
+			},
+			code:    JobErrorInternal,
+			message: jobInternalErrorMessage,
+		},
+		{
+			name: "panic hidden",
+			executor: func(context.Context, json.RawMessage) (interface{}, error) {
+				panic("nil map at secret.go:42")
+			},
+			code:    JobErrorInternal,
+			message: jobInternalErrorMessage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestJobService(t, filepath.Join(t.TempDir(), "jobs.json"), time.Hour, tt.executor)
+			job, err := s.Submit(context.Background(), "test", map[string]string{}, "")
+			if err != nil {
+				t.Fatalf("Submit error: %v", err)
+			}
+
+			job = waitJob(t, s, job.ID)
+			if job.Status != JobStatusFailed || job.Error == nil {
+				t.Fatalf("job = %+v, want failed with error", job)
+			}
+			if job.Error.Code != tt.code || job.Error.Message != tt.message {
+				t.Errorf("job error = %+v, want %s %q", job.Error, tt.code, tt.message)
+			}
+		})
+	}
+}
+
+func TestJobWritesAreBatched(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.json")
+	release := make(chan struct{})
+	s := newTestJobService(t, path, time.Hour, func(ctx context.Context, _ json.RawMessage) (interface{}, error) {
+		<-release
+		return map[string]string{"ok": "yes"}, nil
+	})
+
+	job, err := s.Submit(context.Background(), "test", map[string]string{}, "")
+	if err != nil {
+		t.Fatalf("Submit error: %v", err)
+	}
+	close(release)
+	waitJob(t, s, job.ID)
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("job file written before the flush interval, stat error = %v", err)
+	}
+
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("job file not written on shutdown: %v", err)
+	}
+	if !strings.Contains(string(data), job.ID) || !strings.Contains(string(data), JobStatusSucceeded) {
+		t.Errorf("job file = %s, want succeeded job %s", data, job.ID)
+	}
+}
+
+func TestJobFlushesOnTicker(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.json")
+	s := newTestJobService(t, path, 10*time.Millisecond, func(context.Context, json.RawMessage) (interface{}, error) {
+		return "done", nil
+	})
+
+	job, err := s.Submit(context.Background(), "test", map[string]string{}, "")
+	if err != nil {
+		t.Fatalf("Submit error: %v", err)
+	}
+	waitJob(t, s, job.ID)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if data, err := os.ReadFile(path); err == nil && strings.Contains(string(data), JobStatusSucceeded) {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("job file not written by the background flush")
+}
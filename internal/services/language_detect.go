@@ -0,0 +1,124 @@
+package services
+
+import (
+	"strings"
+	"unicode"
+)
+
+// 检测到的输入语言
+const (
+	LanguageChinese  = "zh"
+	LanguageJapanese = "ja"
+	LanguageKorean   = "ko"
+	LanguageEnglish  = "en"
+	LanguageUnknown  = "und"
+)
+
+// 文字系统
+const (
+	ScriptHan      = "han"
+	ScriptKana     = "kana"
+	ScriptHangul   = "hangul"
+	ScriptLatin    = "latin"
+	ScriptCJKPunct = "cjk_punct"
+)
+
+// languageNames 提示词中使用的源语言名称
+var languageNames = map[string]string{
+	LanguageChinese:  "中文",
+	LanguageJapanese: "日语",
+	LanguageKorean:   "韩语",
+	LanguageEnglish:  "英文",
+}
+
+// japaneseOnlyHan 日文中常见而中文几乎不用的汉字和符号（纯汉字输入时用于区分日文）
+const japaneseOnlyHan = "々〆鶏込丼畑辻峠枠働揚"
+
+// LanguageDetection 语言检测结果
+type LanguageDetection struct {
+	Language string         `json:"language"`
+	Mixed    bool           `json:"mixed"`
+	Scripts  map[string]int `json:"scripts"`
+}
+
+// DetectLanguage 基于文字系统分析和启发式规则检测文本语言
+func DetectLanguage(text string) LanguageDetection {
+	scripts := make(map[string]int)
+	japaneseHints := 0
+
+	for _, r := range foldFullWidth(text) {
+		switch {
+		case unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r) || r == 'ー':
+			scripts[ScriptKana]++
+		case unicode.Is(unicode.Hangul, r):
+			scripts[ScriptHangul]++
+		case unicode.Is(unicode.Han, r):
+			scripts[ScriptHan]++
+			if strings.ContainsRune(japaneseOnlyHan, r) {
+				japaneseHints++
+			}
+		case unicode.Is(unicode.Latin, r):
+			scripts[ScriptLatin]++
+		case isCJKPunct(r):
+			scripts[ScriptCJKPunct]++
+		}
+	}
+
+	detection := LanguageDetection{Scripts: scripts}
+	cjkScripts := 0
+	for _, script := range []string{ScriptHan, ScriptKana, ScriptHangul} {
+		if scripts[script] > 0 {
+			cjkScripts++
+		}
+	}
+	detection.Mixed = scripts[ScriptLatin] > 0 && cjkScripts > 0
+
+	switch {
+	case scripts[ScriptKana] > 0:
+		// 假名是日文最可靠的特征，日文中的汉字不影响判断
+		detection.Language = LanguageJapanese
+	case scripts[ScriptHangul] > 0:
+		detection.Language = LanguageKorean
+	case scripts[ScriptHan] > 0 && japaneseHints > 0:
+		detection.Language = LanguageJapanese
+	case scripts[ScriptHan] > 0:
+		detection.Language = LanguageChinese
+	case scripts[ScriptLatin] > 0:
+		detection.Language = LanguageEnglish
+	default:
+		detection.Language = LanguageUnknown
+	}
+
+	return detection
+}
+
+// NeedsTranslation 是否需要翻译为英文后才能用于Spoonacular查询
+func (d LanguageDetection) NeedsTranslation() bool {
+	return d.Language == LanguageChinese || d.Language == LanguageJapanese || d.Language == LanguageKorean
+}
+
+// LanguageName 返回源语言在提示词中的名称
+func (d LanguageDetection) LanguageName() string {
+	if name, exists := languageNames[d.Language]; exists {
+		return name
+	}
+	return "中文"
+}
+
+// foldFullWidth 将全角ASCII字符和全角空格转换为半角
+func foldFullWidth(text string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 0xFF01 && r <= 0xFF5E:
+			return r - 0xFEE0
+		case r == 0x3000:
+			return ' '
+		}
+		return r
+	}, text)
+}
+
+// isCJKPunct 判断是否为CJK标点符号
+func isCJKPunct(r rune) bool {
+	return (r >= 0x3000 && r <= 0x303F) || (r >= 0xFF00 && r <= 0xFFEF) || (r >= 0xFE30 && r <= 0xFE4F)
+}
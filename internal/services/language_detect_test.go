@@ -0,0 +1,95 @@
+package services
+
+import "testing"
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		language  string
+		mixed     bool
+		translate bool
+	}{
+		{"han", "西红柿炒鸡蛋", LanguageChinese, false, true},
+		{"cjk extension a", "㐀㐁", LanguageChinese, false, true},
+		{"japanese-only han", "親子丼", LanguageJapanese, false, true},
+		{"hiragana", "たまご", LanguageJapanese, false, true},
+		{"katakana with han", "トマトと卵", LanguageJapanese, false, true},
+		{"prolonged sound mark", "カレーライス", LanguageJapanese, false, true},
+		{"hangul", "김치찌개", LanguageKorean, false, true},
+		{"latin", "chicken breast", LanguageEnglish, false, false},
+		{"full-width latin", "ｃｈｉｃｋｅｎ", LanguageEnglish, false, false},
+		{"mixed latin and han", "chicken胸肉", LanguageChinese, true, true},
+		{"mixed latin and kana", "beefカレー", LanguageJapanese, true, true},
+		{"full-width punctuation only", "！？，。、「」　", LanguageUnknown, false, false},
+		{"digits only", "１２３ 456", LanguageUnknown, false, false},
+		{"empty", "", LanguageUnknown, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detection := DetectLanguage(tt.text)
+			if detection.Language != tt.language {
+				t.Errorf("DetectLanguage(%q).Language = %q, want %q (scripts %v)", tt.text, detection.Language, tt.language, detection.Scripts)
+			}
+			if detection.Mixed != tt.mixed {
+				t.Errorf("DetectLanguage(%q).Mixed = %v, want %v", tt.text, detection.Mixed, tt.mixed)
+			}
+			if got := detection.NeedsTranslation(); got != tt.translate {
+				t.Errorf("DetectLanguage(%q).NeedsTranslation() = %v, want %v", tt.text, got, tt.translate)
+			}
+		})
+	}
+}
+
+func TestDetectLanguageScripts(t *testing.T) {
+	detection := DetectLanguage("鸡蛋，ｅｇｇ。")
+	want := map[string]int{ScriptHan: 2, ScriptLatin: 3, ScriptCJKPunct: 1}
+	for script, count := range want {
+		if detection.Scripts[script] != count {
+			t.Errorf("Scripts[%s] = %d, want %d (scripts %v)", script, detection.Scripts[script], count, detection.Scripts)
+		}
+	}
+}
+
+func TestLanguageName(t *testing.T) {
+	tests := map[string]string{
+		LanguageChinese:  "中文",
+		LanguageJapanese: "日语",
+		LanguageKorean:   "韩语",
+		LanguageEnglish:  "英文",
+		LanguageUnknown:  "中文",
+	}
+	for language, want := range tests {
+		if got := (LanguageDetection{Language: language}).LanguageName(); got != want {
+			t.Errorf("LanguageName(%s) = %q, want %q", language, got, want)
+		}
+	}
+}
+
+func TestTranslationRoutesByLanguage(t *testing.T) {
+	s, calls := newTestTranslationService(t, func(string) string { return "chicken breast" })
+
+	// 英文输入直接返回，不调用AI
+	for _, ingredient := range []string{"rambutan", "ｒａｍｂｕｔａｎ"} {
+		translation := s.TranslateIngredientDetailed(t.Context(), ingredient)
+		if translation.Origin != TranslationOriginPassthrough || translation.Text != "rambutan" {
+			t.Errorf("TranslateIngredientDetailed(%q) = %+v, want passthrough rambutan", ingredient, translation)
+		}
+	}
+	if dish := s.TranslateDishNameDetailed(t.Context(), "Beef Wellington"); dish.Origin != TranslationOriginPassthrough {
+		t.Errorf("TranslateDishNameDetailed(Beef Wellington) origin = %s, want passthrough", dish.Origin)
+	}
+	if got := calls.Load(); got != 0 {
+		t.Fatalf("AI calls for English input = %d, want 0", got)
+	}
+
+	// 中英混合输入需要翻译，走AI路径
+	translation := s.TranslateIngredientDetailed(t.Context(), "chicken胸肉")
+	if got := calls.Load(); got == 0 {
+		t.Fatal("mixed input did not reach the AI")
+	}
+	if translation.Origin != TranslationOriginAI || translation.Text != "chicken breast" {
+		t.Errorf("TranslateIngredientDetailed(chicken胸肉) = %+v, want AI chicken breast", translation)
+	}
+}
@@ -0,0 +1,196 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"recipe-agent/internal/tracing"
+)
+
+// 内置查询类型
+const (
+	QueryTypeIngredients = "ingredients" // 按食材推荐菜品
+	QueryTypeDish        = "dish"        // 菜品制作方法
+	QueryTypeSubstitute  = "substitute"  // 食材替代
+	QueryTypePairing     = "pairing"     // 菜品搭配
+	QueryTypeLeftover    = "leftover"    // 剩菜改造
+	QueryTypeQuestion    = "question"    // 烹饪问答
+)
+
+// ErrNoUsableSource 所有数据源都没有可用的结果
+var ErrNoUsableSource = errors.New("没有可用的数据源结果")
+
+// PipelineQuery 数据源的查询条件
+type PipelineQuery struct {
+	Type        string
+	Ingredients []string
+	DishName    string
+	Question    string
+	Locale      string
+	// Constraints 附加要求（耗时、忌口等），可为空
+	Constraints *Constraints
+}
+
+// SourceOutput 数据源返回的内容
+type SourceOutput struct {
+	// Content 文本内容（如AI分析），Recipes 参考食谱
+	Content string
+	Recipes []SpoonacularRecipe
+	// Search 食谱搜索的翻译和缓存详情
+	Search *RecipeSearch
+	// Skipped 未发起查询的原因（数据源错误码），此时输出为空
+	Skipped string
+	// Notice 输出可用但已降级的原因（如未配置密钥时返回内置内容）
+	Notice string
+}
+
+// Source 聚合管道中的数据源
+type Source struct {
+	Name string
+	// Priority 越大越优先，合并时优先使用高优先级数据源的内容
+	Priority int
+	// Timeout 单个数据源的超时时间，0表示不限制
+	Timeout time.Duration
+	Fetch   func(ctx context.Context, query PipelineQuery) (SourceOutput, error)
+}
+
+// SourceResult 单个数据源的执行结果
+type SourceResult struct {
+	Source   string
+	Priority int
+	Output   SourceOutput
+	Err      error
+	Latency  time.Duration
+}
+
+// Usable 数据源是否成功返回了可用于合并的结果
+func (r SourceResult) Usable() bool {
+	return r.Err == nil && r.Output.Skipped == ""
+}
+
+// ErrorCode 数据源不可用或降级的原因
+func (r SourceResult) ErrorCode() string {
+	if r.Err != nil {
+		return SourceErrorCode(r.Err)
+	}
+	if r.Output.Skipped != "" {
+		return r.Output.Skipped
+	}
+	return r.Output.Notice
+}
+
+// Combiner 合并各数据源结果的策略，没有可用结果时返回 ErrNoUsableSource
+type Combiner interface {
+	Combine(query PipelineQuery, results []SourceResult) (*PipelineResult, error)
+}
+
+// PipelineResult 聚合结果
+type PipelineResult struct {
+	Content string
+	Recipes []SpoonacularRecipe
+	// Contributors 实际参与生成结果的数据源
+	Contributors []string
+	// Results 各数据源的执行结果，按优先级从高到低排序
+	Results []SourceResult
+}
+
+// Pipeline 多数据源聚合管道：并行调用所有数据源，按到达顺序收集结果，再由合并策略生成最终结果
+type Pipeline struct {
+	sources  []Source
+	combiner Combiner
+	mutex    sync.RWMutex
+}
+
+// NewPipeline 创建聚合管道
+func NewPipeline(combiner Combiner, sources ...Source) *Pipeline {
+	p := &Pipeline{combiner: combiner}
+	for _, source := range sources {
+		p.Register(source)
+	}
+	return p
+}
+
+// Register 注册数据源
+func (p *Pipeline) Register(source Source) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.sources = append(p.sources, source)
+	sort.SliceStable(p.sources, func(i, j int) bool {
+		return p.sources[i].Priority > p.sources[j].Priority
+	})
+}
+
+// Run 执行查询，返回合并结果；没有可用结果时同时返回各数据源的执行结果和错误
+func (p *Pipeline) Run(ctx context.Context, query PipelineQuery) (_ *PipelineResult, err error) {
+	ctx, span := tracing.Start(ctx, "pipeline.run", attribute.String("query.type", query.Type))
+	defer func() { tracing.End(span, err) }()
+
+	p.mutex.RLock()
+	sources := append([]Source{}, p.sources...)
+	p.mutex.RUnlock()
+
+	ReportProgress(ctx, JobStageFetching)
+
+	resultChan := make(chan SourceResult, len(sources))
+	for _, source := range sources {
+		go func(source Source) {
+			resultChan <- p.fetch(ctx, source, query)
+		}(source)
+	}
+
+	// 按到达顺序收集结果
+	results := make([]SourceResult, 0, len(sources))
+	for range sources {
+		results = append(results, <-resultChan)
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Priority > results[j].Priority
+	})
+
+	ReportProgress(ctx, JobStageComposing)
+	combined, err := p.combiner.Combine(query, results)
+	if err != nil {
+		return &PipelineResult{Results: results}, err
+	}
+	combined.Results = results
+	return combined, nil
+}
+
+// fetch 在数据源自己的超时时间内调用数据源，panic时视为失败
+func (p *Pipeline) fetch(ctx context.Context, source Source, query PipelineQuery) (result SourceResult) {
+	start := time.Now()
+	result = SourceResult{Source: source.Name, Priority: source.Priority}
+	ctx, span := tracing.Start(ctx, "source."+source.Name, attribute.Int("source.priority", source.Priority))
+
+	if source.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, source.Timeout)
+		defer cancel()
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			result.Err = newSourceError(SourceErrorUnknown, "数据源 %s 异常: %v", source.Name, recovered)
+		}
+		result.Latency = time.Since(start)
+		if code := result.ErrorCode(); code != "" {
+			span.SetAttributes(attribute.String("source.error_code", code))
+		}
+		tracing.End(span, result.Err)
+	}()
+
+	result.Output, result.Err = source.Fetch(ctx, query)
+	if result.Err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		result.Err = newSourceError(SourceErrorTimeout, "数据源 %s 超时(%s): %v", source.Name, source.Timeout, result.Err)
+	}
+	return result
+}
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PromptTemplate 查询类型的AI提示词模板
+type PromptTemplate struct {
+	// Build 根据查询生成提示词
+	Build func(query PipelineQuery) string
+	// Default 未配置API密钥时返回的内置内容
+	Default func(query PipelineQuery) string
+}
+
+// IngredientsPrompt 按食材推荐菜品
+var IngredientsPrompt = PromptTemplate{
+	Build:   func(query PipelineQuery) string { return buildIngredientsPrompt(query.Ingredients) },
+	Default: func(query PipelineQuery) string { return generateDefaultRecipe(query.Ingredients) },
+}
+
+// DishPrompt 菜品详细制作方法
+var DishPrompt = PromptTemplate{
+	Build:   func(query PipelineQuery) string { return buildDishPrompt(query.DishName) },
+	Default: func(query PipelineQuery) string { return generateDefaultDishDetails(query.DishName) },
+}
+
+// SubstitutePrompt 食材替代建议，DishName 为可选的使用场景
+var SubstitutePrompt = PromptTemplate{
+	Build: func(query PipelineQuery) string {
+		scene := ""
+		if query.DishName != "" {
+			scene = fmt.Sprintf("\n用户准备制作的菜品：%s（请优先考虑在这道菜中的效果）\n", query.DishName)
+		}
+		return fmt.Sprintf(`你是一位专业的厨师。用户缺少以下食材，需要找到可行的替代品。
+
+需要替代的食材：%s
+%s
+请按照以下结构回答：
+
+## 🔄 替代方案
+对每种需要替代的食材，列出2-4个替代品：
+### [原食材]
+1. **[替代品]**：[用量换算] + [口味和口感的差异]
+2. **[替代品]**：[用量换算] + [口味和口感的差异]
+
+## ⚠️ 注意事项
+- [替代后需要调整的火候、时间或调味]
+- [不适合替代的情况]
+
+请确保替代品是家庭厨房容易获得的，用中文回复，语气亲切专业。`, strings.Join(query.Ingredients, "、"), scene)
+	},
+	Default: func(query PipelineQuery) string {
+		return fmt.Sprintf(`# 食材替代建议
+
+## 需要替代的食材
+%s
+
+## 通用替代思路
+1. **同类替代**：选择同一类别、口感相近的食材，如不同品种的绿叶菜、不同部位的肉类
+2. **风味替代**：缺少调味料时，选择风味相近的调料，注意减少用量后逐步调整
+3. **质地替代**：关注食材在菜品中的作用（增稠、提鲜、增加口感），选择作用相同的食材
+
+## 注意事项
+- 替代后先少量尝试，再调整用量
+- 注意替代食材的烹饪时间可能不同
+
+*注：当前显示通用建议，如需针对性的替代方案，请配置AI服务。*`, strings.Join(query.Ingredients, "、"))
+	},
+}
+
+// PairingPrompt 菜品搭配建议
+var PairingPrompt = PromptTemplate{
+	Build: func(query PipelineQuery) string {
+		return fmt.Sprintf(`你是一位经验丰富的厨师和营养师。请为"%s"推荐合适的搭配，组成一餐完整、均衡的饭菜。
+
+请按照以下结构回答：
+
+## 🍚 主食搭配
+- **[主食]**：[推荐理由]
+
+## 🥗 配菜推荐
+1. **[配菜名称]**：[口味互补或营养互补的理由] + [简要做法]
+2. **[配菜名称]**：[口味互补或营养互补的理由] + [简要做法]
+
+## 🍲 汤品推荐
+- **[汤品]**：[推荐理由]
+
+## 🍵 饮品搭配
+- **[饮品]**：[推荐理由]
+
+## 📊 营养均衡
+- [整餐的营养搭配说明]
+
+请推荐真实可行的家常菜品，用中文回复，语气亲切专业。`, query.DishName)
+	},
+	Default: func(query PipelineQuery) string {
+		return fmt.Sprintf(`# %s 搭配建议
+
+## 搭配原则
+1. **口味互补**：重口味的菜品搭配清淡的配菜和汤品
+2. **荤素搭配**：肉类菜品搭配绿叶蔬菜或凉拌菜
+3. **营养均衡**：保证主食、蛋白质和蔬菜的比例
+
+## 推荐组合
+- **主食**：米饭或面食
+- **配菜**：清炒时蔬、凉拌黄瓜
+- **汤品**：紫菜蛋花汤、青菜豆腐汤
+
+*注：当前显示通用搭配建议，如需针对性的推荐，请配置AI服务。*`, query.DishName)
+	},
+}
+
+// LeftoverPrompt 剩菜改造建议，Ingredients 为可选的现有食材
+var LeftoverPrompt = PromptTemplate{
+	Build: func(query PipelineQuery) string {
+		extra := ""
+		if len(query.Ingredients) > 0 {
+			extra = fmt.Sprintf("\n家里还有的食材：%s\n", strings.Join(query.Ingredients, "、"))
+		}
+		return fmt.Sprintf(`你是一位擅长家常菜的厨师。用户有昨天剩下的"%s"，希望把它改造成一道新的菜品。
+%s
+请按照以下结构回答：
+
+## ♻️ 改造方案
+
+### 1. [新菜品名称]
+**思路**：[如何利用剩菜]
+**需要补充**：[额外需要的食材]
+**制作步骤**：
+1. [第一步详细说明]
+2. [第二步详细说明]
+**预计时间**：[时间]
+
+### 2. [新菜品名称]
+[同样结构...]
+
+## 🛡️ 食品安全
+- [剩菜的保存时间和加热要求]
+- [不适合再次加工的情况]
+
+请确保方案真实可行、适合家庭厨房操作，用中文回复，语气亲切专业。`, query.DishName, extra)
+	},
+	Default: func(query PipelineQuery) string {
+		return fmt.Sprintf(`# %s 剩菜改造建议
+
+## 改造思路
+1. **炒饭/炒面**：将剩菜切碎，与米饭或面条一起大火翻炒
+2. **汤面/泡饭**：加入高汤煮开，搭配面条或米饭
+3. **馅料**：切碎后做成饺子、包子或饼的馅料
+4. **煎蛋饼**：与鸡蛋液混合，煎成蛋饼
+
+## 食品安全
+- 剩菜应冷藏保存，并在24小时内食用
+- 再次加工时要彻底加热
+- 有异味或变质的剩菜不要食用
+
+*注：当前显示通用改造思路，如需针对性的方案，请配置AI服务。*`, query.DishName)
+	},
+}
+
+// QuestionPrompt 自由烹饪问答
+var QuestionPrompt = PromptTemplate{
+	Build: func(query PipelineQuery) string {
+		return fmt.Sprintf(`你是一位专业的厨师和营养师。请回答用户的烹饪问题。
+
+用户的问题：%s
+
+请直接、准确地回答，必要时使用标题和列表组织内容，给出可以操作的具体建议。如果问题与烹饪、食材或饮食无关，请礼貌地说明只能回答烹饪相关的问题。用中文回复，语气亲切专业。`, query.Question)
+	},
+	Default: func(query PipelineQuery) string {
+		return fmt.Sprintf(`# 烹饪问答
+
+## 您的问题
+%s
+
+当前未配置AI服务，暂时无法回答自由提问。您可以：
+- 使用食材查询获取菜品推荐
+- 使用菜品查询获取制作方法
+
+*注：如需烹饪问答功能，请配置AI服务。*`, query.Question)
+	},
+}
@@ -1,24 +1,43 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
-	"os"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"recipe-agent/internal/config"
+	"recipe-agent/internal/metrics"
+	"recipe-agent/internal/tracing"
 )
 
 // RecipeService 食谱服务结构
 type RecipeService struct {
+	config           config.Spoonacular
 	apiKey           string
 	baseURL          string
 	cache            map[string]*CacheEntry
 	cacheMutex       sync.RWMutex
+	// 缓存命中和未命中次数
+	cacheHits   atomic.Uint64
+	cacheMisses atomic.Uint64
 	translationService *TranslationService
+	ontology         *IngredientOntology
+	// 请求Spoonacular的客户端，生成客户端span并传递追踪上下文和请求ID
+	client *http.Client
+	// Spoonacular熔断器，同时记录调用额度
+	breaker *Breaker
 }
 
 // CacheEntry 缓存条目
@@ -37,6 +56,9 @@ type SpoonacularRecipe struct {
 	Servings    int              `json:"servings"`
 	ReadyInMinutes int            `json:"readyInMinutes"`
 	ExtendedIngredients []ExtendedIngredient `json:"extendedIngredients"`
+	// 翻译为用户语言后保留的英文原文
+	OriginalTitle        string `json:"originalTitle,omitempty"`
+	OriginalInstructions string `json:"originalInstructions,omitempty"`
 }
 
 // ExtendedIngredient 扩展食材
@@ -45,6 +67,8 @@ type ExtendedIngredient struct {
 	Name   string  `json:"name"`
 	Amount float64 `json:"amount"`
 	Unit   string  `json:"unit"`
+	// 翻译为用户语言后保留的英文原文
+	OriginalName string `json:"originalName,omitempty"`
 }
 
 // SpoonacularResponse API响应结构
@@ -56,137 +80,265 @@ type SpoonacularResponse struct {
 }
 
 // NewRecipeService 创建食谱服务实例
-func NewRecipeService() *RecipeService {
+func NewRecipeService(cfg config.Spoonacular, translationService *TranslationService, ontology *IngredientOntology, breaker *Breaker) *RecipeService {
 	return &RecipeService{
-		apiKey:             os.Getenv("SPOONACULAR_API_KEY"),
-		baseURL:            "https://api.spoonacular.com/recipes",
+		config:             cfg,
+		apiKey:             cfg.APIKey,
+		baseURL:            cfg.BaseURL,
 		cache:              make(map[string]*CacheEntry),
-		translationService: NewTranslationService(),
+		translationService: translationService,
+		ontology:           ontology,
+		client:             &http.Client{Transport: upstreamTransport()},
+		breaker:            breaker,
 	}
 }
 
+// RecipeSearch 食谱搜索结果及搜索过程信息
+type RecipeSearch struct {
+	Recipes []SpoonacularRecipe
+	// Translations 原文到英文的翻译结果，Terms 为实际用于查询的英文词
+	Translations []Translation
+	Terms        []string
+	CacheHit     bool
+	// Skipped 未发起查询的原因（数据源错误码），为空表示已查询
+	Skipped string
+}
+
 // translateIngredients 翻译中文食材为英文（使用动态翻译服务）
-func (s *RecipeService) translateIngredients(ingredients []string) []string {
-	return s.translationService.TranslateIngredients(ingredients)
+// 低置信度的译文不参与搜索，以放宽条件而不是用错误的食材查询
+func (s *RecipeService) translateIngredients(ctx context.Context, ingredients []string) ([]Translation, []string) {
+	translations := s.translationService.TranslateIngredientsDetailed(ctx, ingredients)
+
+	var terms, skipped []string
+	for _, translation := range translations {
+		switch {
+		case translation.Text == "":
+			continue
+		case translation.LowConfidence:
+			skipped = append(skipped, fmt.Sprintf("%s→%s(%.2f)", translation.Source, translation.Text, translation.Confidence))
+		default:
+			terms = append(terms, translation.Text)
+		}
+	}
+
+	if len(skipped) > 0 {
+		slog.InfoContext(ctx, "低置信度翻译不参与食谱搜索", "skipped", skipped)
+	}
+	return translations, terms
 }
 
 // SearchByIngredients 根据食材搜索食谱
 func (s *RecipeService) SearchByIngredients(ingredients []string) ([]SpoonacularRecipe, error) {
+	search, err := s.SearchByIngredientsDetailed(context.Background(), ingredients)
+	if err != nil {
+		return []SpoonacularRecipe{}, err
+	}
+	return search.Recipes, nil
+}
+
+// SearchByIngredientsDetailed 根据食材搜索食谱，同时返回翻译详情和缓存命中情况
+func (s *RecipeService) SearchByIngredientsDetailed(ctx context.Context, ingredients []string) (search *RecipeSearch, err error) {
+	ctx, span := tracing.Start(ctx, "recipe.search_by_ingredients", attribute.StringSlice("recipe.ingredients", ingredients))
+	defer func() { endSearchSpan(span, search, err) }()
+
+	search = &RecipeSearch{Recipes: []SpoonacularRecipe{}}
 	if s.apiKey == "" {
-		return []SpoonacularRecipe{}, nil
+		search.Skipped = SourceErrorNotConfigured
+		return search, nil
 	}
 
 	// 翻译中文食材为英文
-	translatedIngredients := s.translateIngredients(ingredients)
-	if len(translatedIngredients) == 0 {
-		return []SpoonacularRecipe{}, nil
+	search.Translations, search.Terms = s.translateIngredients(ctx, ingredients)
+	if len(search.Terms) == 0 {
+		search.Skipped = SourceErrorLowConfidence
+		return search, nil
 	}
 
-	// 检查缓存（使用原始食材作为缓存键）
+	// 检查缓存（使用规范化后的食材作为缓存键）
 	cacheKey := s.generateCacheKey("ingredients", ingredients)
 	if cached := s.getFromCache(cacheKey); cached != "" {
 		var recipes []SpoonacularRecipe
 		if err := json.Unmarshal([]byte(cached), &recipes); err == nil {
-			return recipes, nil
+			search.Recipes = recipes
+			search.CacheHit = true
+			return search, nil
 		}
 	}
 
 	// 构建请求参数（使用翻译后的英文食材）
-	ingredientsStr := strings.Join(translatedIngredients, ",+")
-	apiURL := fmt.Sprintf("%s/findByIngredients?ingredients=%s&number=5&apiKey=%s",
-		s.baseURL, url.QueryEscape(ingredientsStr), s.apiKey)
-
-	// 发送请求
-	resp, err := http.Get(apiURL)
-	if err != nil {
-		return []SpoonacularRecipe{}, fmt.Errorf("API请求失败: %v", err)
-	}
-	defer resp.Body.Close()
+	ingredientsStr := strings.Join(search.Terms, ",+")
+	apiURL := fmt.Sprintf("%s/findByIngredients?ingredients=%s&number=%d",
+		s.baseURL, url.QueryEscape(ingredientsStr), s.config.ResultNumber)
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return []SpoonacularRecipe{}, fmt.Errorf("API返回错误: %d - %s", resp.StatusCode, string(body))
-	}
-
-	body, err := io.ReadAll(resp.Body)
+	body, err := s.fetch(ctx, apiURL)
 	if err != nil {
-		return []SpoonacularRecipe{}, fmt.Errorf("读取响应失败: %v", err)
+		return search, err
 	}
 
 	var recipes []SpoonacularRecipe
 	if err := json.Unmarshal(body, &recipes); err != nil {
-		return []SpoonacularRecipe{}, fmt.Errorf("解析响应失败: %v", err)
+		return search, newSourceError(SourceErrorInvalidResponse, "解析响应失败: %v", err)
 	}
 
 	// 缓存结果
-	s.saveToCache(cacheKey, string(body), 30*time.Minute)
+	s.saveToCache(cacheKey, string(body), s.config.IngredientsCacheTTL.Duration)
 
-	return recipes, nil
+	search.Recipes = recipes
+	return search, nil
+}
+
+// endSearchSpan 记录搜索结果后结束span
+func endSearchSpan(span trace.Span, search *RecipeSearch, err error) {
+	span.SetAttributes(
+		attribute.StringSlice("recipe.terms", search.Terms),
+		attribute.Int("recipe.results", len(search.Recipes)),
+		attribute.Bool("recipe.cache_hit", search.CacheHit),
+	)
+	if search.Skipped != "" {
+		span.SetAttributes(attribute.String("recipe.skipped", search.Skipped))
+	}
+	tracing.End(span, err)
 }
 
 // translateDishName 翻译中文菜名为英文（使用动态翻译服务）
-func (s *RecipeService) translateDishName(dishName string) string {
-	return s.translationService.TranslateDishName(dishName)
+// 低置信度的译文直接跳过搜索，避免返回不相关的食谱
+func (s *RecipeService) translateDishName(ctx context.Context, dishName string) (Translation, string) {
+	translation := s.translationService.TranslateDishNameDetailed(ctx, dishName)
+	if translation.LowConfidence {
+		slog.InfoContext(ctx, "菜名翻译置信度过低，跳过食谱搜索", "dish", dishName, "translation", translation.Text, "confidence", translation.Confidence)
+		return translation, ""
+	}
+	return translation, translation.Text
 }
 
 // SearchByDishName 根据菜品名搜索食谱
 func (s *RecipeService) SearchByDishName(dishName string) ([]SpoonacularRecipe, error) {
+	search, err := s.SearchByDishNameDetailed(context.Background(), dishName)
+	if err != nil {
+		return []SpoonacularRecipe{}, err
+	}
+	return search.Recipes, nil
+}
+
+// SearchByDishNameDetailed 根据菜品名搜索食谱，同时返回翻译详情和缓存命中情况
+func (s *RecipeService) SearchByDishNameDetailed(ctx context.Context, dishName string) (search *RecipeSearch, err error) {
+	ctx, span := tracing.Start(ctx, "recipe.search_by_dish", attribute.String("recipe.dish", dishName))
+	defer func() { endSearchSpan(span, search, err) }()
+
+	search = &RecipeSearch{Recipes: []SpoonacularRecipe{}}
 	if s.apiKey == "" {
-		return []SpoonacularRecipe{}, nil
+		search.Skipped = SourceErrorNotConfigured
+		return search, nil
 	}
 
 	// 翻译中文菜名为英文
-	translatedDishName := s.translateDishName(dishName)
+	translation, translatedDishName := s.translateDishName(ctx, dishName)
+	search.Translations = []Translation{translation}
 	if translatedDishName == "" {
-		return []SpoonacularRecipe{}, nil
+		search.Skipped = SourceErrorLowConfidence
+		return search, nil
 	}
+	search.Terms = []string{translatedDishName}
 
-	// 检查缓存（使用原始菜名作为缓存键）
+	// 检查缓存（使用规范化后的菜名作为缓存键）
 	cacheKey := s.generateCacheKey("dish", []string{dishName})
 	if cached := s.getFromCache(cacheKey); cached != "" {
-		var recipes []SpoonacularRecipe
-		if err := json.Unmarshal([]byte(cached), &recipes); err == nil {
-			return recipes, nil
+		var searchResp SpoonacularResponse
+		if err := json.Unmarshal([]byte(cached), &searchResp); err == nil {
+			search.Recipes = searchResp.Results
+			search.CacheHit = true
+			return search, nil
 		}
 	}
 
 	// 构建请求参数（使用翻译后的英文菜名）
-	apiURL := fmt.Sprintf("%s/complexSearch?query=%s&number=5&addRecipeInformation=true&apiKey=%s",
-		s.baseURL, url.QueryEscape(translatedDishName), s.apiKey)
+	apiURL := fmt.Sprintf("%s/complexSearch?query=%s&number=%d&addRecipeInformation=true",
+		s.baseURL, url.QueryEscape(translatedDishName), s.config.ResultNumber)
 
-	// 发送请求
-	resp, err := http.Get(apiURL)
+	body, err := s.fetch(ctx, apiURL)
+	if err != nil {
+		return search, err
+	}
+
+	var searchResp SpoonacularResponse
+	if err := json.Unmarshal(body, &searchResp); err != nil {
+		return search, newSourceError(SourceErrorInvalidResponse, "解析响应失败: %v", err)
+	}
+
+	// 缓存结果
+	s.saveToCache(cacheKey, string(body), s.config.DishCacheTTL.Duration)
+
+	search.Recipes = searchResp.Results
+	return search, nil
+}
+
+// fetch 请求Spoonacular接口并返回响应体，API密钥通过 x-api-key 请求头传递，不出现在地址和错误信息中
+func (s *RecipeService) fetch(ctx context.Context, apiURL string) (body []byte, err error) {
+	defer observeUpstream(metrics.ProviderSpoonacular, time.Now(), &err)
+	if err = s.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	defer func() { s.breaker.Record(err) }()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
 	if err != nil {
-		return []SpoonacularRecipe{}, fmt.Errorf("API请求失败: %v", err)
+		return nil, newSourceError(SourceErrorRequestFailed, "创建请求失败: %v", err)
+	}
+	req.Header.Set("x-api-key", s.apiKey)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, newSourceError(SourceErrorRequestFailed, "API请求失败: %w", err)
 	}
 	defer resp.Body.Close()
+	s.breaker.RecordQuota(resp.Header)
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return []SpoonacularRecipe{}, fmt.Errorf("API返回错误: %d - %s", resp.StatusCode, string(body))
+		return nil, upstreamStatusError(resp.StatusCode, body)
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	body, err = io.ReadAll(resp.Body)
 	if err != nil {
-		return []SpoonacularRecipe{}, fmt.Errorf("读取响应失败: %v", err)
+		return nil, newSourceError(SourceErrorInvalidResponse, "读取响应失败: %v", err)
 	}
+	return body, nil
+}
 
-	var searchResp SpoonacularResponse
-	if err := json.Unmarshal(body, &searchResp); err != nil {
-		return []SpoonacularRecipe{}, fmt.Errorf("解析响应失败: %v", err)
+// WarmTranslations 预先翻译一批请求中的全部食材和菜名，食材合并为一次AI请求
+// 结果写入翻译记忆和缓存，之后各请求的搜索直接命中
+func (s *RecipeService) WarmTranslations(ctx context.Context, ingredients, dishNames []string) {
+	if s.apiKey == "" {
+		return
 	}
-
-	// 缓存结果
-	s.saveToCache(cacheKey, string(body), 60*time.Minute)
-
-	return searchResp.Results, nil
+	ctx, span := tracing.Start(ctx, "recipe.warm_translations")
+	defer span.End()
+
+	var wg sync.WaitGroup
+	if len(ingredients) > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.translationService.TranslateIngredientsDetailed(ctx, uniqueStrings(ingredients))
+		}()
+	}
+	for _, dishName := range uniqueStrings(dishNames) {
+		wg.Add(1)
+		go func(dishName string) {
+			defer wg.Done()
+			s.translationService.TranslateDishNameDetailed(ctx, dishName)
+		}(dishName)
+	}
+	wg.Wait()
 }
 
 // GetRecipeInformation 获取详细食谱信息
-func (s *RecipeService) GetRecipeInformation(recipeID int) (*SpoonacularRecipe, error) {
+func (s *RecipeService) GetRecipeInformation(ctx context.Context, recipeID int) (_ *SpoonacularRecipe, err error) {
 	if s.apiKey == "" {
 		return nil, fmt.Errorf("API密钥未配置")
 	}
+	ctx, span := tracing.Start(ctx, "recipe.information", attribute.Int("recipe.id", recipeID))
+	defer func() { tracing.End(span, err) }()
 
 	// 检查缓存
 	cacheKey := fmt.Sprintf("recipe_info_%d", recipeID)
@@ -198,24 +350,13 @@ func (s *RecipeService) GetRecipeInformation(recipeID int) (*SpoonacularRecipe,
 	}
 
 	// 构建请求参数
-	apiURL := fmt.Sprintf("%s/%d/information?includeNutrition=false&apiKey=%s",
-		s.baseURL, recipeID, s.apiKey)
+	apiURL := fmt.Sprintf("%s/%d/information?includeNutrition=false",
+		s.baseURL, recipeID)
 
 	// 发送请求
-	resp, err := http.Get(apiURL)
+	body, err := s.fetch(ctx, apiURL)
 	if err != nil {
-		return nil, fmt.Errorf("API请求失败: %v", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API返回错误: %d - %s", resp.StatusCode, string(body))
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("读取响应失败: %v", err)
+		return nil, err
 	}
 
 	var recipe SpoonacularRecipe
@@ -224,11 +365,21 @@ func (s *RecipeService) GetRecipeInformation(recipeID int) (*SpoonacularRecipe,
 	}
 
 	// 缓存结果
-	s.saveToCache(cacheKey, string(body), 60*time.Minute)
+	s.saveToCache(cacheKey, string(body), s.config.RecipeCacheTTL.Duration)
 
 	return &recipe, nil
 }
 
+// LocalizeRecipes 将食谱标题、食材名和制作说明翻译为用户语言
+func (s *RecipeService) LocalizeRecipes(ctx context.Context, recipes []SpoonacularRecipe, locale string) []SpoonacularRecipe {
+	ctx, span := tracing.Start(ctx, "recipe.localize",
+		attribute.String("recipe.locale", locale),
+		attribute.Int("recipe.count", len(recipes)),
+	)
+	defer span.End()
+	return s.translationService.LocalizeRecipes(ctx, recipes, locale)
+}
+
 // FormatRecipesForAI 将食谱格式化为AI可读取的格式
 func (s *RecipeService) FormatRecipesForAI(recipes []SpoonacularRecipe) string {
 	if len(recipes) == 0 {
@@ -239,7 +390,11 @@ func (s *RecipeService) FormatRecipesForAI(recipes []SpoonacularRecipe) string {
 	result.WriteString("## 参考食谱信息\n\n")
 
 	for i, recipe := range recipes {
-		result.WriteString(fmt.Sprintf("### 参考食谱 %d: %s\n", i+1, recipe.Title))
+		if recipe.OriginalTitle != "" && recipe.OriginalTitle != recipe.Title {
+			result.WriteString(fmt.Sprintf("### 参考食谱 %d: %s (%s)\n", i+1, recipe.Title, recipe.OriginalTitle))
+		} else {
+			result.WriteString(fmt.Sprintf("### 参考食谱 %d: %s\n", i+1, recipe.Title))
+		}
 
 		if recipe.ReadyInMinutes > 0 {
 			result.WriteString(fmt.Sprintf("- **制作时间**: %d分钟\n", recipe.ReadyInMinutes))
@@ -266,23 +421,41 @@ func (s *RecipeService) FormatRecipesForAI(recipes []SpoonacularRecipe) string {
 	return result.String()
 }
 
-// generateCacheKey 生成缓存键
+// generateCacheKey 生成缓存键，食材按本体规范ID归一化并排序，使同义词和顺序不同的请求共享缓存
 func (s *RecipeService) generateCacheKey(prefix string, items []string) string {
-	return fmt.Sprintf("%s:%s", prefix, strings.Join(items, "|"))
+	keys := make([]string, 0, len(items))
+	for _, item := range items {
+		if prefix == "ingredients" {
+			keys = append(keys, s.ontology.CanonicalKey(item))
+		} else {
+			keys = append(keys, normalizeName(item))
+		}
+	}
+	if prefix == "ingredients" {
+		sort.Strings(keys)
+	}
+	return fmt.Sprintf("%s:%s", prefix, strings.Join(keys, "|"))
 }
 
 // getFromCache 从缓存获取数据
 func (s *RecipeService) getFromCache(key string) string {
 	s.cacheMutex.RLock()
-	defer s.cacheMutex.RUnlock()
+	entry, exists := s.cache[key]
+	s.cacheMutex.RUnlock()
 
-	if entry, exists := s.cache[key]; exists {
-		if time.Now().Before(entry.ExpiresAt) {
-			return entry.Data
+	if exists && time.Now().Before(entry.ExpiresAt) {
+		s.cacheHits.Add(1)
+		return entry.Data
+	}
+	if exists {
+		// 清理过期缓存需要写锁，期间条目可能已被重新写入，只删除同一个过期条目
+		s.cacheMutex.Lock()
+		if s.cache[key] == entry {
+			delete(s.cache, key)
 		}
-		// 清理过期缓存
-		delete(s.cache, key)
+		s.cacheMutex.Unlock()
 	}
+	s.cacheMisses.Add(1)
 	return ""
 }
 
@@ -297,21 +470,60 @@ func (s *RecipeService) saveToCache(key, data string, ttl time.Duration) {
 	}
 }
 
-// CleanExpiredCache 清理过期缓存
-func (s *RecipeService) CleanExpiredCache() {
+// CleanExpiredCache 清理过期缓存，返回清理的条目数
+func (s *RecipeService) CleanExpiredCache() int {
 	s.cacheMutex.Lock()
 	defer s.cacheMutex.Unlock()
 
 	now := time.Now()
+	removed := 0
 	for key, entry := range s.cache {
 		if now.After(entry.ExpiresAt) {
-		delete(s.cache, key)
+			delete(s.cache, key)
+			removed++
 		}
 	}
+	return removed
 }
 
-// GetCacheStatus 获取缓存状态
-func (s *RecipeService) GetCacheStatus() map[string]interface{} {
+// CacheEntries 列出键以 prefix 开头的缓存条目（如 ingredients:、dish:、recipe_info_），按键排序
+func (s *RecipeService) CacheEntries(prefix string) []CachedEntry {
+	s.cacheMutex.RLock()
+	defer s.cacheMutex.RUnlock()
+
+	now := time.Now()
+	entries := []CachedEntry{}
+	for key, entry := range s.cache {
+		if strings.HasPrefix(key, prefix) {
+			entries = append(entries, CachedEntry{
+				Key:       key,
+				Size:      len(entry.Data),
+				ExpiresAt: entry.ExpiresAt,
+				Expired:   !now.Before(entry.ExpiresAt),
+			})
+		}
+	}
+	sortCachedEntries(entries)
+	return entries
+}
+
+// PurgeCache 删除键以 prefix 开头的缓存条目，prefix 为空时清空缓存，返回删除的条目数
+func (s *RecipeService) PurgeCache(prefix string) int {
+	s.cacheMutex.Lock()
+	defer s.cacheMutex.Unlock()
+
+	removed := 0
+	for key := range s.cache {
+		if strings.HasPrefix(key, prefix) {
+			delete(s.cache, key)
+			removed++
+		}
+	}
+	return removed
+}
+
+// GetCacheStatus 获取缓存状态和命中率
+func (s *RecipeService) GetCacheStatus() metrics.CacheStatus {
 	s.cacheMutex.RLock()
 	defer s.cacheMutex.RUnlock()
 
@@ -327,9 +539,5 @@ func (s *RecipeService) GetCacheStatus() map[string]interface{} {
 		}
 	}
 
-	return map[string]interface{}{
-		"total_entries":  len(s.cache),
-		"active_entries": activeCount,
-		"expired_entries": expiredCount,
-	}
+	return metrics.NewCacheStatus(activeCount, expiredCount, s.cacheHits.Load(), s.cacheMisses.Load())
 }
\ No newline at end of file
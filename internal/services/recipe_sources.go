@@ -0,0 +1,215 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// 数据源名称和默认优先级
+const (
+	SourceAI          = "ai"
+	SourceSpoonacular = "spoonacular"
+
+	priorityAI          = 100
+	prioritySpoonacular = 50
+)
+
+// Spoonacular数据源的搜索方式
+const (
+	SearchByIngredients = "ingredients"
+	SearchByDishName    = "dish"
+)
+
+// NewAISource 创建按提示词模板生成内容的AI数据源，超时时间使用AI服务的配置
+func NewAISource(aiService *AIService, template PromptTemplate) Source {
+	return Source{
+		Name:     SourceAI,
+		Priority: priorityAI,
+		Timeout:  aiService.sourceTimeout,
+		Fetch: func(ctx context.Context, query PipelineQuery) (SourceOutput, error) {
+			content, err := aiService.Generate(ctx, template, query)
+			if err != nil {
+				slog.WarnContext(ctx, "AI服务调用失败", "error", err)
+				return SourceOutput{}, err
+			}
+
+			output := SourceOutput{Content: content}
+			if !aiService.Configured() {
+				// 未配置密钥时返回的是内置默认内容
+				output.Notice = SourceErrorNotConfigured
+			}
+			return output, nil
+		},
+	}
+}
+
+// NewSpoonacularSource 创建Spoonacular食谱数据源，按 searchBy 使用食材或菜名搜索，
+// 返回的食谱翻译为请求语言，超时时间使用食谱服务的配置
+func NewSpoonacularSource(recipeService *RecipeService, searchBy string) Source {
+	return Source{
+		Name:     SourceSpoonacular,
+		Priority: prioritySpoonacular,
+		Timeout:  recipeService.config.SourceTimeout.Duration,
+		Fetch: func(ctx context.Context, query PipelineQuery) (SourceOutput, error) {
+			var search *RecipeSearch
+			var err error
+			if searchBy == SearchByDishName {
+				search, err = recipeService.SearchByDishNameDetailed(ctx, query.DishName)
+			} else {
+				search, err = recipeService.SearchByIngredientsDetailed(ctx, query.Ingredients)
+			}
+			if err != nil {
+				slog.WarnContext(ctx, "食谱API调用失败", "error", err)
+				return SourceOutput{Search: search}, err
+			}
+
+			ReportProgress(ctx, JobStageLocalizing)
+			search.Recipes = recipeService.LocalizeRecipes(ctx, search.Recipes, query.Locale)
+			return SourceOutput{Recipes: search.Recipes, Search: search, Skipped: search.Skipped}, nil
+		},
+	}
+}
+
+// FallbackFunc 没有文本内容时根据参考食谱生成结果
+type FallbackFunc func(query PipelineQuery, recipesText string) string
+
+// RecipeCombiner 默认合并策略：使用优先级最高的文本内容，在 heading 标题下附上其余数据源的参考食谱；
+// 没有文本内容时由 fallback 根据参考食谱生成基础结果，fallback 为空时视为没有可用结果
+type RecipeCombiner struct {
+	recipeService *RecipeService
+	heading       string
+	fallback      FallbackFunc
+}
+
+// NewRecipeCombiner 创建默认合并策略
+func NewRecipeCombiner(recipeService *RecipeService, heading string, fallback FallbackFunc) *RecipeCombiner {
+	return &RecipeCombiner{recipeService: recipeService, heading: heading, fallback: fallback}
+}
+
+// Combine 合并各数据源结果，results 按优先级从高到低排序
+func (c *RecipeCombiner) Combine(query PipelineQuery, results []SourceResult) (*PipelineResult, error) {
+	combined := &PipelineResult{Recipes: []SpoonacularRecipe{}}
+
+	// 优先级最高的文本内容作为正文
+	content := ""
+	for _, result := range results {
+		if result.Usable() && result.Output.Content != "" {
+			content = result.Output.Content
+			combined.Contributors = append(combined.Contributors, result.Source)
+			break
+		}
+	}
+
+	// 其余成功查询的数据源提供参考食谱
+	references := false
+	for _, result := range results {
+		if result.Err != nil || result.Output.Content != "" {
+			continue
+		}
+		references = true
+		if result.Output.Skipped != "" {
+			continue
+		}
+		combined.Recipes = append(combined.Recipes, result.Output.Recipes...)
+		combined.Contributors = append(combined.Contributors, result.Source)
+	}
+
+	if len(combined.Contributors) == 0 || (content == "" && c.fallback == nil) {
+		return nil, ErrNoUsableSource
+	}
+
+	recipesText := c.recipeService.FormatRecipesForAI(combined.Recipes)
+	switch {
+	case content == "":
+		// 只有参考食谱可用
+		combined.Content = c.fallback(query, recipesText)
+	case references:
+		combined.Content = content + "\n\n" + c.heading + "\n\n" + recipesText
+	default:
+		combined.Content = content
+	}
+	return combined, nil
+}
+
+// 内置查询类型参考食谱部分的标题
+const (
+	IngredientsReferenceHeading = "## API食谱参考"
+	DishReferenceHeading        = "## 参考食谱信息"
+)
+
+// IngredientsFallback 没有AI分析时根据参考食谱生成食材推荐
+func IngredientsFallback(query PipelineQuery, recipesText string) string {
+	return fallbackIngredientResult(query.Ingredients, recipesText)
+}
+
+// DishFallback 没有AI分析时根据参考食谱生成菜品制作指南
+func DishFallback(query PipelineQuery, recipesText string) string {
+	return fallbackDishResult(query.DishName, recipesText)
+}
+
+// fallbackIngredientResult 生成食材请求的备选结果
+func fallbackIngredientResult(ingredients []string, recipesText string) string {
+	ingredientsStr := strings.Join(ingredients, "、")
+	return fmt.Sprintf(`# 食材分析与推荐
+
+## 食材概述
+您提供的食材：%s
+
+这些食材搭配很有创意，可以制作出营养丰富、口感多样的菜品。
+
+%s
+
+## 简单制作建议
+
+### 推荐制作方式
+1. **清炒类**：将主要食材切块，大火快炒，保持食材营养和口感
+2. **汤品类**：制作营养汤品，适合全家享用
+3. **焖烧类**：慢火焖煮，让食材充分融合味道
+
+### 烹饪小贴士
+- 食材新鲜度是成功的关键
+- 控制火候，避免过度烹饪
+- 适当调味，突显食材本味
+
+*注：当前显示基础推荐，如需个性化专业建议，请配置完整服务。*`, ingredientsStr, recipesText)
+}
+
+// fallbackDishResult 生成菜品请求的备选结果
+func fallbackDishResult(dishName, recipesText string) string {
+	return fmt.Sprintf(`# %s 制作指南
+
+## 菜品介绍
+%s是一道经典菜品，具有独特的风味和文化特色。
+
+## 基础制作方法
+
+### 食材准备
+- 主要食材（根据菜品特点选择）
+- 调料：盐、生抽、料酒等基础调料
+- 辅料：姜、蒜、葱等增香材料
+
+### 制作步骤
+1. **准备工作**
+   - 清洗和处理所有食材
+   - 按需要改刀切配
+
+2. **烹饪过程**
+   - 热锅下油，控制油温
+   - 按顺序下入食材
+   - 适时调味，注意火候
+
+3. **完成装盘**
+   - 调整最终口味
+   - 适当装饰，提升视觉效果
+
+### 成功要点
+- 食材处理要均匀一致
+- 火候控制要精准
+- 调味要层次分明
+
+%s
+
+*注：当前显示基础制作指南，如需详细专业指导，请配置完整服务。*`, dishName, dishName, recipesText)
+}
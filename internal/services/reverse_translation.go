@@ -0,0 +1,261 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"html"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"recipe-agent/internal/tracing"
+)
+
+// DefaultLocale 默认的响应语言
+const DefaultLocale = "zh-CN"
+
+// localeLanguages 支持的目标语言及其在提示词中的名称
+var localeLanguages = map[string]string{
+	"zh":    "简体中文",
+	"zh-cn": "简体中文",
+	"zh-sg": "简体中文",
+	"zh-tw": "繁體中文",
+	"zh-hk": "繁體中文",
+	"ja":    "日语",
+	"ko":    "韩语",
+	"fr":    "法语",
+	"de":    "德语",
+	"es":    "西班牙语",
+}
+
+// targetLanguage 返回目标语言名称，英文或不支持的语言返回false（无需翻译）
+func targetLanguage(locale string) (string, bool) {
+	locale = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+	if locale == "" {
+		locale = strings.ToLower(DefaultLocale)
+	}
+	if name, exists := localeLanguages[locale]; exists {
+		return name, true
+	}
+	// 仅匹配主语言，例如 ja-JP → ja
+	if i := strings.Index(locale, "-"); i > 0 {
+		if name, exists := localeLanguages[locale[:i]]; exists {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// isChineseLocale 判断是否为中文语言环境
+func isChineseLocale(locale string) bool {
+	name, ok := targetLanguage(locale)
+	return ok && (name == "简体中文" || name == "繁體中文")
+}
+
+// TranslateText 将英文文本翻译为目标语言，失败或无需翻译时返回原文
+func (t *TranslationService) TranslateText(ctx context.Context, text, locale string) string {
+	translations := t.TranslateTexts(ctx, []string{text}, locale)
+	return translations[text]
+}
+
+// TranslateTexts 将多条英文文本翻译为目标语言，返回原文到译文的映射
+// 未命中缓存的短文本合并为一次AI请求，长文本（如制作步骤）并行单独翻译
+func (t *TranslationService) TranslateTexts(ctx context.Context, texts []string, locale string) map[string]string {
+	ctx, span := tracing.Start(ctx, "translation.texts",
+		attribute.String("translation.locale", locale),
+		attribute.Int("translation.count", len(texts)),
+	)
+	defer span.End()
+
+	translations := make(map[string]string, len(texts))
+	language, ok := targetLanguage(locale)
+
+	var shortMisses, longMisses []string
+	for _, text := range texts {
+		if _, done := translations[text]; done {
+			continue
+		}
+		translations[text] = text
+		if !ok || strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		// 食材本体可直接给出中文名
+		if isChineseLocale(locale) {
+			if ingredient, exists := t.ontology.Lookup(text); exists {
+				translations[text] = ingredient.Chinese
+				continue
+			}
+		}
+
+		if cached := t.getFromCache(t.textCacheKey(text, locale)); cached != "" {
+			translations[text] = cached
+			continue
+		}
+
+		if len([]rune(text)) > 200 {
+			longMisses = append(longMisses, text)
+		} else {
+			shortMisses = append(shortMisses, text)
+		}
+	}
+
+	span.SetAttributes(
+		attribute.Int("translation.short_misses", len(shortMisses)),
+		attribute.Int("translation.long_misses", len(longMisses)),
+	)
+	if len(shortMisses)+len(longMisses) == 0 {
+		return translations
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	store := func(text, translation string) {
+		if translation == "" {
+			return
+		}
+		t.saveToCache(t.textCacheKey(text, locale), translation, t.config.CacheTTL.Duration)
+		mu.Lock()
+		translations[text] = translation
+		mu.Unlock()
+	}
+
+	if len(shortMisses) > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			batch, err := t.translateTextsWithAI(ctx, shortMisses, language)
+			if err != nil {
+				slog.WarnContext(ctx, "反向翻译失败，保留原文", "texts", len(shortMisses), "error", err)
+				return
+			}
+			// 解析结果的键已去除首尾空白，按同样的方式查找
+			for _, text := range shortMisses {
+				store(text, batch[strings.TrimSpace(text)])
+			}
+		}()
+	}
+
+	for _, text := range longMisses {
+		wg.Add(1)
+		go func(text string) {
+			defer wg.Done()
+			translation, err := t.translateLongTextWithAI(ctx, text, language)
+			if err != nil {
+				slog.WarnContext(ctx, "制作说明翻译失败，保留原文", "error", err)
+				return
+			}
+			store(text, translation)
+		}(text)
+	}
+
+	wg.Wait()
+	return translations
+}
+
+// LocalizeRecipes 将Spoonacular食谱的标题、食材名和制作说明翻译为目标语言，原文保留在Original*字段
+func (t *TranslationService) LocalizeRecipes(ctx context.Context, recipes []SpoonacularRecipe, locale string) []SpoonacularRecipe {
+	if _, ok := targetLanguage(locale); !ok || len(recipes) == 0 {
+		return recipes
+	}
+
+	var texts []string
+	for _, recipe := range recipes {
+		texts = append(texts, recipe.Title)
+		for _, ing := range recipe.ExtendedIngredients {
+			texts = append(texts, ing.Name)
+		}
+		if recipe.Instructions != "" {
+			texts = append(texts, recipe.Instructions)
+		}
+	}
+
+	translations := t.TranslateTexts(ctx, texts, locale)
+
+	localized := make([]SpoonacularRecipe, len(recipes))
+	for i, recipe := range recipes {
+		recipe.OriginalTitle = recipe.Title
+		recipe.Title = translations[recipe.Title]
+
+		ingredients := make([]ExtendedIngredient, len(recipe.ExtendedIngredients))
+		for j, ing := range recipe.ExtendedIngredients {
+			ing.OriginalName = ing.Name
+			ing.Name = translations[ing.Name]
+			ingredients[j] = ing
+		}
+		recipe.ExtendedIngredients = ingredients
+
+		if recipe.Instructions != "" {
+			recipe.OriginalInstructions = recipe.Instructions
+			recipe.Instructions = translations[recipe.Instructions]
+		}
+		localized[i] = recipe
+	}
+	return localized
+}
+
+// textCacheKey 生成反向翻译缓存键，长文本使用摘要
+func (t *TranslationService) textCacheKey(text, locale string) string {
+	return fmt.Sprintf("text:%s:%x", strings.ToLower(locale), sha256.Sum256([]byte(text)))
+}
+
+// translateTextsWithAI 在一次AI请求中将多条英文短文本翻译为目标语言
+func (t *TranslationService) translateTextsWithAI(ctx context.Context, texts []string, language string) (map[string]string, error) {
+	items, err := json.Marshal(texts)
+	if err != nil {
+		return nil, fmt.Errorf("序列化翻译内容失败: %v", err)
+	}
+
+	prompt := fmt.Sprintf(`请将以下JSON数组中的英文食谱标题和食材名称逐个翻译成%s，使用家常、自然的说法。
+只返回一个JSON对象，键为英文原文（保持原样），值为译文，不要任何解释或代码块标记：
+%s`, language, string(items))
+
+	content, err := t.callTranslationAPI(ctx, prompt, t.config.BatchTimeout.Duration)
+	if err != nil {
+		return nil, err
+	}
+	return parseTranslationMap(content)
+}
+
+// translateLongTextWithAI 将较长的英文文本（如制作说明）翻译为目标语言
+// 原文中的HTML标签先转换为纯文本，译文校验通过后才返回
+func (t *TranslationService) translateLongTextWithAI(ctx context.Context, text, language string) (string, error) {
+	plain := htmlToText(text)
+	if plain == "" {
+		return "", fmt.Errorf("制作说明为空")
+	}
+	prompt := fmt.Sprintf(`请将以下英文食谱制作说明翻译成%s，保留原有的步骤顺序，每个步骤一行，使用纯文本，不要包含HTML标签、标题或任何解释，只返回译文：
+%s`, language, plain)
+
+	content, err := t.callTranslationAPI(ctx, prompt, t.config.LongTextTimeout.Duration)
+	if err != nil {
+		return "", err
+	}
+	return validateLongTranslationOutput(plain, content)
+}
+
+var (
+	// htmlBreakPattern 换行类标签，转换为换行
+	htmlBreakPattern = regexp.MustCompile(`(?i)<\s*(?:br|/p|/li|/div|/h[1-6])\s*/?\s*>`)
+	// htmlTagPattern 其他HTML标签，直接移除
+	htmlTagPattern = regexp.MustCompile(`<[^>]*>`)
+)
+
+// htmlToText 将HTML片段转换为纯文本，按换行类标签分行并去除空行
+func htmlToText(text string) string {
+	text = htmlBreakPattern.ReplaceAllString(text, "\n")
+	text = html.UnescapeString(htmlTagPattern.ReplaceAllString(text, ""))
+
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
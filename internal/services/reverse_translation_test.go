@@ -0,0 +1,82 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"recipe-agent/internal/config"
+)
+
+// newTestTranslationService 创建使用模拟AI接口的翻译服务，reply 根据提示词返回模型回复，返回的计数为AI调用次数
+func newTestTranslationService(t *testing.T, reply func(prompt string) string) (*TranslationService, *atomic.Int32) {
+	t.Helper()
+	calls := &atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		var req struct {
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Messages) == 0 {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		resp := map[string]interface{}{
+			"choices": []map[string]interface{}{
+				{"message": map[string]string{"content": reply(req.Messages[0].Content)}},
+			},
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(server.Close)
+
+	data := config.Data{IngredientsFile: filepath.Join("..", "..", "data", "ingredients.json")}
+	memory := newTestTranslationMemory(t, t.TempDir(), time.Hour)
+	cfg := config.Translation{
+		CacheTTL:        config.Duration{Duration: time.Hour},
+		Timeout:         config.Duration{Duration: 5 * time.Second},
+		BatchTimeout:    config.Duration{Duration: 5 * time.Second},
+		LongTextTimeout: config.Duration{Duration: 5 * time.Second},
+		Concurrency:     2,
+	}
+	deepSeek := config.DeepSeek{APIKey: "test", BaseURL: server.URL, Model: "test"}
+	breaker := NewBreaker("translation", config.Breaker{Threshold: 100, Cooldown: config.Duration{Duration: time.Second}})
+	return NewTranslationService(deepSeek, cfg, NewIngredientOntology(data), memory, breaker), calls
+}
+
+func TestHTMLToText(t *testing.T) {
+	got := htmlToText("<ol><li>Heat the <b>oil</b>.</li><li>Add eggs &amp; stir.<br/>Serve.</li></ol>")
+	if want := "Heat the oil.\nAdd eggs & stir.\nServe."; got != want {
+		t.Errorf("htmlToText = %q, want %q", got, want)
+	}
+}
+
+func TestTranslateTexts(t *testing.T) {
+	instructions := "<ol>" + strings.Repeat("<li>Heat the oil in a wok, then add the eggs and stir gently.</li>", 5) + "</ol>"
+	s, _ := newTestTranslationService(t, func(prompt string) string {
+		if strings.Contains(prompt, "制作说明") {
+			return "以下是翻译后的制作说明：\n热油后加入鸡蛋。"
+		}
+		// 模型返回的键去除了首尾空白
+		return `{"Fried Rice": "炒饭"}`
+	})
+
+	translations := s.TranslateTexts(t.Context(), []string{" Fried Rice ", instructions}, "zh-CN")
+	if got := translations[" Fried Rice "]; got != "炒饭" {
+		t.Errorf("translation of padded title = %q, want 炒饭", got)
+	}
+	// 带开场白的长文本译文被拒绝，保留原文且不写入缓存
+	if got := translations[instructions]; got != instructions {
+		t.Errorf("translation of instructions = %q, want original kept", got)
+	}
+	if cached := s.getFromCache(s.textCacheKey(instructions, "zh-CN")); cached != "" {
+		t.Errorf("rejected translation cached: %q", cached)
+	}
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"recipe-agent/internal/metrics"
+)
+
+// 数据源错误码，用于向客户端说明结果降级的原因
+const (
+	SourceErrorNotConfigured   = "not_configured"   // 未配置API密钥
+	SourceErrorLowConfidence   = "low_confidence"   // 翻译置信度过低，跳过查询
+	SourceErrorRequestFailed   = "request_failed"   // 网络请求失败
+	SourceErrorUpstreamStatus  = "upstream_status"  // 上游返回非200状态码
+	SourceErrorQuotaExceeded   = "quota_exceeded"   // 上游调用额度用尽（402/429）
+	SourceErrorInvalidResponse = "invalid_response" // 上游响应无法解析
+	SourceErrorEmptyResponse   = "empty_response"   // 上游返回空结果
+	SourceErrorTimeout         = "timeout"          // 超过数据源的超时时间
+	SourceErrorCircuitOpen     = "circuit_open"     // 上游连续失败，熔断期间不发起调用
+	SourceErrorUnknown         = "unknown"
+)
+
+// SourceError 带错误码的数据源调用错误
+type SourceError struct {
+	Code string
+	Err  error
+}
+
+// newSourceError 创建数据源错误
+func newSourceError(code, format string, args ...interface{}) *SourceError {
+	return &SourceError{Code: code, Err: fmt.Errorf(format, args...)}
+}
+
+// Error 实现error接口
+func (e *SourceError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap 返回原始错误
+func (e *SourceError) Unwrap() error {
+	return e.Err
+}
+
+// upstreamStatusError 根据上游状态码创建数据源错误，额度用尽单独区分
+func upstreamStatusError(status int, body []byte) *SourceError {
+	code := SourceErrorUpstreamStatus
+	if status == http.StatusPaymentRequired || status == http.StatusTooManyRequests {
+		code = SourceErrorQuotaExceeded
+	}
+	return newSourceError(code, "API返回错误: %d - %s", status, string(body))
+}
+
+// observeUpstream 记录一次上游调用的耗时和错误码，在发起调用的函数中 defer 使用
+func observeUpstream(provider string, start time.Time, err *error) {
+	code := SourceErrorCode(*err)
+	var netErr net.Error
+	switch {
+	case code == "":
+	case errors.Is(*err, context.Canceled):
+		code = "cancelled"
+	case errors.Is(*err, context.DeadlineExceeded), errors.As(*err, &netErr) && netErr.Timeout():
+		code = SourceErrorTimeout
+	}
+	metrics.ObserveUpstream(provider, time.Since(start), code)
+}
+
+// SourceErrorCode 返回错误对应的数据源错误码，无错误时返回空字符串
+func SourceErrorCode(err error) string {
+	if err == nil {
+		return ""
+	}
+	var sourceErr *SourceError
+	if errors.As(err, &sourceErr) {
+		return sourceErr.Code
+	}
+	return SourceErrorUnknown
+}
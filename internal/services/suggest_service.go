@@ -0,0 +1,590 @@
+package services
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mozillazg/go-pinyin"
+
+	"recipe-agent/internal/config"
+)
+
+// 建议类型
+const (
+	SuggestTypeIngredient = "ingredient"
+	SuggestTypeDish       = "dish"
+)
+
+// 建议来源
+const (
+	SuggestSourceOntology = "ontology"
+	SuggestSourceDish     = "dish"
+	SuggestSourceRecipe   = "recipe"
+	SuggestSourceHistory  = "history"
+)
+
+// 匹配方式权重，名称前缀匹配优先于拼音匹配
+const (
+	matchInitials = iota + 1
+	matchPinyin
+	matchName
+)
+
+// maxSuggestCandidates 单次查询从前缀树中收集的最大候选词条数
+const maxSuggestCandidates = 500
+
+// maxPinyinVariants 多音字组合出的拼音读法上限
+const maxPinyinVariants = 16
+
+// 查询历史词条的限制：未收录的用户输入只作为历史词条加入索引，长度、数量和保留时间都有上限
+const (
+	maxHistoryTermRunes = 20
+	maxHistoryTerms     = 1000
+	historyTermTTL      = 7 * 24 * time.Hour
+)
+
+// 食谱词条的限制：返回给用户的食谱标题（AI翻译结果）同样有长度、数量和保留时间上限
+const (
+	maxRecipeTermRunes = 40
+	maxRecipeTerms     = 1000
+	recipeTermTTL      = 7 * 24 * time.Hour
+)
+
+// transientTermLimits 会过期的词条来源及其数量上限和保留时间，其他来源的词条常驻索引
+var transientTermLimits = map[string]struct {
+	max int
+	ttl time.Duration
+}{
+	SuggestSourceHistory: {maxHistoryTerms, historyTermTTL},
+	SuggestSourceRecipe:  {maxRecipeTerms, recipeTermTTL},
+}
+
+// Suggestion 自动补全建议
+type Suggestion struct {
+	Text       string `json:"text"`
+	Type       string `json:"type"`
+	Source     string `json:"source"`
+	Matched    string `json:"matched,omitempty"`
+	Category   string `json:"category,omitempty"`
+	Popularity int    `json:"popularity"`
+}
+
+// suggestTerm 建议词条
+type suggestTerm struct {
+	text       string
+	names      []string
+	source     string
+	category   string
+	popularity int
+	// lastQueried 历史词条最近一次被查询、食谱词条最近一次被返回的时间，超过保留时间后不再展示并在下次加入同来源词条时移除
+	lastQueried time.Time
+}
+
+// expired 历史词条或食谱词条是否已过期
+func (t *suggestTerm) expired(now time.Time) bool {
+	limit, transient := transientTermLimits[t.source]
+	return transient && now.Sub(t.lastQueried) > limit.ttl
+}
+
+// suggestTarget 前缀树终点指向的词条及命中的别名
+type suggestTarget struct {
+	text   string
+	alias  string
+	weight int
+	// exact 键与查询完全相同，只在收集结果中设置
+	exact bool
+}
+
+// 排序时的匹配级别：完全匹配 > 规范名称前缀 > 别名前缀 > 规范名称的拼音 > 别名的拼音，
+// 同级别内再按热度排序
+const (
+	rankAliasPinyin = iota
+	rankPrimaryPinyin
+	rankAlias
+	rankPrimary
+	rankExact
+)
+
+// rank 返回命中的匹配级别
+func (t suggestTarget) rank() int {
+	primary := t.alias == t.text
+	switch {
+	case t.exact:
+		return rankExact
+	case t.weight == matchName && primary:
+		return rankPrimary
+	case t.weight == matchName:
+		return rankAlias
+	case primary:
+		return rankPrimaryPinyin
+	}
+	return rankAliasPinyin
+}
+
+// better 同一词条的两个命中中是否应保留t
+func (t suggestTarget) better(other suggestTarget) bool {
+	if t.rank() != other.rank() {
+		return t.rank() > other.rank()
+	}
+	if t.weight != other.weight {
+		return t.weight > other.weight
+	}
+	// 权重相同时优先展示命中规范名称本身的结果
+	return t.alias == t.text && other.alias != other.text
+}
+
+// suggestTrieNode 前缀树节点
+type suggestTrieNode struct {
+	children map[rune]*suggestTrieNode
+	targets  []suggestTarget
+}
+
+// suggestIndex 单一类型的建议索引
+type suggestIndex struct {
+	root  *suggestTrieNode
+	terms map[string]*suggestTerm
+	// transient 各来源会过期的词条数
+	transient map[string]int
+}
+
+// SuggestService 基于内存前缀树的自动补全服务，支持前缀、拼音首字母和全拼匹配
+type SuggestService struct {
+	indexes  map[string]*suggestIndex
+	ontology *IngredientOntology
+	mutex    sync.RWMutex
+}
+
+// NewSuggestService 创建自动补全服务实例，索引食材本体、菜名数据和翻译记忆中的菜名
+func NewSuggestService(cfg config.Data, ontology *IngredientOntology, memory *TranslationMemory) *SuggestService {
+	s := &SuggestService{
+		indexes: map[string]*suggestIndex{
+			SuggestTypeIngredient: newSuggestIndex(),
+			SuggestTypeDish:       newSuggestIndex(),
+		},
+		ontology: ontology,
+	}
+
+	for _, ingredient := range ontology.Ingredients() {
+		names := append([]string{ingredient.Chinese, ingredient.English}, ingredient.Synonyms...)
+		s.add(SuggestTypeIngredient, ingredient.Chinese, names, SuggestSourceOntology, ingredient.Category)
+	}
+
+	for _, dish := range loadDishNames(cfg.DishesFile) {
+		s.add(SuggestTypeDish, dish, []string{dish}, SuggestSourceDish, "")
+	}
+	for _, entry := range memory.List(TranslationKindDish) {
+		s.add(SuggestTypeDish, entry.Source, []string{entry.Source, entry.Translation}, SuggestSourceDish, "")
+	}
+
+	return s
+}
+
+// loadDishNames 加载常见菜名数据文件
+func loadDishNames(path string) []string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		slog.Warn("读取菜名数据文件失败", "error", err)
+		return nil
+	}
+
+	var dishes []string
+	if err := json.Unmarshal(data, &dishes); err != nil {
+		slog.Warn("解析菜名数据文件失败", "error", err)
+		return nil
+	}
+	return dishes
+}
+
+// newSuggestIndex 创建空索引
+func newSuggestIndex() *suggestIndex {
+	return &suggestIndex{
+		root:      &suggestTrieNode{children: make(map[rune]*suggestTrieNode)},
+		terms:     make(map[string]*suggestTerm),
+		transient: make(map[string]int),
+	}
+}
+
+// Suggest 返回与查询匹配的建议，按匹配级别、热度、匹配方式和长度排序
+func (s *SuggestService) Suggest(suggestType, query string, limit int) []Suggestion {
+	query = normalizeSuggestQuery(query)
+	if query == "" {
+		return []Suggestion{}
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	index, exists := s.indexes[suggestType]
+	if !exists {
+		return []Suggestion{}
+	}
+
+	// 收集候选，同一词条保留权重最高的命中
+	now := time.Now()
+	best := make(map[string]suggestTarget)
+	for _, target := range index.collect(query) {
+		if index.terms[target.text].expired(now) {
+			continue
+		}
+		if current, seen := best[target.text]; !seen || target.better(current) {
+			best[target.text] = target
+		}
+	}
+
+	type candidate struct {
+		suggestion Suggestion
+		rank       int
+		weight     int
+	}
+	candidates := make([]candidate, 0, len(best))
+	for text, target := range best {
+		term := index.terms[text]
+		suggestion := Suggestion{
+			Text:       term.text,
+			Type:       suggestType,
+			Source:     term.source,
+			Category:   term.category,
+			Popularity: term.popularity,
+		}
+		if target.alias != term.text {
+			suggestion.Matched = target.alias
+		}
+		candidates = append(candidates, candidate{suggestion: suggestion, rank: target.rank(), weight: target.weight})
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.rank != b.rank {
+			return a.rank > b.rank
+		}
+		if a.suggestion.Popularity != b.suggestion.Popularity {
+			return a.suggestion.Popularity > b.suggestion.Popularity
+		}
+		if a.weight != b.weight {
+			return a.weight > b.weight
+		}
+		la, lb := utf8.RuneCountInString(a.suggestion.Text), utf8.RuneCountInString(b.suggestion.Text)
+		if la != lb {
+			return la < lb
+		}
+		return a.suggestion.Text < b.suggestion.Text
+	})
+
+	if limit > 0 && len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	suggestions := make([]Suggestion, len(candidates))
+	for i, c := range candidates {
+		suggestions[i] = c.suggestion
+	}
+	return suggestions
+}
+
+// RecordQuery 记录用户查询。食材先按本体规范为中文名，已收录的词条提升热度；
+// 未收录的输入规范化后作为历史词条加入索引，超长或不含文字的输入忽略，
+// 历史词条数量达到上限时移除过期和最久未查询的词条
+func (s *SuggestService) RecordQuery(suggestType, text string) {
+	if suggestType == SuggestTypeIngredient {
+		text = s.ontology.Canonicalize(text)
+	}
+	text = normalizeHistoryTerm(text)
+	if text == "" {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	index, exists := s.indexes[suggestType]
+	if !exists {
+		return
+	}
+	now := time.Now()
+	term, known := index.terms[text]
+	if !known {
+		if utf8.RuneCountInString(text) > maxHistoryTermRunes {
+			return
+		}
+		index.prune(SuggestSourceHistory, now)
+		index.insertTerm(text, []string{text}, SuggestSourceHistory, "")
+		term = index.terms[text]
+	}
+	if _, transient := transientTermLimits[term.source]; transient {
+		if term.expired(now) {
+			term.popularity = 0
+		}
+		term.lastQueried = now
+	}
+	term.popularity++
+}
+
+// normalizeHistoryTerm 统一查询词格式：全角转半角、合并空白，不含字母或汉字的输入返回空
+func normalizeHistoryTerm(text string) string {
+	text = strings.Join(strings.Fields(foldFullWidth(text)), " ")
+	if strings.IndexFunc(text, unicode.IsLetter) < 0 || strings.IndexFunc(text, unicode.IsControl) >= 0 {
+		return ""
+	}
+	return text
+}
+
+// prune 来源的词条数达到上限时移除该来源过期的词条，仍未低于上限时移除最久未使用的词条，调用方需持有写锁
+func (idx *suggestIndex) prune(source string, now time.Time) {
+	limit := transientTermLimits[source]
+	if idx.transient[source] < limit.max {
+		return
+	}
+
+	var oldest *suggestTerm
+	for _, term := range idx.terms {
+		if term.source != source {
+			continue
+		}
+		if term.expired(now) {
+			idx.removeTerm(term.text)
+			continue
+		}
+		if oldest == nil || term.lastQueried.Before(oldest.lastQueried) {
+			oldest = term
+		}
+	}
+	if idx.transient[source] >= limit.max && oldest != nil {
+		idx.removeTerm(oldest.text)
+	}
+}
+
+// RecordRecipes 将返回给用户的食谱标题加入菜名索引。标题规范化后超长或不含文字的忽略，
+// 已有的食谱词条刷新保留时间，食谱词条数量达到上限时移除过期和最久未返回的词条
+func (s *SuggestService) RecordRecipes(recipes []SpoonacularRecipe) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	index := s.indexes[SuggestTypeDish]
+	for _, recipe := range recipes {
+		now := time.Now()
+		title := normalizeHistoryTerm(recipe.Title)
+		if title == "" || utf8.RuneCountInString(title) > maxRecipeTermRunes {
+			continue
+		}
+		if term, known := index.terms[title]; known {
+			if term.source == SuggestSourceRecipe {
+				term.lastQueried = now
+			}
+			continue
+		}
+
+		names := []string{title}
+		if original := normalizeHistoryTerm(recipe.OriginalTitle); original != "" && utf8.RuneCountInString(original) <= maxRecipeTermRunes {
+			names = append(names, original)
+		}
+		index.prune(SuggestSourceRecipe, now)
+		index.insertTerm(title, names, SuggestSourceRecipe, "")
+		index.terms[title].lastQueried = now
+	}
+}
+
+// add 加入词条（加锁）
+func (s *SuggestService) add(suggestType, text string, names []string, source, category string) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	index := s.indexes[suggestType]
+	if _, known := index.terms[text]; known {
+		return
+	}
+	index.insertTerm(text, names, source, category)
+}
+
+// insertTerm 将词条的名称、别名及其拼音写入前缀树，调用方需持有写锁
+func (idx *suggestIndex) insertTerm(text string, names []string, source, category string) {
+	idx.terms[text] = &suggestTerm{text: text, names: names, source: source, category: category}
+	if _, transient := transientTermLimits[source]; transient {
+		idx.transient[source]++
+	}
+	idx.eachKey(text, names, idx.insert)
+}
+
+// removeTerm 从前缀树中移除词条的所有键，调用方需持有写锁
+func (idx *suggestIndex) removeTerm(text string) {
+	term, exists := idx.terms[text]
+	if !exists {
+		return
+	}
+	delete(idx.terms, text)
+	if _, transient := transientTermLimits[term.source]; transient {
+		idx.transient[term.source]--
+	}
+	idx.eachKey(text, term.names, func(key string, _ suggestTarget) {
+		idx.remove(key, text)
+	})
+}
+
+// eachKey 对词条的名称、别名、全拼和拼音首字母逐一调用fn
+func (idx *suggestIndex) eachKey(text string, names []string, fn func(key string, target suggestTarget)) {
+	for _, name := range names {
+		key := normalizeSuggestQuery(name)
+		if key == "" {
+			continue
+		}
+		fn(key, suggestTarget{text: text, alias: name, weight: matchName})
+
+		for _, syllables := range pinyinVariants(name) {
+			var initials strings.Builder
+			for _, syllable := range syllables {
+				initials.WriteByte(syllable[0])
+			}
+			fn(strings.Join(syllables, ""), suggestTarget{text: text, alias: name, weight: matchPinyin})
+			fn(initials.String(), suggestTarget{text: text, alias: name, weight: matchInitials})
+		}
+	}
+}
+
+// pinyinVariants 返回名称中汉字的拼音读法组合，多音字（如“茄”读 qie/jia）展开为多种读法
+func pinyinVariants(name string) [][]string {
+	args := pinyin.NewArgs()
+	args.Heteronym = true
+
+	variants := [][]string{{}}
+	for _, readings := range pinyin.Pinyin(name, args) {
+		readings = uniqueStrings(readings)
+		var next [][]string
+		for _, variant := range variants {
+			for _, reading := range readings {
+				if len(next) >= maxPinyinVariants {
+					break
+				}
+				combined := append(append([]string{}, variant...), reading)
+				next = append(next, combined)
+			}
+		}
+		variants = next
+	}
+
+	if len(variants) == 1 && len(variants[0]) == 0 {
+		return nil
+	}
+	return variants
+}
+
+// uniqueStrings 去除重复字符串并保持顺序
+func uniqueStrings(items []string) []string {
+	seen := make(map[string]bool, len(items))
+	var result []string
+	for _, item := range items {
+		if !seen[item] {
+			seen[item] = true
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// insert 写入单个键
+func (idx *suggestIndex) insert(key string, target suggestTarget) {
+	node := idx.root
+	for _, r := range key {
+		child, exists := node.children[r]
+		if !exists {
+			child = &suggestTrieNode{children: make(map[rune]*suggestTrieNode)}
+			node.children[r] = child
+		}
+		node = child
+	}
+	node.targets = append(node.targets, target)
+}
+
+// remove 删除键上指向text的目标，并移除不再使用的节点
+func (idx *suggestIndex) remove(key, text string) {
+	path := []*suggestTrieNode{idx.root}
+	runes := []rune(key)
+	for _, r := range runes {
+		child, exists := path[len(path)-1].children[r]
+		if !exists {
+			return
+		}
+		path = append(path, child)
+	}
+
+	node := path[len(path)-1]
+	targets := node.targets[:0]
+	for _, target := range node.targets {
+		if target.text != text {
+			targets = append(targets, target)
+		}
+	}
+	node.targets = targets
+
+	for i := len(runes) - 1; i >= 0; i-- {
+		node := path[i+1]
+		if len(node.targets) > 0 || len(node.children) > 0 {
+			break
+		}
+		delete(path[i].children, runes[i])
+	}
+}
+
+// collect 收集以query为前缀的键对应的词条。按层广度优先遍历，子节点按字符排序，
+// 较短（更接近查询）的键先被收集，结果是确定的；收集到 maxSuggestCandidates 个词条后停止
+func (idx *suggestIndex) collect(query string) []suggestTarget {
+	node := idx.root
+	for _, r := range query {
+		child, exists := node.children[r]
+		if !exists {
+			return nil
+		}
+		node = child
+	}
+
+	var targets []suggestTarget
+	for _, target := range node.targets {
+		target.exact = true
+		targets = append(targets, target)
+	}
+
+	seen := make(map[string]bool)
+	level := []*suggestTrieNode{node}
+	for len(level) > 0 && len(seen) < maxSuggestCandidates {
+		var next []*suggestTrieNode
+		for _, current := range level {
+			if current != node {
+				targets = append(targets, current.targets...)
+			}
+			for _, target := range current.targets {
+				seen[target.text] = true
+			}
+
+			runes := make([]rune, 0, len(current.children))
+			for r := range current.children {
+				runes = append(runes, r)
+			}
+			sort.Slice(runes, func(i, j int) bool { return runes[i] < runes[j] })
+			for _, r := range runes {
+				next = append(next, current.children[r])
+			}
+		}
+		level = next
+	}
+	return targets
+}
+
+// normalizeSuggestQuery 统一查询格式：转小写、全角转半角、去除空白和拼音分隔符
+func normalizeSuggestQuery(query string) string {
+	query = strings.ToLower(foldFullWidth(query))
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '\'' || r == '\t' {
+			return -1
+		}
+		return r
+	}, query)
+}
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"recipe-agent/internal/config"
+)
+
+// newTestSuggestService 使用仓库中的食材本体、菜名库和术语表创建自动补全服务
+func newTestSuggestService(t *testing.T) *SuggestService {
+	t.Helper()
+	data := config.Data{
+		IngredientsFile: filepath.Join("..", "..", "data", "ingredients.json"),
+		DishesFile:      filepath.Join("..", "..", "data", "dishes.json"),
+	}
+	memory := NewTranslationMemory(config.Translation{
+		GlossaryFile: filepath.Join("..", "..", "data", "glossary.json"),
+		MemoryFile:   filepath.Join(t.TempDir(), "memory.json"),
+	})
+	return NewSuggestService(data, NewIngredientOntology(data), memory)
+}
+
+// suggestTexts 返回建议的文本列表
+func suggestTexts(suggestions []Suggestion) []string {
+	texts := make([]string, len(suggestions))
+	for i, suggestion := range suggestions {
+		texts[i] = suggestion.Text
+	}
+	return texts
+}
+
+func TestRecordQueryKnownTerms(t *testing.T) {
+	s := newTestSuggestService(t)
+
+	// 别名按本体规范为中文名，只提升已收录词条的热度
+	s.RecordQuery(SuggestTypeIngredient, "番茄")
+	s.RecordQuery(SuggestTypeIngredient, " 西红柿 ")
+
+	index := s.indexes[SuggestTypeIngredient]
+	if got := index.terms["西红柿"].popularity; got != 2 {
+		t.Errorf("popularity of 西红柿 = %d, want 2", got)
+	}
+	if _, exists := index.terms["番茄"]; exists {
+		t.Error("alias 番茄 was added as a separate term")
+	}
+	if index.transient[SuggestSourceHistory] != 0 {
+		t.Errorf("history terms = %d, want 0", index.transient[SuggestSourceHistory])
+	}
+}
+
+func TestRecordQueryHistoryTerms(t *testing.T) {
+	s := newTestSuggestService(t)
+	index := s.indexes[SuggestTypeIngredient]
+
+	s.RecordQuery(SuggestTypeIngredient, "龙　虾 尾")
+	term, exists := index.terms["龙 虾 尾"]
+	if !exists {
+		t.Fatalf("normalized history term missing, terms with 龙: %v", suggestTexts(s.Suggest(SuggestTypeIngredient, "龙", 10)))
+	}
+	if term.source != SuggestSourceHistory || term.popularity != 1 {
+		t.Errorf("term = %+v, want history term with popularity 1", term)
+	}
+
+	for _, text := range []string{"", "   ", "12345", "!!!", strings.Repeat("很长的输入", 5), "a\x00b"} {
+		s.RecordQuery(SuggestTypeIngredient, text)
+	}
+	if index.transient[SuggestSourceHistory] != 1 {
+		t.Errorf("history terms = %d, want 1 after rejected inputs", index.transient[SuggestSourceHistory])
+	}
+}
+
+func TestRecordQueryHistoryAgesOut(t *testing.T) {
+	s := newTestSuggestService(t)
+	index := s.indexes[SuggestTypeDish]
+
+	s.RecordQuery(SuggestTypeDish, "zzqq special")
+	if got := suggestTexts(s.Suggest(SuggestTypeDish, "zzqq", 5)); len(got) != 1 {
+		t.Fatalf("Suggest(zzqq) = %v, want the history term", got)
+	}
+
+	index.terms["zzqq special"].lastQueried = time.Now().Add(-historyTermTTL - time.Hour)
+	if got := s.Suggest(SuggestTypeDish, "zzqq", 5); len(got) != 0 {
+		t.Errorf("Suggest(zzqq) = %v, want expired history term hidden", suggestTexts(got))
+	}
+
+	// 过期后再次查询重新计算热度
+	s.RecordQuery(SuggestTypeDish, "zzqq special")
+	if got := index.terms["zzqq special"].popularity; got != 1 {
+		t.Errorf("popularity after expiry = %d, want 1", got)
+	}
+}
+
+func TestRecordQueryHistoryCap(t *testing.T) {
+	s := newTestSuggestService(t)
+	index := s.indexes[SuggestTypeDish]
+
+	for i := 0; i < maxHistoryTerms+10; i++ {
+		s.RecordQuery(SuggestTypeDish, fmt.Sprintf("zzqq %d", i))
+	}
+	if index.transient[SuggestSourceHistory] != maxHistoryTerms {
+		t.Errorf("history terms = %d, want %d", index.transient[SuggestSourceHistory], maxHistoryTerms)
+	}
+	// 最早加入的词条被移除，前缀树中也不再有对应的键
+	if _, exists := index.terms["zzqq 0"]; exists {
+		t.Error("oldest history term was not evicted")
+	}
+	for _, target := range index.collect("zzqq0") {
+		if target.text == "zzqq 0" {
+			t.Error("evicted term still reachable in the trie")
+		}
+	}
+	if _, exists := index.terms[fmt.Sprintf("zzqq %d", maxHistoryTerms+9)]; !exists {
+		t.Error("newest history term missing")
+	}
+}
+
+func TestSuggestRanking(t *testing.T) {
+	s := newTestSuggestService(t)
+	for i := 0; i < 5; i++ {
+		s.RecordQuery(SuggestTypeIngredient, "土鸡蛋")
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		// 完全匹配（别名“鸡”）排在规范名称前缀之前，同级别内按热度排序
+		{"鸡", []string{"鸡肉", "鸡蛋", "鸡翅"}},
+		// 规范名称前缀排在热度更高的别名前缀之前
+		{"土", []string{"土豆", "鸡蛋"}},
+		{"xhs", []string{"西红柿"}},
+		{"fanqie", []string{"西红柿"}},
+	}
+	for _, tt := range tests {
+		got := suggestTexts(s.Suggest(SuggestTypeIngredient, tt.query, 8))
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("Suggest(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestSuggestSingleLetterIncludesPrimaryPinyin(t *testing.T) {
+	s := newTestSuggestService(t)
+
+	first := suggestTexts(s.Suggest(SuggestTypeIngredient, "x", 8))
+	if first[0] != "虾" {
+		t.Errorf("Suggest(x)[0] = %q, want exact initials match 虾", first[0])
+	}
+	found := false
+	for _, text := range first {
+		found = found || text == "西红柿"
+	}
+	if !found {
+		t.Errorf("Suggest(x) = %v, want 西红柿 among primary pinyin matches", first)
+	}
+
+	// 遍历顺序确定，多次查询结果相同
+	for i := 0; i < 20; i++ {
+		if got := suggestTexts(s.Suggest(SuggestTypeIngredient, "x", 8)); strings.Join(got, ",") != strings.Join(first, ",") {
+			t.Fatalf("Suggest(x) = %v, want stable %v", got, first)
+		}
+	}
+}
+
+func TestRecordRecipesCapAndTTL(t *testing.T) {
+	s := newTestSuggestService(t)
+	index := s.indexes[SuggestTypeDish]
+
+	recipes := make([]SpoonacularRecipe, 0, maxRecipeTerms+10)
+	for i := 0; i < maxRecipeTerms+10; i++ {
+		recipes = append(recipes, SpoonacularRecipe{Title: fmt.Sprintf("zzqq 食谱 %d", i), OriginalTitle: fmt.Sprintf("zzqq recipe %d", i)})
+	}
+	recipes = append(recipes, SpoonacularRecipe{Title: strings.Repeat("很长的食谱标题", 10)}, SpoonacularRecipe{Title: "   "})
+	s.RecordRecipes(recipes)
+
+	if got := index.transient[SuggestSourceRecipe]; got != maxRecipeTerms {
+		t.Errorf("recipe terms = %d, want %d", got, maxRecipeTerms)
+	}
+	if _, exists := index.terms["zzqq 食谱 0"]; exists {
+		t.Error("oldest recipe term was not evicted")
+	}
+	if index.transient[SuggestSourceHistory] != 0 {
+		t.Errorf("history terms = %d, want recipe terms counted separately", index.transient[SuggestSourceHistory])
+	}
+
+	// 过期的食谱词条不再展示
+	newest := fmt.Sprintf("zzqq 食谱 %d", maxRecipeTerms+9)
+	index.terms[newest].lastQueried = time.Now().Add(-recipeTermTTL - time.Hour)
+	for _, text := range suggestTexts(s.Suggest(SuggestTypeDish, newest, 5)) {
+		if text == newest {
+			t.Errorf("Suggest(%q) returned expired recipe term", newest)
+		}
+	}
+}
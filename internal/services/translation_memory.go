@@ -0,0 +1,400 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"recipe-agent/internal/config"
+)
+
+// 翻译条目类型
+const (
+	TranslationKindIngredient = "ingredient"
+	TranslationKindDish       = "dish"
+)
+
+// 翻译条目来源
+const (
+	TranslationOriginGlossary = "glossary"
+	TranslationOriginAI       = "ai"
+	TranslationOriginFallback = "fallback"
+	TranslationOriginHuman    = "human"
+)
+
+// ErrTranslationNotFound 翻译条目不存在
+var ErrTranslationNotFound = errors.New("翻译条目不存在")
+
+// TranslationEntry 翻译记忆条目
+type TranslationEntry struct {
+	Source      string    `json:"source"`
+	Kind        string    `json:"kind"`
+	Translation string    `json:"translation"`
+	Origin      string    `json:"origin"`
+	Approved    bool      `json:"approved"`
+	Confidence  float64   `json:"confidence,omitempty"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// translationMemoryRecord 翻译记忆文件中的记录，Deleted 为被删除的术语表条目的删除标记
+type translationMemoryRecord struct {
+	*TranslationEntry
+	Deleted bool `json:"deleted,omitempty"`
+}
+
+// TranslationMemory 持久化的翻译记忆，人工审核过的条目优先于AI翻译
+type TranslationMemory struct {
+	path    string
+	entries map[string]*TranslationEntry
+	// glossary 术语表中的条目键
+	glossary map[string]bool
+	// tombstones 已删除的术语表条目，写入文件后重启时不会从术语表恢复
+	tombstones map[string]*TranslationEntry
+	mutex      sync.RWMutex
+	// dirty 内存中有尚未写入文件的修改，由后台定期写入或关闭时写入
+	dirty bool
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+// NewTranslationMemory 加载术语表和翻译记忆文件，加载失败时仅记录日志；
+// 自动翻译结果按 MemoryFlushInterval 批量写入文件
+func NewTranslationMemory(cfg config.Translation) *TranslationMemory {
+	m := &TranslationMemory{
+		path:       cfg.MemoryFile,
+		entries:    make(map[string]*TranslationEntry),
+		glossary:   make(map[string]bool),
+		tombstones: make(map[string]*TranslationEntry),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+
+	if err := m.loadGlossary(cfg.GlossaryFile); err != nil {
+		slog.Warn("加载翻译术语表失败", "error", err)
+	}
+	if err := m.load(); err != nil {
+		slog.Warn("加载翻译记忆失败", "error", err)
+	}
+	slog.Info("已加载翻译记忆", "entries", len(m.entries))
+
+	interval := cfg.MemoryFlushInterval.Duration
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	go m.flushLoop(interval)
+
+	return m
+}
+
+// translationMemoryKey 生成翻译记忆键
+func translationMemoryKey(kind, source string) string {
+	return kind + ":" + normalizeName(source)
+}
+
+// loadGlossary 加载术语表，术语表条目视为已审核
+func (m *TranslationMemory) loadGlossary(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("读取术语表失败: %v", err)
+	}
+
+	var glossary []TranslationEntry
+	if err := json.Unmarshal(data, &glossary); err != nil {
+		return fmt.Errorf("解析术语表失败: %v", err)
+	}
+
+	for i := range glossary {
+		entry := glossary[i]
+		if entry.Source == "" || entry.Translation == "" {
+			continue
+		}
+		if entry.Kind == "" {
+			entry.Kind = TranslationKindIngredient
+		}
+		entry.Origin = TranslationOriginGlossary
+		entry.Approved = true
+		key := translationMemoryKey(entry.Kind, entry.Source)
+		m.entries[key] = &entry
+		m.glossary[key] = true
+	}
+	return nil
+}
+
+// load 加载持久化的翻译记忆，同名条目覆盖术语表，删除标记移除对应的术语表条目
+func (m *TranslationMemory) load() error {
+	data, err := os.ReadFile(m.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("读取翻译记忆文件失败: %v", err)
+	}
+
+	var records []translationMemoryRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return fmt.Errorf("解析翻译记忆文件失败: %v", err)
+	}
+
+	for _, record := range records {
+		if record.TranslationEntry == nil {
+			continue
+		}
+		key := translationMemoryKey(record.Kind, record.Source)
+		if record.Deleted {
+			delete(m.entries, key)
+			m.tombstones[key] = record.TranslationEntry
+			continue
+		}
+		m.entries[key] = record.TranslationEntry
+	}
+	return nil
+}
+
+// flushLoop 定期将自动翻译结果写入文件，直到 Close
+func (m *TranslationMemory) flushLoop(interval time.Duration) {
+	defer close(m.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+			if err := m.Flush(); err != nil {
+				slog.Error("保存翻译记忆失败", "error", err)
+			}
+		}
+	}
+}
+
+// Close 停止后台写入并写入剩余的修改，服务关闭时调用
+func (m *TranslationMemory) Close() error {
+	select {
+	case <-m.stop:
+	default:
+		close(m.stop)
+	}
+	<-m.done
+	return m.Flush()
+}
+
+// Flush 将尚未写入文件的修改写入翻译记忆文件
+func (m *TranslationMemory) Flush() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if !m.dirty {
+		return nil
+	}
+	return m.save()
+}
+
+// save 将非术语表条目写入翻译记忆文件，失败时标记为待写入，调用方需持有写锁
+func (m *TranslationMemory) save() error {
+	if err := m.write(); err != nil {
+		m.dirty = true
+		return err
+	}
+	m.dirty = false
+	return nil
+}
+
+// write 写入翻译记忆文件，调用方需持有写锁
+func (m *TranslationMemory) write() error {
+	entries := []*TranslationEntry{}
+	for _, entry := range m.entries {
+		if entry.Origin != TranslationOriginGlossary {
+			entries = append(entries, entry)
+		}
+	}
+	sortTranslationEntries(entries)
+
+	tombstones := make([]*TranslationEntry, 0, len(m.tombstones))
+	for _, entry := range m.tombstones {
+		tombstones = append(tombstones, entry)
+	}
+	sortTranslationEntries(tombstones)
+
+	records := make([]translationMemoryRecord, 0, len(entries)+len(tombstones))
+	for _, entry := range entries {
+		records = append(records, translationMemoryRecord{TranslationEntry: entry})
+	}
+	for _, entry := range tombstones {
+		records = append(records, translationMemoryRecord{TranslationEntry: entry, Deleted: true})
+	}
+
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化翻译记忆失败: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(m.path), 0o755); err != nil {
+		return fmt.Errorf("创建翻译记忆目录失败: %v", err)
+	}
+
+	// 先写临时文件再重命名，避免写入中断导致文件损坏
+	tmpPath := m.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o644); err != nil {
+		return fmt.Errorf("写入翻译记忆文件失败: %v", err)
+	}
+	if err := os.Rename(tmpPath, m.path); err != nil {
+		return fmt.Errorf("写入翻译记忆文件失败: %v", err)
+	}
+	return nil
+}
+
+// Get 获取翻译记忆条目
+func (m *TranslationMemory) Get(kind, source string) (*TranslationEntry, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	entry, exists := m.entries[translationMemoryKey(kind, source)]
+	if !exists {
+		return nil, false
+	}
+	copied := *entry
+	return &copied, true
+}
+
+// Record 记录自动翻译结果及其置信度，不会覆盖已审核的条目。
+// 自动翻译很频繁，只标记为待写入，由后台定期写入文件
+func (m *TranslationMemory) Record(kind, source, translation, origin string, confidence float64) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	key := translationMemoryKey(kind, source)
+	if existing, exists := m.entries[key]; exists && existing.Approved {
+		return
+	}
+
+	m.entries[key] = &TranslationEntry{
+		Source:      source,
+		Kind:        kind,
+		Translation: translation,
+		Origin:      origin,
+		Confidence:  confidence,
+		UpdatedAt:   time.Now(),
+	}
+	delete(m.tombstones, key)
+	m.dirty = true
+}
+
+// Correct 人工修正翻译，修正后的条目自动视为已审核。写入失败时恢复原有条目
+func (m *TranslationMemory) Correct(kind, source, translation string) (*TranslationEntry, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	entry := &TranslationEntry{
+		Source:      source,
+		Kind:        kind,
+		Translation: translation,
+		Origin:      TranslationOriginHuman,
+		Approved:    true,
+		UpdatedAt:   time.Now(),
+	}
+	key := translationMemoryKey(kind, source)
+	previous, existed := m.entries[key]
+	tombstone, deleted := m.tombstones[key]
+	m.entries[key] = entry
+	delete(m.tombstones, key)
+	if err := m.save(); err != nil {
+		if existed {
+			m.entries[key] = previous
+		} else {
+			delete(m.entries, key)
+		}
+		if deleted {
+			m.tombstones[key] = tombstone
+		}
+		return nil, err
+	}
+
+	copied := *entry
+	return &copied, nil
+}
+
+// Approve 审核通过已有的翻译条目。写入失败时条目保持原状
+func (m *TranslationMemory) Approve(kind, source string) (*TranslationEntry, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	key := translationMemoryKey(kind, source)
+	previous, exists := m.entries[key]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrTranslationNotFound, source)
+	}
+
+	entry := *previous
+	entry.Approved = true
+	entry.UpdatedAt = time.Now()
+	// 术语表条目被审核后转为人工条目，以便持久化
+	if entry.Origin == TranslationOriginGlossary {
+		entry.Origin = TranslationOriginHuman
+	}
+	m.entries[key] = &entry
+	if err := m.save(); err != nil {
+		m.entries[key] = previous
+		return nil, err
+	}
+
+	copied := entry
+	return &copied, nil
+}
+
+// Delete 删除翻译条目。术语表中的条目记录删除标记，否则重启后会从术语表恢复。写入失败时恢复条目
+func (m *TranslationMemory) Delete(kind, source string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	key := translationMemoryKey(kind, source)
+	previous, exists := m.entries[key]
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrTranslationNotFound, source)
+	}
+
+	delete(m.entries, key)
+	if m.glossary[key] {
+		m.tombstones[key] = &TranslationEntry{Source: source, Kind: kind, UpdatedAt: time.Now()}
+	}
+	if err := m.save(); err != nil {
+		m.entries[key] = previous
+		delete(m.tombstones, key)
+		return err
+	}
+	return nil
+}
+
+// List 列出翻译条目，kind为空时返回全部类型
+func (m *TranslationMemory) List(kind string) []*TranslationEntry {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	var entries []*TranslationEntry
+	for _, entry := range m.entries {
+		if kind != "" && entry.Kind != kind {
+			continue
+		}
+		copied := *entry
+		entries = append(entries, &copied)
+	}
+	sortTranslationEntries(entries)
+	return entries
+}
+
+// sortTranslationEntries 按类型和原文排序
+func sortTranslationEntries(entries []*TranslationEntry) {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Kind != entries[j].Kind {
+			return entries[i].Kind < entries[j].Kind
+		}
+		return strings.Compare(entries[i].Source, entries[j].Source) < 0
+	})
+}
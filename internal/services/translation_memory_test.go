@@ -0,0 +1,139 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"recipe-agent/internal/config"
+)
+
+// newTestTranslationMemory 使用临时术语表和翻译记忆文件创建翻译记忆
+func newTestTranslationMemory(t *testing.T, dir string, flushInterval time.Duration) *TranslationMemory {
+	t.Helper()
+	glossary := filepath.Join(dir, "glossary.json")
+	if _, err := os.Stat(glossary); os.IsNotExist(err) {
+		data := `[{"source": "西红柿", "translation": "tomato"}, {"source": "鸡蛋", "translation": "egg"}]`
+		if err := os.WriteFile(glossary, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	memory := NewTranslationMemory(config.Translation{
+		GlossaryFile:        glossary,
+		MemoryFile:          filepath.Join(dir, "memory.json"),
+		MemoryFlushInterval: config.Duration{Duration: flushInterval},
+	})
+	t.Cleanup(func() { memory.Close() })
+	return memory
+}
+
+func TestTranslationMemoryRecordIsBatched(t *testing.T) {
+	dir := t.TempDir()
+	memory := newTestTranslationMemory(t, dir, time.Hour)
+
+	memory.Record(TranslationKindDish, "宫保鸡丁", "Kung Pao Chicken", TranslationOriginAI, 0.9)
+	if _, err := os.Stat(filepath.Join(dir, "memory.json")); !os.IsNotExist(err) {
+		t.Fatalf("memory file written on Record, stat error = %v", err)
+	}
+
+	if err := memory.Close(); err != nil {
+		t.Fatalf("Close error: %v", err)
+	}
+	reloaded := newTestTranslationMemory(t, dir, time.Hour)
+	entry, exists := reloaded.Get(TranslationKindDish, "宫保鸡丁")
+	if !exists || entry.Translation != "Kung Pao Chicken" {
+		t.Errorf("reloaded entry = %+v, want Kung Pao Chicken", entry)
+	}
+}
+
+func TestTranslationMemoryFlushesOnTicker(t *testing.T) {
+	dir := t.TempDir()
+	memory := newTestTranslationMemory(t, dir, 10*time.Millisecond)
+
+	memory.Record(TranslationKindDish, "麻婆豆腐", "Mapo Tofu", TranslationOriginAI, 0.9)
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, err := os.Stat(filepath.Join(dir, "memory.json")); err == nil {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("memory file not written by the background flush")
+}
+
+func TestTranslationMemoryDeleteGlossaryEntrySurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	memory := newTestTranslationMemory(t, dir, time.Hour)
+
+	if err := memory.Delete(TranslationKindIngredient, "西红柿"); err != nil {
+		t.Fatalf("Delete error: %v", err)
+	}
+	if _, exists := memory.Get(TranslationKindIngredient, "西红柿"); exists {
+		t.Fatal("glossary entry still present after Delete")
+	}
+	memory.Close()
+
+	reloaded := newTestTranslationMemory(t, dir, time.Hour)
+	if entry, exists := reloaded.Get(TranslationKindIngredient, "西红柿"); exists {
+		t.Fatalf("deleted glossary entry restored after restart: %+v", entry)
+	}
+	if _, exists := reloaded.Get(TranslationKindIngredient, "鸡蛋"); !exists {
+		t.Error("other glossary entry missing after restart")
+	}
+	for _, entry := range reloaded.List("") {
+		if entry.Source == "西红柿" {
+			t.Errorf("List returned deleted entry %+v", entry)
+		}
+	}
+
+	// 重新翻译后删除标记失效
+	reloaded.Record(TranslationKindIngredient, "西红柿", "tomatoes", TranslationOriginAI, 0.8)
+	reloaded.Close()
+	again := newTestTranslationMemory(t, dir, time.Hour)
+	if entry, exists := again.Get(TranslationKindIngredient, "西红柿"); !exists || entry.Translation != "tomatoes" {
+		t.Errorf("entry after re-translation = %+v, want tomatoes", entry)
+	}
+}
+
+func TestTranslationMemoryRollsBackOnWriteFailure(t *testing.T) {
+	dir := t.TempDir()
+	memory := newTestTranslationMemory(t, dir, time.Hour)
+	memory.Record(TranslationKindDish, "宫保鸡丁", "Kung Pao Chicken", TranslationOriginAI, 0.9)
+
+	// 父路径是普通文件，写入必然失败
+	blocked := filepath.Join(dir, "blocked")
+	if err := os.WriteFile(blocked, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	memory.path = filepath.Join(blocked, "memory.json")
+
+	if _, err := memory.Approve(TranslationKindDish, "宫保鸡丁"); err == nil {
+		t.Fatal("Approve succeeded with an unwritable memory file")
+	}
+	if entry, _ := memory.Get(TranslationKindDish, "宫保鸡丁"); entry.Approved {
+		t.Errorf("entry approved after failed write: %+v", entry)
+	}
+
+	if _, err := memory.Correct(TranslationKindDish, "宫保鸡丁", "Gong Bao Chicken"); err == nil {
+		t.Fatal("Correct succeeded with an unwritable memory file")
+	}
+	if entry, _ := memory.Get(TranslationKindDish, "宫保鸡丁"); entry.Translation != "Kung Pao Chicken" || entry.Origin != TranslationOriginAI {
+		t.Errorf("entry changed after failed write: %+v", entry)
+	}
+
+	if err := memory.Delete(TranslationKindIngredient, "西红柿"); err == nil {
+		t.Fatal("Delete succeeded with an unwritable memory file")
+	}
+	if _, exists := memory.Get(TranslationKindIngredient, "西红柿"); !exists {
+		t.Error("glossary entry removed after failed write")
+	}
+	if len(memory.tombstones) != 0 {
+		t.Errorf("tombstones = %v, want none after failed write", memory.tombstones)
+	}
+
+	if err := memory.Delete(TranslationKindDish, "不存在的菜"); !errors.Is(err, ErrTranslationNotFound) {
+		t.Errorf("Delete(missing) error = %v, want ErrTranslationNotFound", err)
+	}
+}
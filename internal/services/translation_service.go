@@ -2,25 +2,46 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
-	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"recipe-agent/internal/config"
+	"recipe-agent/internal/metrics"
+	"recipe-agent/internal/tracing"
 )
 
 // TranslationService 翻译服务结构
 type TranslationService struct {
+	config       config.Translation
 	aiAPIKey     string
 	aiBaseURL    string
 	model        string
 	cache        map[string]*TranslationCacheEntry
 	cacheMutex   sync.RWMutex
-	// 保留高频常用词的静态映射作为快速查询
+	// 缓存命中和未命中次数
+	cacheHits   atomic.Uint64
+	cacheMisses atomic.Uint64
+	// 食材本体，提供规范名称和别名
+	ontology *IngredientOntology
+	// 持久化翻译记忆，包含术语表和人工审核条目
+	memory *TranslationMemory
+	// 由食材本体生成的高频词映射，作为快速查询
 	commonTranslations map[string]string
+	// 出站请求的传输层，生成客户端span并传递追踪上下文和请求ID
+	transport http.RoundTripper
+	// DeepSeek熔断器，与AI服务共用
+	breaker *Breaker
 }
 
 // TranslationCacheEntry 翻译缓存条目
@@ -30,117 +51,405 @@ type TranslationCacheEntry struct {
 }
 
 // NewTranslationService 创建翻译服务实例
-func NewTranslationService() *TranslationService {
+func NewTranslationService(deepSeek config.DeepSeek, cfg config.Translation, ontology *IngredientOntology, memory *TranslationMemory, breaker *Breaker) *TranslationService {
 	return &TranslationService{
-		aiAPIKey:  os.Getenv("DEEPSEEK_API_KEY"),
-		aiBaseURL: "https://api.deepseek.com/v1/chat/completions",
-		model:     "deepseek-chat",
-		cache:     make(map[string]*TranslationCacheEntry),
-		commonTranslations: map[string]string{
-			// 保留最常用的几个快速映射
-			"鸡蛋": "eggs",
-			"西红柿": "tomato",
-			"土豆": "potato",
-			"猪肉": "pork",
-			"牛肉": "beef",
-			"鸡肉": "chicken",
-			"鱼肉": "fish",
-			"米饭": "rice",
-			"面条": "noodles",
-			"豆腐": "tofu",
-		},
+		config:             cfg,
+		aiAPIKey:           deepSeek.APIKey,
+		aiBaseURL:          deepSeek.BaseURL,
+		model:              deepSeek.Model,
+		cache:              make(map[string]*TranslationCacheEntry),
+		ontology:           ontology,
+		memory:             memory,
+		commonTranslations: ontology.Translations(),
+		transport:          upstreamTransport(),
+		breaker:            breaker,
 	}
 }
 
 // TranslateIngredient 翻译食材名称（中译英）
-func (t *TranslationService) TranslateIngredient(ingredient string) string {
-	// 1. 检查是否为英文，直接返回
-	if !t.containsChinese(ingredient) {
-		return ingredient
-	}
+func (t *TranslationService) TranslateIngredient(ctx context.Context, ingredient string) string {
+	return t.TranslateIngredientDetailed(ctx, ingredient).Text
+}
 
-	// 2. 检查常用翻译映射（快速查询）
-	if translation, exists := t.commonTranslations[ingredient]; exists {
-		return translation
-	}
+// TranslateIngredientDetailed 翻译食材名称，返回来源和置信度
+func (t *TranslationService) TranslateIngredientDetailed(ctx context.Context, ingredient string) (translation Translation) {
+	ctx, span := startTranslationSpan(ctx, TranslationKindIngredient, ingredient)
+	defer func() { endTranslationSpan(span, translation) }()
 
-	// 3. 检查缓存
-	cacheKey := "ingredient:" + ingredient
-	if cached := t.getFromCache(cacheKey); cached != "" {
-		return cached
+	// 1. 检查食材本体、英文输入和缓存
+	if translation, ok := t.lookupIngredient(ingredient); ok {
+		return translation
 	}
 
-	// 4. 调用AI进行翻译
-	translation, err := t.translateWithAI(ingredient, "ingredient")
+	// 2. 调用AI进行翻译（校验失败时重新询问一次）
+	text, retried, err := t.translateWithAI(ctx, ingredient, TranslationKindIngredient)
 	if err != nil {
 		// AI翻译失败，尝试关键词匹配作为降级策略
-		return t.fallbackTranslation(ingredient)
+		slog.WarnContext(ctx, "食材AI翻译失败，使用降级翻译", "ingredient", ingredient, "error", err)
+		return t.recordFallback(TranslationKindIngredient, ingredient)
 	}
 
-	// 5. 缓存翻译结果
-	t.saveToCache(cacheKey, translation, 24*time.Hour) // 缓存24小时
+	// 3. 评估置信度，记录并缓存翻译结果
+	return t.recordIngredient(ingredient, text, retried)
+}
 
-	return translation
+// startTranslationSpan 为单条翻译创建span
+func startTranslationSpan(ctx context.Context, kind, text string) (context.Context, trace.Span) {
+	return tracing.Start(ctx, "translation."+kind,
+		attribute.String("translation.kind", kind),
+		attribute.String("translation.source", text),
+	)
+}
+
+// endTranslationSpan 记录翻译来源和置信度后结束span
+func endTranslationSpan(span trace.Span, translation Translation) {
+	span.SetAttributes(
+		attribute.String("translation.origin", translation.Origin),
+		attribute.Float64("translation.confidence", translation.Confidence),
+	)
+	span.End()
+}
+
+// lookupIngredient 不调用AI的快速翻译路径：已审核条目 → 食材本体 → 英文直接返回 → 翻译记忆 → 缓存
+func (t *TranslationService) lookupIngredient(ingredient string) (Translation, bool) {
+	source := t.ontology.Canonicalize(ingredient)
+	entry, remembered := t.memory.Get(TranslationKindIngredient, source)
+
+	// 人工审核过的条目始终优先
+	if remembered && entry.Approved {
+		return newTranslation(ingredient, entry.Translation, entry.Origin, confidenceApproved), true
+	}
+
+	// 食材本体（包含中英文名和地区别名）
+	if known, exists := t.ontology.Lookup(ingredient); exists {
+		return newTranslation(ingredient, known.English, TranslationOriginOntology, confidenceOntology), true
+	}
+
+	// 英文等无需翻译的输入直接返回（全角字母转为半角）
+	if !DetectLanguage(ingredient).NeedsTranslation() {
+		return newTranslation(ingredient, strings.TrimSpace(foldFullWidth(ingredient)), TranslationOriginPassthrough, confidencePassthrough), true
+	}
+
+	// 此前的AI翻译结果
+	if remembered && entry.Origin == TranslationOriginAI {
+		return newTranslation(ingredient, entry.Translation, TranslationOriginAI, rememberedConfidence(entry)), true
+	}
+
+	// 缓存
+	if cached := t.getFromCache(t.ingredientCacheKey(ingredient)); cached != "" {
+		return newTranslation(ingredient, cached, TranslationOriginCache, confidenceCached), true
+	}
+
+	return Translation{}, false
+}
+
+// rememberedConfidence 返回翻译记忆条目的置信度，旧条目未记录时使用默认值
+func rememberedConfidence(entry *TranslationEntry) float64 {
+	if entry.Confidence > 0 {
+		return entry.Confidence
+	}
+	return confidenceAI
+}
+
+// recordIngredient 评估食材AI翻译的置信度，并写入翻译记忆和缓存
+func (t *TranslationService) recordIngredient(ingredient, text string, retried bool) Translation {
+	confidence := t.scoreAITranslation(TranslationKindIngredient, ingredient, text)
+	if retried {
+		confidence -= retryPenalty
+	}
+
+	t.memory.Record(TranslationKindIngredient, t.ontology.Canonicalize(ingredient), text, TranslationOriginAI, confidence)
+	t.saveToCache(t.ingredientCacheKey(ingredient), text, t.config.CacheTTL.Duration)
+
+	return newTranslation(ingredient, text, TranslationOriginAI, confidence)
+}
+
+// recordFallback 执行降级翻译并记录结果，便于管理员事后修正
+func (t *TranslationService) recordFallback(kind, text string) Translation {
+	translation := t.fallbackTranslation(text)
+	if translation == "" {
+		return newTranslation(text, "", TranslationOriginNone, 0)
+	}
+
+	source := text
+	if kind == TranslationKindIngredient {
+		source = t.ontology.Canonicalize(text)
+	}
+	t.memory.Record(kind, source, translation, TranslationOriginFallback, confidenceFallback)
+	return newTranslation(text, translation, TranslationOriginFallback, confidenceFallback)
+}
+
+// ingredientCacheKey 生成食材翻译缓存键
+func (t *TranslationService) ingredientCacheKey(ingredient string) string {
+	return "ingredient:" + t.ontology.CanonicalKey(ingredient)
 }
 
 // TranslateDishName 翻译菜名（中译英）
-func (t *TranslationService) TranslateDishName(dishName string) string {
-	// 1. 检查是否为英文，直接返回
-	if !t.containsChinese(dishName) {
-		return dishName
+func (t *TranslationService) TranslateDishName(ctx context.Context, dishName string) string {
+	return t.TranslateDishNameDetailed(ctx, dishName).Text
+}
+
+// TranslateDishNameDetailed 翻译菜名，返回来源和置信度
+func (t *TranslationService) TranslateDishNameDetailed(ctx context.Context, dishName string) (translation Translation) {
+	ctx, span := startTranslationSpan(ctx, TranslationKindDish, dishName)
+	defer func() { endTranslationSpan(span, translation) }()
+
+	entry, remembered := t.memory.Get(TranslationKindDish, dishName)
+
+	// 1. 人工审核过的条目始终优先
+	if remembered && entry.Approved {
+		return newTranslation(dishName, entry.Translation, entry.Origin, confidenceApproved)
 	}
 
-	// 2. 检查常用翻译映射（快速查询）
-	if translation, exists := t.commonTranslations[dishName]; exists {
-		return translation
+	// 2. 英文等无需翻译的输入直接返回（全角字母转为半角）
+	if !DetectLanguage(dishName).NeedsTranslation() {
+		return newTranslation(dishName, strings.TrimSpace(foldFullWidth(dishName)), TranslationOriginPassthrough, confidencePassthrough)
+	}
+
+	// 3. 检查常用翻译映射（快速查询）
+	if known, exists := t.commonTranslations[dishName]; exists {
+		return newTranslation(dishName, known, TranslationOriginOntology, confidenceOntology)
 	}
 
-	// 3. 检查缓存
+	// 4. 检查翻译记忆和缓存
+	if remembered && entry.Origin == TranslationOriginAI {
+		return newTranslation(dishName, entry.Translation, TranslationOriginAI, rememberedConfidence(entry))
+	}
 	cacheKey := "dish:" + dishName
 	if cached := t.getFromCache(cacheKey); cached != "" {
-		return cached
+		return newTranslation(dishName, cached, TranslationOriginCache, confidenceCached)
 	}
 
-	// 4. 调用AI进行翻译
-	translation, err := t.translateWithAI(dishName, "dish")
+	// 5. 调用AI进行翻译（校验失败时重新询问一次）
+	text, retried, err := t.translateWithAI(ctx, dishName, TranslationKindDish)
 	if err != nil {
 		// AI翻译失败，尝试关键词匹配作为降级策略
-		return t.fallbackTranslation(dishName)
+		slog.WarnContext(ctx, "菜名AI翻译失败，使用降级翻译", "dish", dishName, "error", err)
+		return t.recordFallback(TranslationKindDish, dishName)
 	}
 
-	// 5. 缓存翻译结果
-	t.saveToCache(cacheKey, translation, 24*time.Hour) // 缓存24小时
+	// 6. 评估置信度，记录并缓存翻译结果
+	confidence := t.scoreAITranslation(TranslationKindDish, dishName, text)
+	if retried {
+		confidence -= retryPenalty
+	}
+	t.memory.Record(TranslationKindDish, dishName, text, TranslationOriginAI, confidence)
+	t.saveToCache(cacheKey, text, t.config.CacheTTL.Duration)
 
-	return translation
+	return newTranslation(dishName, text, TranslationOriginAI, confidence)
 }
 
-// TranslateIngredients 批量翻译食材
-func (t *TranslationService) TranslateIngredients(ingredients []string) []string {
+// TranslateIngredients 批量翻译食材，过滤无法翻译的食材
+func (t *TranslationService) TranslateIngredients(ctx context.Context, ingredients []string) []string {
 	var translated []string
-	for _, ingredient := range ingredients {
-		translation := t.TranslateIngredient(ingredient)
-		if translation != "" {
-			translated = append(translated, translation)
+	for _, translation := range t.TranslateIngredientsDetailed(ctx, ingredients) {
+		if translation.Text != "" {
+			translated = append(translated, translation.Text)
 		}
 	}
 	return translated
 }
 
-// translateWithAI 使用AI进行翻译
-func (t *TranslationService) translateWithAI(text string, textType string) (string, error) {
-	if t.aiAPIKey == "" {
-		return "", fmt.Errorf("AI API密钥未配置")
+// TranslateIngredientsDetailed 批量翻译食材，结果与输入一一对应
+// 未命中缓存的食材合并为一次AI请求，批量请求失败时逐个并行翻译
+func (t *TranslationService) TranslateIngredientsDetailed(ctx context.Context, ingredients []string) []Translation {
+	ctx, span := tracing.Start(ctx, "translation.ingredients", attribute.Int("translation.count", len(ingredients)))
+	defer span.End()
+
+	results := make([]Translation, len(ingredients))
+
+	// 1. 快速路径，收集需要AI翻译的食材（去重）
+	missIndexes := make(map[string][]int)
+	var misses []string
+	for i, ingredient := range ingredients {
+		if translation, ok := t.lookupIngredient(ingredient); ok {
+			results[i] = translation
+			continue
+		}
+		if _, exists := missIndexes[ingredient]; !exists {
+			misses = append(misses, ingredient)
+		}
+		missIndexes[ingredient] = append(missIndexes[ingredient], i)
+	}
+
+	// 2. 翻译未命中的食材
+	var translations map[string]Translation
+	switch {
+	case len(misses) == 1:
+		translations = map[string]Translation{misses[0]: t.TranslateIngredientDetailed(ctx, misses[0])}
+	case len(misses) > 1:
+		translations = t.translateIngredientMisses(ctx, misses)
+	}
+	span.SetAttributes(attribute.Int("translation.misses", len(misses)))
+	for ingredient, indexes := range missIndexes {
+		for _, i := range indexes {
+			results[i] = translations[ingredient]
+		}
+	}
+
+	return results
+}
+
+// translateIngredientMisses 翻译多个未命中缓存的食材
+func (t *TranslationService) translateIngredientMisses(ctx context.Context, ingredients []string) map[string]Translation {
+	batch, err := t.translateBatchWithAI(ctx, ingredients)
+	if err != nil {
+		slog.WarnContext(ctx, "批量翻译失败，改为并行逐个翻译", "ingredients", len(ingredients), "error", err)
+		return t.translateIngredientsParallel(ctx, ingredients)
 	}
 
+	translations := make(map[string]Translation, len(ingredients))
+	var invalid []string
+	for _, ingredient := range ingredients {
+		text, err := validateTranslationOutput(batch[ingredient])
+		if err != nil {
+			// 单项缺失或校验失败时单独重新翻译，不影响其他食材
+			invalid = append(invalid, ingredient)
+			continue
+		}
+		translations[ingredient] = t.recordIngredient(ingredient, text, false)
+	}
+
+	if len(invalid) > 0 {
+		slog.InfoContext(ctx, "批量翻译中部分译文未通过校验，单独重新翻译", "invalid", invalid)
+		for ingredient, translation := range t.translateIngredientsParallel(ctx, invalid) {
+			translations[ingredient] = translation
+		}
+	}
+	return translations
+}
+
+// translateIngredientsParallel 并行逐个翻译食材，同时进行的AI调用不超过 Concurrency 个
+func (t *TranslationService) translateIngredientsParallel(ctx context.Context, ingredients []string) map[string]Translation {
+	translations := make(map[string]Translation, len(ingredients))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	concurrency := t.config.Concurrency
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+	semaphore := make(chan struct{}, concurrency)
+	for _, ingredient := range ingredients {
+		wg.Add(1)
+		go func(ingredient string) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			translation := t.TranslateIngredientDetailed(ctx, ingredient)
+			mu.Lock()
+			translations[ingredient] = translation
+			mu.Unlock()
+		}(ingredient)
+	}
+
+	wg.Wait()
+	return translations
+}
+
+// translateWithAI 使用AI进行翻译，译文未通过校验时附带纠正要求重新询问一次
+func (t *TranslationService) translateWithAI(ctx context.Context, text string, textType string) (string, bool, error) {
+	// 按检测到的源语言（中文、日语、韩语）构建提示词
+	language := DetectLanguage(text).LanguageName()
+
 	var prompt string
-	if textType == "ingredient" {
-		prompt = fmt.Sprintf(`请将以下中文食材名称翻译成英文，只需返回单个英文单词或词组，不要任何解释：
-%s`, text)
+	if textType == TranslationKindIngredient {
+		prompt = fmt.Sprintf(`请将以下%s食材名称翻译成英文，只需返回单个英文单词或词组，不要任何解释：
+%s`, language, text)
 	} else {
-		prompt = fmt.Sprintf(`请将以下中文菜名翻译成对应的英文菜名，返回适合食谱搜索的英文表达：
-%s`, text)
+		prompt = fmt.Sprintf(`请将以下%s菜名翻译成对应的英文菜名，返回适合食谱搜索的英文表达：
+%s`, language, text)
+	}
+
+	content, err := t.callTranslationAPI(ctx, prompt, t.config.Timeout.Duration)
+	if err != nil {
+		return "", false, err
+	}
+
+	translation, validationErr := validateTranslationOutput(content)
+	if validationErr == nil {
+		return translation, false, nil
+	}
+
+	// 重新询问一次，明确指出上次回答的问题
+	retryPrompt := fmt.Sprintf(`%s
+
+你上次的回答是：%s
+该回答不符合要求（%v）。请只返回一行、不超过%d个英文单词的英文名称，只能包含英文字母、空格和连字符，不要任何解释、标点或引号。`,
+		prompt, strings.TrimSpace(content), validationErr, maxTranslationWords)
+
+	content, err = t.callTranslationAPI(ctx, retryPrompt, t.config.Timeout.Duration)
+	if err != nil {
+		return "", true, err
 	}
 
+	translation, validationErr = validateTranslationOutput(content)
+	if validationErr != nil {
+		return "", true, fmt.Errorf("译文校验失败: %v", validationErr)
+	}
+	return translation, true, nil
+}
+
+// translateBatchWithAI 在一次AI请求中翻译多个食材，返回原文到译文的映射
+func (t *TranslationService) translateBatchWithAI(ctx context.Context, ingredients []string) (map[string]string, error) {
+	items, err := json.Marshal(ingredients)
+	if err != nil {
+		return nil, fmt.Errorf("序列化批量翻译内容失败: %v", err)
+	}
+
+	prompt := fmt.Sprintf(`请将以下JSON数组中的食材名称（可能是中文、日语、韩语，或夹杂英文）逐个翻译成英文。
+只返回一个JSON对象，键为原中文名称（保持原样），值为对应的英文单词或词组（小写），不要任何解释或代码块标记：
+%s`, string(items))
+
+	content, err := t.callTranslationAPI(ctx, prompt, t.config.BatchTimeout.Duration)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := parseTranslationMap(content)
+	if err != nil {
+		return nil, err
+	}
+
+	translations := make(map[string]string, len(raw))
+	for source, translation := range raw {
+		translations[source] = cleanTranslation(translation)
+	}
+	return translations, nil
+}
+
+// parseTranslationMap 解析模型返回的JSON翻译映射
+func parseTranslationMap(content string) (map[string]string, error) {
+	// 去除模型可能附带的代码块标记
+	content = strings.TrimSpace(content)
+	content = strings.TrimPrefix(content, "```json")
+	content = strings.TrimPrefix(content, "```")
+	content = strings.TrimSuffix(content, "```")
+
+	var raw map[string]string
+	if err := json.Unmarshal([]byte(strings.TrimSpace(content)), &raw); err != nil {
+		return nil, fmt.Errorf("解析批量翻译结果失败: %v", err)
+	}
+
+	translations := make(map[string]string, len(raw))
+	for source, translation := range raw {
+		translations[strings.TrimSpace(source)] = strings.TrimSpace(translation)
+	}
+	return translations, nil
+}
+
+// callTranslationAPI 调用翻译模型并返回原始回复内容
+// 请求只继承 ctx 中的追踪信息而不随调用方取消，已发出的翻译完成后仍会写入翻译记忆
+func (t *TranslationService) callTranslationAPI(ctx context.Context, prompt string, timeout time.Duration) (content string, err error) {
+	if t.aiAPIKey == "" {
+		return "", newSourceError(SourceErrorNotConfigured, "AI API密钥未配置")
+	}
+	defer observeUpstream(metrics.ProviderTranslation, time.Now(), &err)
+	if err = t.breaker.Allow(); err != nil {
+		return "", err
+	}
+	defer func() { t.breaker.Record(err) }()
+
 	// 构建AI请求
 	requestBody := map[string]interface{}{
 		"model": t.model,
@@ -155,35 +464,36 @@ func (t *TranslationService) translateWithAI(text string, textType string) (stri
 
 	jsonData, err := json.Marshal(requestBody)
 	if err != nil {
-		return "", fmt.Errorf("序列化翻译请求失败: %v", err)
+		return "", newSourceError(SourceErrorRequestFailed, "序列化翻译请求失败: %v", err)
 	}
 
-	req, err := http.NewRequest("POST", t.aiBaseURL, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(context.WithoutCancel(ctx), "POST", t.aiBaseURL, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return "", fmt.Errorf("创建翻译请求失败: %v", err)
+		return "", newSourceError(SourceErrorRequestFailed, "创建翻译请求失败: %v", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+t.aiAPIKey)
 
 	client := &http.Client{
-		Timeout: 10 * time.Second, // 设置10秒超时
+		Timeout:   timeout,
+		Transport: t.transport,
 	}
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("翻译API调用失败: %v", err)
+		return "", newSourceError(SourceErrorRequestFailed, "翻译API调用失败: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("翻译API返回错误 %d: %s", resp.StatusCode, string(body))
+		return "", upstreamStatusError(resp.StatusCode, body)
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("读取翻译响应失败: %v", err)
+		return "", newSourceError(SourceErrorInvalidResponse, "读取翻译响应失败: %v", err)
 	}
 
 	var apiResp struct {
@@ -192,76 +502,57 @@ func (t *TranslationService) translateWithAI(text string, textType string) (stri
 				Content string `json:"content"`
 			} `json:"message"`
 		} `json:"choices"`
+		Usage APIUsage `json:"usage"`
 	}
 
 	if err := json.Unmarshal(body, &apiResp); err != nil {
-		return "", fmt.Errorf("解析翻译响应失败: %v", err)
+		return "", newSourceError(SourceErrorInvalidResponse, "解析翻译响应失败: %v", err)
 	}
+	metrics.AddTokens(metrics.ProviderTranslation, apiResp.Usage.PromptTokens, apiResp.Usage.CompletionTokens)
 
 	if len(apiResp.Choices) == 0 {
-		return "", fmt.Errorf("翻译API返回空响应")
+		return "", newSourceError(SourceErrorEmptyResponse, "翻译API返回空响应")
 	}
 
-	// 清理返回的翻译结果
-	translation := strings.TrimSpace(apiResp.Choices[0].Message.Content)
-	translation = strings.Trim(translation, `"'`) // 去除可能的引号
-	translation = strings.ToLower(translation)   // 统一转为小写
+	return apiResp.Choices[0].Message.Content, nil
+}
 
-	return translation, nil
+// cleanTranslation 清理模型返回的翻译结果
+func cleanTranslation(translation string) string {
+	translation = strings.TrimSpace(translation)
+	translation = strings.Trim(translation, `"'`) // 去除可能的引号
+	return strings.ToLower(translation)           // 统一转为小写
 }
 
+
 // fallbackTranslation 降级翻译策略
 func (t *TranslationService) fallbackTranslation(text string) string {
-	// 关键词匹配作为最后的备选方案
-	if strings.Contains(text, "鸡蛋") || strings.Contains(text, "炒蛋") {
-		return "scrambled eggs"
-	} else if strings.Contains(text, "土豆") {
-		return "potato"
-	} else if strings.Contains(text, "鸡肉") || strings.Contains(text, "鸡") {
-		return "chicken"
-	} else if strings.Contains(text, "猪肉") || strings.Contains(text, "肉") {
-		return "pork"
-	} else if strings.Contains(text, "牛肉") {
-		return "beef"
-	} else if strings.Contains(text, "鱼") {
-		return "fish"
-	} else if strings.Contains(text, "豆腐") {
-		return "tofu"
-	} else if strings.Contains(text, "面条") || strings.Contains(text, "面") {
-		return "noodles"
-	} else if strings.Contains(text, "米饭") || strings.Contains(text, "饭") {
-		return "rice"
-	} else if strings.Contains(text, "西红柿") || strings.Contains(text, "番茄") {
-		return "tomato"
-	} else if strings.Contains(text, "青菜") || strings.Contains(text, "菜") {
-		return "vegetables"
-	} else {
-		return "" // 无法翻译则返回空字符串
+	// 在食材本体中匹配最具体的食材名称作为最后的备选方案
+	if ingredient, exists := t.ontology.Match(text); exists {
+		return ingredient.English
 	}
-}
-
-// containsChinese 检查字符串是否包含中文字符
-func (t *TranslationService) containsChinese(str string) bool {
-	for _, r := range str {
-		if r >= rune('\u4e00') && r <= rune('\u9fff') {
-			return true
-		}
-	}
-	return false
+	return "" // 无法翻译则返回空字符串
 }
 
 // getFromCache 从缓存获取翻译结果
 func (t *TranslationService) getFromCache(key string) string {
 	t.cacheMutex.RLock()
-	defer t.cacheMutex.RUnlock()
+	entry, exists := t.cache[key]
+	t.cacheMutex.RUnlock()
 
-	if entry, exists := t.cache[key]; exists {
-		if time.Now().Before(entry.ExpiresAt) {
-			return entry.Translation
+	if exists && time.Now().Before(entry.ExpiresAt) {
+		t.cacheHits.Add(1)
+		return entry.Translation
+	}
+	if exists {
+		// 清理过期缓存需要写锁，期间条目可能已被重新写入，只删除同一个过期条目
+		t.cacheMutex.Lock()
+		if t.cache[key] == entry {
+			delete(t.cache, key)
 		}
-		// 清理过期缓存
-		delete(t.cache, key)
+		t.cacheMutex.Unlock()
 	}
+	t.cacheMisses.Add(1)
 	return ""
 }
 
@@ -276,21 +567,62 @@ func (t *TranslationService) saveToCache(key, translation string, ttl time.Durat
 	}
 }
 
-// CleanExpiredCache 清理过期的翻译缓存
-func (t *TranslationService) CleanExpiredCache() {
+// CleanExpiredCache 清理过期的翻译缓存，返回清理的条目数
+func (t *TranslationService) CleanExpiredCache() int {
 	t.cacheMutex.Lock()
 	defer t.cacheMutex.Unlock()
 
 	now := time.Now()
+	removed := 0
 	for key, entry := range t.cache {
 		if now.After(entry.ExpiresAt) {
 			delete(t.cache, key)
+			removed++
 		}
 	}
+	return removed
 }
 
-// GetCacheStatus 获取翻译缓存状态
-func (t *TranslationService) GetCacheStatus() map[string]interface{} {
+// CacheEntries 列出键以 prefix 开头的翻译缓存条目（如 ingredient:、dish:），按键排序
+func (t *TranslationService) CacheEntries(prefix string) []CachedEntry {
+	t.cacheMutex.RLock()
+	defer t.cacheMutex.RUnlock()
+
+	now := time.Now()
+	entries := []CachedEntry{}
+	for key, entry := range t.cache {
+		if strings.HasPrefix(key, prefix) {
+			entries = append(entries, CachedEntry{
+				Key:       key,
+				Value:     entry.Translation,
+				Size:      len(entry.Translation),
+				ExpiresAt: entry.ExpiresAt,
+				Expired:   !now.Before(entry.ExpiresAt),
+			})
+		}
+	}
+	sortCachedEntries(entries)
+	return entries
+}
+
+// PurgeCache 删除键以 prefix 开头的翻译缓存，prefix 为空时清空缓存，返回删除的条目数；
+// 翻译记忆中的条目不受影响，下次请求时从翻译记忆重新加载
+func (t *TranslationService) PurgeCache(prefix string) int {
+	t.cacheMutex.Lock()
+	defer t.cacheMutex.Unlock()
+
+	removed := 0
+	for key := range t.cache {
+		if strings.HasPrefix(key, prefix) {
+			delete(t.cache, key)
+			removed++
+		}
+	}
+	return removed
+}
+
+// GetCacheStatus 获取翻译缓存状态和命中率
+func (t *TranslationService) GetCacheStatus() metrics.CacheStatus {
 	t.cacheMutex.RLock()
 	defer t.cacheMutex.RUnlock()
 
@@ -306,9 +638,62 @@ func (t *TranslationService) GetCacheStatus() map[string]interface{} {
 		}
 	}
 
-	return map[string]interface{}{
-		"total_entries":  len(t.cache),
-		"active_entries": activeCount,
-		"expired_entries": expiredCount,
+	return metrics.NewCacheStatus(activeCount, expiredCount, t.cacheHits.Load(), t.cacheMisses.Load())
+}
+
+// ListTranslations 列出翻译记忆条目
+func (t *TranslationService) ListTranslations(kind string) []*TranslationEntry {
+	return t.memory.List(kind)
+}
+
+// GetTranslation 获取翻译记忆条目
+func (t *TranslationService) GetTranslation(kind, source string) (*TranslationEntry, bool) {
+	return t.memory.Get(kind, t.memorySource(kind, source))
+}
+
+// CorrectTranslation 人工修正翻译，修正后立即生效
+func (t *TranslationService) CorrectTranslation(kind, source, translation string) (*TranslationEntry, error) {
+	source = t.memorySource(kind, source)
+	entry, err := t.memory.Correct(kind, source, cleanTranslation(translation))
+	if err != nil {
+		return nil, err
+	}
+	t.evictTranslation(kind, source)
+	return entry, nil
+}
+
+// ApproveTranslation 审核通过翻译条目
+func (t *TranslationService) ApproveTranslation(kind, source string) (*TranslationEntry, error) {
+	return t.memory.Approve(kind, t.memorySource(kind, source))
+}
+
+// DeleteTranslation 删除翻译条目，下次请求时将重新翻译
+func (t *TranslationService) DeleteTranslation(kind, source string) error {
+	source = t.memorySource(kind, source)
+	if err := t.memory.Delete(kind, source); err != nil {
+		return err
 	}
-}
\ No newline at end of file
+	t.evictTranslation(kind, source)
+	return nil
+}
+
+// memorySource 食材按本体规范名称存储，菜名保持原样
+func (t *TranslationService) memorySource(kind, source string) string {
+	source = strings.TrimSpace(source)
+	if kind == TranslationKindIngredient {
+		return t.ontology.Canonicalize(source)
+	}
+	return source
+}
+
+// evictTranslation 清除条目对应的翻译缓存
+func (t *TranslationService) evictTranslation(kind, source string) {
+	key := "dish:" + source
+	if kind == TranslationKindIngredient {
+		key = t.ingredientCacheKey(source)
+	}
+
+	t.cacheMutex.Lock()
+	defer t.cacheMutex.Unlock()
+	delete(t.cache, key)
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// 翻译结果来源（补充翻译记忆条目来源）
+const (
+	TranslationOriginOntology    = "ontology"
+	TranslationOriginPassthrough = "passthrough"
+	TranslationOriginCache       = "cache"
+	TranslationOriginNone        = "none"
+)
+
+// 翻译置信度
+const (
+	confidenceApproved    = 1.0
+	confidenceOntology    = 1.0
+	confidencePassthrough = 0.95
+	confidenceAIVerified  = 0.9  // AI结果与本体中的已知食材一致
+	confidenceAI          = 0.75 // AI结果无法交叉验证
+	confidenceAIConflict  = 0.45 // AI结果与本体中的已知食材不一致
+	confidenceCached      = 0.7
+	confidenceFallback    = 0.4
+	retryPenalty          = 0.1 // 重新询问后才通过校验的结果
+
+	// LowConfidenceThreshold 低于该值的翻译被标记为低置信度
+	LowConfidenceThreshold = 0.6
+)
+
+// 翻译输出限制
+const (
+	maxTranslationLength = 40
+	maxTranslationWords  = 6
+	// maxLongTranslationRatio 长文本译文与原文的最大字符数之比，超出时多半附带了解释
+	maxLongTranslationRatio = 2
+)
+
+// explanationMarkers 模型附带解释时的常见措辞，按完整单词匹配。
+// 不包含 english 等会出现在食材名中的词（english cucumber、english peas）
+var explanationMarkers = []string{
+	"i think", "translation", "translated", "means", "it's", "it is", "this is",
+	"could be", "probably", "maybe", "answer", "sorry", "note",
+}
+
+// Translation 带来源和置信度的翻译结果
+type Translation struct {
+	Source        string  `json:"source"`
+	Text          string  `json:"text"`
+	Origin        string  `json:"origin"`
+	Confidence    float64 `json:"confidence"`
+	LowConfidence bool    `json:"lowConfidence"`
+}
+
+// newTranslation 创建翻译结果并根据置信度标记
+func newTranslation(source, text, origin string, confidence float64) Translation {
+	if text == "" {
+		confidence = 0
+	}
+	return Translation{
+		Source:        source,
+		Text:          text,
+		Origin:        origin,
+		Confidence:    confidence,
+		LowConfidence: confidence < LowConfidenceThreshold,
+	}
+}
+
+// validateTranslationOutput 严格校验模型返回的译文：单行、ASCII食物词汇、长度限制、不含解释
+func validateTranslationOutput(raw string) (string, error) {
+	text := strings.TrimSpace(raw)
+
+	lines := 0
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines++
+		}
+	}
+	if lines > 1 {
+		return "", fmt.Errorf("译文包含多行内容")
+	}
+
+	text = cleanTranslation(text)
+	text = strings.TrimRight(text, ".。!！")
+	if text == "" {
+		return "", fmt.Errorf("译文为空")
+	}
+	if utf8.RuneCountInString(text) > maxTranslationLength {
+		return "", fmt.Errorf("译文过长: %d 个字符", utf8.RuneCountInString(text))
+	}
+	if len(strings.Fields(text)) > maxTranslationWords {
+		return "", fmt.Errorf("译文单词过多")
+	}
+
+	for _, r := range text {
+		if !(r >= 'a' && r <= 'z') && r != ' ' && r != '-' && r != '\'' && r != '&' {
+			return "", fmt.Errorf("译文包含非法字符: %q", r)
+		}
+	}
+
+	// 前后补空格，按完整单词匹配，避免 annotated 之类的词命中 note
+	padded := " " + strings.Join(strings.Fields(text), " ") + " "
+	for _, marker := range explanationMarkers {
+		if strings.Contains(padded, " "+marker+" ") {
+			return "", fmt.Errorf("译文包含解释性内容: %q", marker)
+		}
+	}
+
+	return text, nil
+}
+
+// longTranslationPreambles 模型在长文本译文前附带的开场白，按首行前缀匹配（不区分大小写）
+var longTranslationPreambles = []string{
+	"以下是", "翻译如下", "译文如下", "译文：", "翻译：", "好的", "当然",
+	"here is", "here's", "sure", "translation:", "certainly",
+}
+
+// validateLongTranslationOutput 校验长文本译文：去除HTML标签和代码块标记，拒绝空白、开场白和明显过长的回复
+func validateLongTranslationOutput(source, raw string) (string, error) {
+	text := strings.TrimSpace(raw)
+	text = strings.TrimPrefix(text, "```text")
+	text = strings.TrimPrefix(text, "```")
+	text = strings.TrimSuffix(text, "```")
+	text = htmlToText(text)
+	if text == "" {
+		return "", fmt.Errorf("译文为空")
+	}
+
+	firstLine := strings.ToLower(strings.SplitN(text, "\n", 2)[0])
+	for _, preamble := range longTranslationPreambles {
+		if strings.HasPrefix(firstLine, preamble) {
+			return "", fmt.Errorf("译文包含解释性内容: %q", preamble)
+		}
+	}
+
+	if length, limit := utf8.RuneCountInString(text), maxLongTranslationRatio*utf8.RuneCountInString(source); length > limit {
+		return "", fmt.Errorf("译文过长: %d 个字符，原文 %d 个字符", length, utf8.RuneCountInString(source))
+	}
+	return text, nil
+}
+
+// scoreAITranslation 将AI译文与食材本体交叉验证，返回置信度
+func (t *TranslationService) scoreAITranslation(kind, source, translation string) float64 {
+	known, exists := t.ontology.Match(source)
+	if !exists {
+		return confidenceAI
+	}
+
+	// 原文中包含已知食材时，译文应包含该食材英文名的核心词
+	words := strings.Fields(known.English)
+	head := strings.TrimSuffix(words[len(words)-1], "s")
+	if strings.Contains(translation, head) {
+		return confidenceAIVerified
+	}
+	// 菜名常以意译为主（如鱼香肉丝），不一致时不直接判为低置信度
+	if kind == TranslationKindDish {
+		return LowConfidenceThreshold
+	}
+	return confidenceAIConflict
+}
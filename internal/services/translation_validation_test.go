@@ -0,0 +1,67 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateTranslationOutput(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+		ok   bool
+	}{
+		{"Tomato", "tomato", true},
+		{"english cucumber", "english cucumber", true},
+		{"English peas.", "english peas", true},
+		{"notched carrot", "notched carrot", true},
+		{"chicken breast", "chicken breast", true},
+		{"it is tomato", "", false},
+		{"this is egg", "", false},
+		{"tomato, i think", "", false},
+		{"probably leek", "", false},
+		{"translation tomato", "", false},
+		{"sorry", "", false},
+		{"tomato\negg", "", false},
+		{"番茄", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, err := validateTranslationOutput(tt.raw)
+		if (err == nil) != tt.ok {
+			t.Errorf("validateTranslationOutput(%q) error = %v, want ok=%v", tt.raw, err, tt.ok)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("validateTranslationOutput(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestValidateLongTranslationOutput(t *testing.T) {
+	source := "Heat the oil.\nAdd the eggs and stir."
+	tests := []struct {
+		raw  string
+		want string
+		ok   bool
+	}{
+		{"热油。\n加入鸡蛋翻炒。", "热油。\n加入鸡蛋翻炒。", true},
+		{"<ol><li>热油。</li><li>加入鸡蛋翻炒。</li></ol>", "热油。\n加入鸡蛋翻炒。", true},
+		{"```\n热油。\n```", "热油。", true},
+		{"以下是译文：\n热油。", "", false},
+		{"Here is the translation:\n热油。", "", false},
+		{strings.Repeat("热油。", 40), "", false},
+		{"<p> </p>", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, err := validateLongTranslationOutput(source, tt.raw)
+		if (err == nil) != tt.ok {
+			t.Errorf("validateLongTranslationOutput(%q) error = %v, want ok=%v", tt.raw, err, tt.ok)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("validateLongTranslationOutput(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
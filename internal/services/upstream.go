@@ -0,0 +1,13 @@
+package services
+
+import (
+	"net/http"
+
+	"recipe-agent/internal/logging"
+	"recipe-agent/internal/tracing"
+)
+
+// upstreamTransport 出站请求的传输层：生成客户端span、携带请求ID并记录调用日志
+func upstreamTransport() http.RoundTripper {
+	return tracing.Transport(logging.Transport(nil))
+}
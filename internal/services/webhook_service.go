@@ -0,0 +1,617 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"recipe-agent/internal/config"
+	"recipe-agent/internal/logging"
+	"recipe-agent/internal/tracing"
+)
+
+// 回调状态
+const (
+	WebhookStatusPending   = "pending"
+	WebhookStatusDelivered = "delivered"
+	WebhookStatusDead      = "dead" // 重试次数用尽，进入死信列表
+)
+
+// 回调请求头
+const (
+	WebhookHeaderID        = "X-Webhook-ID"
+	WebhookHeaderEvent     = "X-Webhook-Event"
+	WebhookHeaderTimestamp = "X-Webhook-Timestamp"
+	WebhookHeaderSignature = "X-Webhook-Signature"
+)
+
+// 回调服务错误
+var (
+	ErrWebhookNotFound       = errors.New("回调不存在")
+	ErrWebhookDisabled       = errors.New("回调未启用，请配置WEBHOOK_SECRET")
+	ErrWebhookInvalidURL     = errors.New("回调地址必须是http或https的绝对地址")
+	ErrWebhookBlockedAddress = errors.New("回调地址不能指向本机、内网或链路本地地址")
+	ErrWebhookNotDeliverable = errors.New("回调正在投递中")
+)
+
+// maxResponseDrain 读取并丢弃的响应内容上限，读完响应体后连接才能复用
+const maxResponseDrain = 4096
+
+// WebhookConfig 回调服务配置
+type WebhookConfig struct {
+	// Secret HMAC-SHA256签名密钥，为空时不接受回调注册
+	Secret string
+	// StorePath 持久化文件路径，为空时不持久化
+	StorePath string
+	// MaxAttempts 最大投递次数（含首次）
+	MaxAttempts int
+	// InitialBackoff 首次重试前的等待时间，之后每次翻倍，不超过MaxBackoff
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// HistoryTTL 投递成功的记录保留时间
+	HistoryTTL time.Duration
+	// AllowPrivateNetworks 允许回调地址指向本机和内网，只应在接收方部署在内网时开启
+	AllowPrivateNetworks bool
+	// Client 发送回调使用的HTTP客户端，为nil时使用拒绝连接内网地址的客户端
+	Client *http.Client
+}
+
+// WebhookDelivery 单次投递记录
+type WebhookDelivery struct {
+	Attempt    int       `json:"attempt"`
+	At         time.Time `json:"at"`
+	StatusCode int       `json:"statusCode,omitempty"`
+	DurationMs int64     `json:"durationMs"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Webhook 任务结束回调，ID与任务ID相同
+type Webhook struct {
+	ID          string            `json:"id"`
+	URL         string            `json:"url"`
+	Event       string            `json:"event"`
+	Status      string            `json:"status" enum:"pending,delivered,dead"`
+	Attempts    int               `json:"attempts"`
+	NextAttempt *time.Time        `json:"nextAttemptAt,omitempty"`
+	Deliveries  []WebhookDelivery `json:"deliveries"`
+	Payload     json.RawMessage   `json:"-"`
+	CreatedAt   time.Time         `json:"createdAt"`
+	UpdatedAt   time.Time         `json:"updatedAt"`
+}
+
+// webhookRecord 持久化格式，包含回调内容
+type webhookRecord struct {
+	Webhook
+	Payload json.RawMessage `json:"payload"`
+}
+
+// WebhookPayload 回调请求体
+type WebhookPayload struct {
+	Event     string          `json:"event"`
+	JobID     string          `json:"jobId"`
+	Status    string          `json:"status"`
+	Result    json.RawMessage `json:"result,omitempty"`
+	Error     *JobError       `json:"error,omitempty"`
+	Timestamp time.Time       `json:"timestamp"`
+}
+
+// WebhookService 任务结束时向客户端注册的地址发送签名回调，失败时指数退避重试
+type WebhookService struct {
+	config   WebhookConfig
+	webhooks map[string]*Webhook
+	mutex    sync.Mutex
+	// stopping 已开始关闭，不再发起新的投递
+	stopping bool
+	stop     chan struct{}
+	// deliveries 正在进行的投递
+	deliveries sync.WaitGroup
+	// sending 正在发送请求的投递数
+	sending int
+}
+
+// NewWebhookService 根据服务配置创建回调服务实例
+func NewWebhookService(cfg config.Webhooks) *WebhookService {
+	s := NewWebhookServiceWithConfig(WebhookConfig{
+		Secret:               cfg.Secret,
+		StorePath:            cfg.StoreFile,
+		MaxAttempts:          cfg.MaxAttempts,
+		InitialBackoff:       cfg.Backoff.Duration,
+		MaxBackoff:           cfg.MaxBackoff.Duration,
+		HistoryTTL:           cfg.HistoryTTL.Duration,
+		AllowPrivateNetworks: cfg.AllowPrivateNetworks,
+		Client:               NewWebhookClient(cfg.Timeout.Duration, cfg.AllowPrivateNetworks),
+	})
+	if s.config.Secret == "" {
+		slog.Warn("未配置WEBHOOK_SECRET，任务回调不可用")
+	}
+	return s
+}
+
+// NewWebhookServiceWithConfig 使用指定配置创建回调服务实例，并继续投递上次未完成的回调
+func NewWebhookServiceWithConfig(config WebhookConfig) *WebhookService {
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 5
+	}
+	if config.InitialBackoff <= 0 {
+		config.InitialBackoff = 2 * time.Second
+	}
+	if config.MaxBackoff < config.InitialBackoff {
+		config.MaxBackoff = config.InitialBackoff
+	}
+	if config.HistoryTTL <= 0 {
+		config.HistoryTTL = 24 * time.Hour
+	}
+	if config.Client == nil {
+		config.Client = NewWebhookClient(10*time.Second, false)
+	}
+
+	s := &WebhookService{
+		config:   config,
+		webhooks: make(map[string]*Webhook),
+		stop:     make(chan struct{}),
+	}
+	if err := s.load(); err != nil {
+		slog.Error("加载回调文件失败", "error", err)
+	}
+
+	s.mutex.Lock()
+	for id, webhook := range s.webhooks {
+		if webhook.Status == WebhookStatusPending {
+			s.startDeliveryLocked(id)
+		}
+	}
+	s.mutex.Unlock()
+	return s
+}
+
+// Enabled 是否已配置签名密钥
+func (s *WebhookService) Enabled() bool {
+	return s.config.Secret != ""
+}
+
+// ValidateURL 校验回调地址，提交时拒绝 localhost 和内网IP字面量；
+// 域名解析到的地址在每次连接时由 NewWebhookClient 的拨号器检查
+func (s *WebhookService) ValidateURL(callbackURL string) error {
+	if !s.Enabled() {
+		return ErrWebhookDisabled
+	}
+	parsed, err := url.Parse(callbackURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		return ErrWebhookInvalidURL
+	}
+	if s.config.AllowPrivateNetworks {
+		return nil
+	}
+	host := strings.TrimSuffix(strings.ToLower(parsed.Hostname()), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrWebhookBlockedAddress
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && blockedWebhookAddr(addr) {
+		return ErrWebhookBlockedAddress
+	}
+	return nil
+}
+
+// NewWebhookClient 创建发送回调的HTTP客户端。allowPrivate 为false时，拨号器在域名解析之后检查实际连接的地址，
+// 拒绝本机、内网、链路本地（含云服务元数据地址 169.254.169.254）等地址，防止借回调访问内部服务；
+// 不使用环境变量中的代理，避免绕过该检查
+func NewWebhookClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: 10 * time.Second, KeepAlive: 30 * time.Second}
+	if !allowPrivate {
+		dialer.Control = func(_, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return fmt.Errorf("%w: %s", ErrWebhookBlockedAddress, address)
+			}
+			if blockedWebhookAddr(addrPort.Addr()) {
+				return fmt.Errorf("%w: %s", ErrWebhookBlockedAddress, addrPort.Addr())
+			}
+			return nil
+		}
+	}
+	transport := &http.Transport{
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: tracing.Transport(logging.Transport(transport)),
+		// 重定向可能指向内网地址，拨号时同样会被拒绝；接收方应直接返回2xx
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// blockedWebhookAddr 回调不允许连接的地址：本机、私有网络、链路本地、组播、未指定地址和运营商级NAT
+func blockedWebhookAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() || addr.IsUnspecified() ||
+		sharedAddressSpace.Contains(addr)
+}
+
+// sharedAddressSpace 运营商级NAT地址段（RFC 6598）
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// JobFinished 任务结束时调用，对注册了回调地址的任务发送回调
+func (s *WebhookService) JobFinished(job Job) {
+	if job.CallbackURL == "" || !s.Enabled() {
+		return
+	}
+
+	event := "job." + job.Status
+	payload, err := json.Marshal(WebhookPayload{
+		Event:     event,
+		JobID:     job.ID,
+		Status:    job.Status,
+		Result:    job.Result,
+		Error:     job.Error,
+		Timestamp: time.Now(),
+	})
+	if err != nil {
+		slog.Error("序列化回调内容失败", "job_id", job.ID, "error", err)
+		return
+	}
+
+	now := time.Now()
+	s.mutex.Lock()
+	s.webhooks[job.ID] = &Webhook{
+		ID:          job.ID,
+		URL:         job.CallbackURL,
+		Event:       event,
+		Status:      WebhookStatusPending,
+		Deliveries:  []WebhookDelivery{},
+		Payload:     payload,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		NextAttempt: &now,
+	}
+	s.saveLocked()
+	s.startDeliveryLocked(job.ID)
+	s.mutex.Unlock()
+}
+
+// Get 获取回调及其投递记录
+func (s *WebhookService) Get(id string) (*Webhook, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	webhook, exists := s.webhooks[id]
+	if !exists {
+		return nil, ErrWebhookNotFound
+	}
+	return copyWebhook(webhook), nil
+}
+
+// List 列出回调，status为空时返回全部
+func (s *WebhookService) List(status string) []*Webhook {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	webhooks := make([]*Webhook, 0, len(s.webhooks))
+	for _, webhook := range s.webhooks {
+		if status == "" || webhook.Status == status {
+			webhooks = append(webhooks, copyWebhook(webhook))
+		}
+	}
+	sort.Slice(webhooks, func(i, j int) bool {
+		return webhooks[i].CreatedAt.After(webhooks[j].CreatedAt)
+	})
+	return webhooks
+}
+
+// Redeliver 重新投递死信列表中的回调
+func (s *WebhookService) Redeliver(id string) (*Webhook, error) {
+	s.mutex.Lock()
+	webhook, exists := s.webhooks[id]
+	if !exists {
+		s.mutex.Unlock()
+		return nil, ErrWebhookNotFound
+	}
+	if webhook.Status == WebhookStatusPending {
+		s.mutex.Unlock()
+		return nil, ErrWebhookNotDeliverable
+	}
+
+	now := time.Now()
+	webhook.Status = WebhookStatusPending
+	webhook.Attempts = 0
+	webhook.NextAttempt = &now
+	webhook.UpdatedAt = now
+	s.saveLocked()
+	s.startDeliveryLocked(id)
+	copied := copyWebhook(webhook)
+	s.mutex.Unlock()
+
+	return copied, nil
+}
+
+// Shutdown 停止发起新的投递并等待正在发送的回调完成，等待重试的回调保留在文件中，
+// 下次启动时继续投递；ctx到期时不再等待
+func (s *WebhookService) Shutdown(ctx context.Context) error {
+	s.mutex.Lock()
+	if !s.stopping {
+		s.stopping = true
+		close(s.stop)
+	}
+	s.mutex.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.deliveries.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	// 等待重试的投递会立即退出，只有仍在发送的请求算作超时
+	if s.sending > 0 {
+		err = ctx.Err()
+	}
+	if s.config.StorePath != "" {
+		if saveErr := s.save(); saveErr != nil {
+			return saveErr
+		}
+	}
+	return err
+}
+
+// startDeliveryLocked 在后台投递回调，服务关闭后只保存不投递，调用方需持有锁
+func (s *WebhookService) startDeliveryLocked(id string) {
+	if s.stopping {
+		return
+	}
+	s.deliveries.Add(1)
+	go func() {
+		defer s.deliveries.Done()
+		s.deliver(id)
+	}()
+}
+
+// deliver 投递回调直到成功、重试次数用尽或服务关闭
+func (s *WebhookService) deliver(id string) {
+	for {
+		s.mutex.Lock()
+		webhook, exists := s.webhooks[id]
+		if !exists || webhook.Status != WebhookStatusPending || s.stopping {
+			s.mutex.Unlock()
+			return
+		}
+		var wait time.Duration
+		if webhook.NextAttempt != nil {
+			wait = time.Until(*webhook.NextAttempt)
+		}
+		s.mutex.Unlock()
+
+		if wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+			case <-s.stop:
+				timer.Stop()
+				return
+			}
+		}
+
+		s.mutex.Lock()
+		webhook.Attempts++
+		attempt := webhook.Attempts
+		target, event, payload := webhook.URL, webhook.Event, webhook.Payload
+		s.sending++
+		s.mutex.Unlock()
+
+		delivery := s.send(id, target, event, payload, attempt)
+
+		s.mutex.Lock()
+		s.sending--
+		webhook.Deliveries = append(webhook.Deliveries, delivery)
+		webhook.UpdatedAt = time.Now()
+		switch {
+		case delivery.Error == "":
+			webhook.Status = WebhookStatusDelivered
+			webhook.NextAttempt = nil
+		case attempt >= s.config.MaxAttempts:
+			webhook.Status = WebhookStatusDead
+			webhook.NextAttempt = nil
+			slog.Warn("回调投递失败，已加入死信列表", "webhook_id", id, "attempts", attempt, "error", delivery.Error)
+		default:
+			next := time.Now().Add(s.backoff(attempt))
+			webhook.NextAttempt = &next
+		}
+		finished := webhook.NextAttempt == nil
+		s.saveLocked()
+		s.mutex.Unlock()
+
+		if finished {
+			return
+		}
+	}
+}
+
+// backoff 返回第attempt次失败后的等待时间
+func (s *WebhookService) backoff(attempt int) time.Duration {
+	wait := s.config.InitialBackoff
+	for i := 1; i < attempt && wait < s.config.MaxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > s.config.MaxBackoff {
+		wait = s.config.MaxBackoff
+	}
+	return wait
+}
+
+// send 发送一次回调，非2xx响应视为失败
+func (s *WebhookService) send(id, target, event string, payload []byte, attempt int) (delivery WebhookDelivery) {
+	start := time.Now()
+	delivery = WebhookDelivery{Attempt: attempt, At: start}
+
+	// 回调在后台投递，每次尝试是一条独立的链路
+	ctx, span := tracing.Start(context.Background(), "webhook.deliver",
+		attribute.String("webhook.id", id),
+		attribute.String("webhook.event", event),
+		attribute.Int("webhook.attempt", attempt),
+	)
+	defer func() {
+		var err error
+		if delivery.Error != "" {
+			err = errors.New(delivery.Error)
+		}
+		tracing.End(span, err)
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(payload))
+	if err != nil {
+		delivery.Error = fmt.Sprintf("创建请求失败: %v", err)
+		return delivery
+	}
+
+	timestamp := strconv.FormatInt(start.Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookHeaderID, id)
+	req.Header.Set(WebhookHeaderEvent, event)
+	req.Header.Set(WebhookHeaderTimestamp, timestamp)
+	req.Header.Set(WebhookHeaderSignature, SignWebhook(s.config.Secret, timestamp, payload))
+
+	resp, err := s.config.Client.Do(req)
+	delivery.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		delivery.Error = fmt.Sprintf("请求失败: %v", err)
+		return delivery
+	}
+	defer resp.Body.Close()
+
+	// 响应内容不保存也不返回给任务提交方，只记录状态码
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseDrain))
+	delivery.StatusCode = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		delivery.Error = fmt.Sprintf("接收方返回状态码 %d", resp.StatusCode)
+	}
+	return delivery
+}
+
+// SignWebhook 计算回调签名：HMAC-SHA256(secret, timestamp + "." + body)，格式为 sha256=<hex>
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhook 校验回调签名，供接收方使用
+func VerifyWebhook(secret, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(SignWebhook(secret, timestamp, body)), []byte(signature))
+}
+
+// copyWebhook 复制回调及其投递记录
+func copyWebhook(webhook *Webhook) *Webhook {
+	copied := *webhook
+	copied.Deliveries = append([]WebhookDelivery{}, webhook.Deliveries...)
+	return &copied
+}
+
+// load 加载持久化的回调
+func (s *WebhookService) load() error {
+	if s.config.StorePath == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(s.config.StorePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("读取回调文件失败: %v", err)
+	}
+
+	var records []webhookRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return fmt.Errorf("解析回调文件失败: %v", err)
+	}
+
+	for i := range records {
+		webhook := records[i].Webhook
+		webhook.Payload = records[i].Payload
+		s.webhooks[webhook.ID] = &webhook
+	}
+	return nil
+}
+
+// saveLocked 清理过期的投递成功记录并写入文件，调用方需持有锁
+func (s *WebhookService) saveLocked() {
+	now := time.Now()
+	for id, webhook := range s.webhooks {
+		if webhook.Status == WebhookStatusDelivered && now.Sub(webhook.UpdatedAt) > s.config.HistoryTTL {
+			delete(s.webhooks, id)
+		}
+	}
+
+	if s.config.StorePath == "" {
+		return
+	}
+	if err := s.save(); err != nil {
+		slog.Error("保存回调文件失败", "error", err)
+	}
+}
+
+// save 将回调写入文件，调用方需持有锁
+func (s *WebhookService) save() error {
+	records := make([]webhookRecord, 0, len(s.webhooks))
+	for _, webhook := range s.webhooks {
+		records = append(records, webhookRecord{Webhook: *webhook, Payload: webhook.Payload})
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].CreatedAt.Before(records[j].CreatedAt)
+	})
+
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化回调失败: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.config.StorePath), 0o755); err != nil {
+		return fmt.Errorf("创建回调目录失败: %v", err)
+	}
+
+	// 先写临时文件再重命名，避免写入中断导致文件损坏
+	tmpPath := s.config.StorePath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o644); err != nil {
+		return fmt.Errorf("写入回调文件失败: %v", err)
+	}
+	if err := os.Rename(tmpPath, s.config.StorePath); err != nil {
+		return fmt.Errorf("写入回调文件失败: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,299 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testWebhookSecret = "test-secret"
+
+// webhookReceiver 本地回调接收方，前 failures 次返回500，之后返回200，并校验每次请求的签名
+type webhookReceiver struct {
+	server   *httptest.Server
+	failures int32
+	requests atomic.Int32
+
+	mutex   sync.Mutex
+	invalid []string
+	times   []time.Time
+}
+
+func newWebhookReceiver(t *testing.T, failures int32) *webhookReceiver {
+	t.Helper()
+	receiver := &webhookReceiver{failures: failures}
+	receiver.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		receiver.mutex.Lock()
+		receiver.times = append(receiver.times, time.Now())
+		if !VerifyWebhook(testWebhookSecret, r.Header.Get(WebhookHeaderTimestamp), body, r.Header.Get(WebhookHeaderSignature)) {
+			receiver.invalid = append(receiver.invalid, r.Header.Get(WebhookHeaderID))
+		}
+		receiver.mutex.Unlock()
+
+		if receiver.requests.Add(1) <= receiver.failures {
+			http.Error(w, "internal secret: db password", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(receiver.server.Close)
+	return receiver
+}
+
+// newTestWebhookService 创建使用临时文件的回调服务。httptest 监听本机地址，
+// 因此显式传入不检查地址的客户端
+func newTestWebhookService(t *testing.T, maxAttempts int) *WebhookService {
+	t.Helper()
+	s := NewWebhookServiceWithConfig(WebhookConfig{
+		Secret:         testWebhookSecret,
+		StorePath:      filepath.Join(t.TempDir(), "webhooks.json"),
+		MaxAttempts:    maxAttempts,
+		InitialBackoff: 20 * time.Millisecond,
+		MaxBackoff:     80 * time.Millisecond,
+		Client:         &http.Client{Timeout: 5 * time.Second},
+	})
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		s.Shutdown(ctx)
+	})
+	return s
+}
+
+// waitWebhook 等待回调离开 pending 状态
+func waitWebhook(t *testing.T, s *WebhookService, id string) *Webhook {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		webhook, err := s.Get(id)
+		if err != nil {
+			t.Fatalf("Get(%q) error: %v", id, err)
+		}
+		if webhook.Status != WebhookStatusPending {
+			return webhook
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("webhook %s still pending", id)
+	return nil
+}
+
+func finishedJob(id, callbackURL string) Job {
+	return Job{ID: id, Kind: "recipes", Status: JobStatusSucceeded, CallbackURL: callbackURL}
+}
+
+func TestWebhookDeliverySigned(t *testing.T) {
+	receiver := newWebhookReceiver(t, 0)
+	s := newTestWebhookService(t, 3)
+
+	s.JobFinished(finishedJob("job-signed", receiver.server.URL))
+	webhook := waitWebhook(t, s, "job-signed")
+
+	if webhook.Status != WebhookStatusDelivered || webhook.Attempts != 1 {
+		t.Fatalf("status = %s, attempts = %d, want delivered after 1 attempt", webhook.Status, webhook.Attempts)
+	}
+	if webhook.Event != "job.succeeded" {
+		t.Errorf("event = %q, want job.succeeded", webhook.Event)
+	}
+	receiver.mutex.Lock()
+	defer receiver.mutex.Unlock()
+	if len(receiver.invalid) > 0 {
+		t.Errorf("receiver rejected signatures for %v", receiver.invalid)
+	}
+}
+
+func TestVerifyWebhook(t *testing.T) {
+	body := []byte(`{"event":"job.succeeded"}`)
+	signature := SignWebhook(testWebhookSecret, "1700000000", body)
+
+	if !VerifyWebhook(testWebhookSecret, "1700000000", body, signature) {
+		t.Error("valid signature rejected")
+	}
+	if VerifyWebhook(testWebhookSecret, "1700000001", body, signature) {
+		t.Error("signature accepted with a different timestamp")
+	}
+	if VerifyWebhook("other-secret", "1700000000", body, signature) {
+		t.Error("signature accepted with a different secret")
+	}
+	if VerifyWebhook(testWebhookSecret, "1700000000", []byte(`{"event":"job.failed"}`), signature) {
+		t.Error("signature accepted for a different body")
+	}
+}
+
+func TestWebhookRetryWithBackoff(t *testing.T) {
+	receiver := newWebhookReceiver(t, 2)
+	s := newTestWebhookService(t, 5)
+
+	s.JobFinished(finishedJob("job-retry", receiver.server.URL))
+	webhook := waitWebhook(t, s, "job-retry")
+
+	if webhook.Status != WebhookStatusDelivered || webhook.Attempts != 3 {
+		t.Fatalf("status = %s, attempts = %d, want delivered after 3 attempts", webhook.Status, webhook.Attempts)
+	}
+	for i, delivery := range webhook.Deliveries[:2] {
+		if delivery.StatusCode != http.StatusInternalServerError || delivery.Error == "" {
+			t.Errorf("delivery %d = %+v, want failed with 500", i+1, delivery)
+		}
+		if strings.Contains(delivery.Error, "db password") {
+			t.Errorf("delivery %d leaks receiver response: %q", i+1, delivery.Error)
+		}
+	}
+	if last := webhook.Deliveries[2]; last.StatusCode != http.StatusOK || last.Error != "" {
+		t.Errorf("last delivery = %+v, want 200", last)
+	}
+
+	receiver.mutex.Lock()
+	defer receiver.mutex.Unlock()
+	// 第一次重试等待 20ms，第二次翻倍为 40ms
+	for i, want := range []time.Duration{20 * time.Millisecond, 40 * time.Millisecond} {
+		if got := receiver.times[i+1].Sub(receiver.times[i]); got < want {
+			t.Errorf("wait before attempt %d = %v, want at least %v", i+2, got, want)
+		}
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	s := &WebhookService{config: WebhookConfig{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}}
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 5 * time.Second},
+		{10, 5 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := s.backoff(tt.attempt); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestWebhookDeadLetterAndRedeliver(t *testing.T) {
+	receiver := newWebhookReceiver(t, 2)
+	s := newTestWebhookService(t, 2)
+
+	s.JobFinished(finishedJob("job-dead", receiver.server.URL))
+	webhook := waitWebhook(t, s, "job-dead")
+	if webhook.Status != WebhookStatusDead || webhook.Attempts != 2 {
+		t.Fatalf("status = %s, attempts = %d, want dead after 2 attempts", webhook.Status, webhook.Attempts)
+	}
+	if dead := s.List(WebhookStatusDead); len(dead) != 1 || dead[0].ID != "job-dead" {
+		t.Fatalf("List(dead) = %v, want [job-dead]", dead)
+	}
+
+	if _, err := s.Redeliver("job-dead"); err != nil {
+		t.Fatalf("Redeliver error: %v", err)
+	}
+	webhook = waitWebhook(t, s, "job-dead")
+	if webhook.Status != WebhookStatusDelivered {
+		t.Fatalf("status after redeliver = %s, want delivered", webhook.Status)
+	}
+	if len(webhook.Deliveries) != 3 {
+		t.Errorf("deliveries = %d, want 3", len(webhook.Deliveries))
+	}
+	if _, err := s.Redeliver("missing"); !errors.Is(err, ErrWebhookNotFound) {
+		t.Errorf("Redeliver(missing) error = %v, want ErrWebhookNotFound", err)
+	}
+}
+
+func TestWebhookRestoresPendingAfterRestart(t *testing.T) {
+	receiver := newWebhookReceiver(t, 0)
+	storePath := filepath.Join(t.TempDir(), "webhooks.json")
+	config := WebhookConfig{
+		Secret:         testWebhookSecret,
+		StorePath:      storePath,
+		InitialBackoff: time.Hour,
+		Client:         &http.Client{Timeout: 5 * time.Second},
+	}
+
+	// 服务关闭后不再投递，回调以 pending 状态保存
+	first := NewWebhookServiceWithConfig(config)
+	first.Shutdown(context.Background())
+	first.JobFinished(finishedJob("job-restart", receiver.server.URL))
+
+	second := NewWebhookServiceWithConfig(config)
+	defer second.Shutdown(context.Background())
+	if webhook := waitWebhook(t, second, "job-restart"); webhook.Status != WebhookStatusDelivered {
+		t.Fatalf("status = %s, want delivered", webhook.Status)
+	}
+}
+
+func TestWebhookBlocksPrivateAddresses(t *testing.T) {
+	s := NewWebhookServiceWithConfig(WebhookConfig{Secret: testWebhookSecret})
+	defer s.Shutdown(context.Background())
+
+	blocked := []string{
+		"http://localhost:8080/hook",
+		"http://api.localhost/hook",
+		"http://127.0.0.1/hook",
+		"http://10.0.0.5/hook",
+		"http://172.16.3.4/hook",
+		"http://192.168.1.1/hook",
+		"http://169.254.169.254/latest/meta-data/",
+		"http://100.64.0.1/hook",
+		"http://0.0.0.0/hook",
+		"http://[::1]/hook",
+		"http://[fd00::1]/hook",
+		"http://[fe80::1]/hook",
+		"http://[::ffff:127.0.0.1]/hook",
+	}
+	for _, target := range blocked {
+		if err := s.ValidateURL(target); !errors.Is(err, ErrWebhookBlockedAddress) {
+			t.Errorf("ValidateURL(%q) = %v, want ErrWebhookBlockedAddress", target, err)
+		}
+	}
+	for _, target := range []string{"ftp://example.com/hook", "/hook", "http://"} {
+		if err := s.ValidateURL(target); !errors.Is(err, ErrWebhookInvalidURL) {
+			t.Errorf("ValidateURL(%q) = %v, want ErrWebhookInvalidURL", target, err)
+		}
+	}
+	for _, target := range []string{"https://example.com/hook", "http://8.8.8.8:8080/hook"} {
+		if err := s.ValidateURL(target); err != nil {
+			t.Errorf("ValidateURL(%q) = %v, want nil", target, err)
+		}
+	}
+
+	allowed := NewWebhookServiceWithConfig(WebhookConfig{Secret: testWebhookSecret, AllowPrivateNetworks: true})
+	defer allowed.Shutdown(context.Background())
+	if err := allowed.ValidateURL("http://10.0.0.5/hook"); err != nil {
+		t.Errorf("ValidateURL with AllowPrivateNetworks = %v, want nil", err)
+	}
+}
+
+func TestWebhookClientRefusesPrivateConnections(t *testing.T) {
+	receiver := newWebhookReceiver(t, 0)
+	// 默认客户端在拨号时检查地址，域名解析到本机或内网时同样会被拒绝
+	s := NewWebhookServiceWithConfig(WebhookConfig{Secret: testWebhookSecret, MaxAttempts: 1})
+	defer s.Shutdown(context.Background())
+
+	s.JobFinished(finishedJob("job-private", receiver.server.URL))
+	webhook := waitWebhook(t, s, "job-private")
+	if webhook.Status != WebhookStatusDead {
+		t.Fatalf("status = %s, want dead", webhook.Status)
+	}
+	if got := webhook.Deliveries[0].Error; !strings.Contains(got, ErrWebhookBlockedAddress.Error()) {
+		t.Errorf("delivery error = %q, want blocked address", got)
+	}
+	if receiver.requests.Load() != 0 {
+		t.Errorf("receiver got %d requests, want 0", receiver.requests.Load())
+	}
+
+	client := NewWebhookClient(time.Second, true)
+	resp, err := client.Post(receiver.server.URL, "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatalf("client allowing private networks: %v", err)
+	}
+	resp.Body.Close()
+}
@@ -0,0 +1,97 @@
+package tracing
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// defaultMemorySpans 内存导出器默认保留的span数
+const defaultMemorySpans = 1000
+
+// SpanRecord 内存导出器保存的span
+type SpanRecord struct {
+	TraceID      string            `json:"traceId"`
+	SpanID       string            `json:"spanId"`
+	ParentSpanID string            `json:"parentSpanId,omitempty"`
+	Name         string            `json:"name"`
+	Kind         string            `json:"kind"`
+	StartTime    time.Time         `json:"startTime"`
+	DurationMs   float64           `json:"durationMs"`
+	Status       string            `json:"status" enum:"Unset,Error,Ok"`
+	Error        string            `json:"error,omitempty"`
+	Attributes   map[string]string `json:"attributes,omitempty"`
+}
+
+// MemoryExporter 在内存中保留最近的span，供本地调试时通过管理接口查看
+type MemoryExporter struct {
+	limit int
+	spans []SpanRecord
+	mutex sync.Mutex
+}
+
+// NewMemoryExporter 创建最多保留limit个span的内存导出器，limit不大于0时使用默认值
+func NewMemoryExporter(limit int) *MemoryExporter {
+	if limit <= 0 {
+		limit = defaultMemorySpans
+	}
+	return &MemoryExporter{limit: limit}
+}
+
+// ExportSpans 实现 sdktrace.SpanExporter，超出上限时丢弃最早的span
+func (e *MemoryExporter) ExportSpans(_ context.Context, spans []sdktrace.ReadOnlySpan) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	for _, span := range spans {
+		record := SpanRecord{
+			TraceID:    span.SpanContext().TraceID().String(),
+			SpanID:     span.SpanContext().SpanID().String(),
+			Name:       span.Name(),
+			Kind:       span.SpanKind().String(),
+			StartTime:  span.StartTime(),
+			DurationMs: float64(span.EndTime().Sub(span.StartTime()).Microseconds()) / 1000,
+			Status:     span.Status().Code.String(),
+			Error:      span.Status().Description,
+		}
+		if span.Parent().IsValid() {
+			record.ParentSpanID = span.Parent().SpanID().String()
+		}
+		if attrs := span.Attributes(); len(attrs) > 0 {
+			record.Attributes = make(map[string]string, len(attrs))
+			for _, attr := range attrs {
+				record.Attributes[string(attr.Key)] = attr.Value.Emit()
+			}
+		}
+		e.spans = append(e.spans, record)
+	}
+	if overflow := len(e.spans) - e.limit; overflow > 0 {
+		e.spans = append([]SpanRecord{}, e.spans[overflow:]...)
+	}
+	return nil
+}
+
+// Shutdown 实现 sdktrace.SpanExporter
+func (e *MemoryExporter) Shutdown(context.Context) error {
+	return nil
+}
+
+// Spans 返回保存的span，traceID非空时只返回该链路的span，按开始时间排列
+func (e *MemoryExporter) Spans(traceID string) []SpanRecord {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	spans := make([]SpanRecord, 0, len(e.spans))
+	for _, span := range e.spans {
+		if traceID == "" || span.TraceID == traceID {
+			spans = append(spans, span)
+		}
+	}
+	sort.SliceStable(spans, func(i, j int) bool {
+		return spans[i].StartTime.Before(spans[j].StartTime)
+	})
+	return spans
+}
@@ -0,0 +1,130 @@
+// Package tracing 配置 OpenTelemetry 链路追踪。Setup 按配置创建导出器并注册全局 TracerProvider，
+// 各服务通过 Start 创建子span，出站HTTP请求使用 Transport 包装后自动生成span并传播上下文
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"recipe-agent/internal/config"
+)
+
+// 导出器类型
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+	ExporterMemory = "memory"
+)
+
+// instrumentationName 服务内创建span使用的tracer名称
+const instrumentationName = "recipe-agent"
+
+// Provider 链路追踪的运行状态
+type Provider struct {
+	provider *sdktrace.TracerProvider
+	// Memory 使用内存导出器时保存最近的span，其他导出器为nil
+	Memory *MemoryExporter
+}
+
+// Setup 按配置创建导出器并注册全局 TracerProvider 和 W3C Trace Context 传播器；
+// 导出器为 none 时不导出，但仍会传播上游传入的追踪上下文
+func Setup(cfg config.Tracing, version string) (*Provider, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	p := &Provider{}
+	var exporter sdktrace.SpanExporter
+	switch cfg.Exporter {
+	case ExporterNone:
+		return p, nil
+	case ExporterOTLP:
+		var err error
+		exporter, err = otlptracehttp.New(context.Background(), otlptracehttp.WithEndpointURL(cfg.Endpoint))
+		if err != nil {
+			return nil, fmt.Errorf("创建OTLP导出器失败: %v", err)
+		}
+	case ExporterStdout:
+		var err error
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		if err != nil {
+			return nil, fmt.Errorf("创建stdout导出器失败: %v", err)
+		}
+	case ExporterMemory:
+		p.Memory = NewMemoryExporter(cfg.MemorySpans)
+		exporter = p.Memory
+	default:
+		return nil, fmt.Errorf("未知的导出器: %s", cfg.Exporter)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+		semconv.ServiceVersion(version),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("创建追踪资源失败: %v", err)
+	}
+
+	p.provider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(p.provider)
+	return p, nil
+}
+
+// Shutdown 导出缓冲区中剩余的span并关闭导出器
+func (p *Provider) Shutdown(ctx context.Context) error {
+	if p.provider == nil {
+		return nil
+	}
+	return p.provider.Shutdown(ctx)
+}
+
+// Start 创建子span，ctx 中没有父span时创建新的链路
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End 结束span，err 非空时记录错误并将span标记为失败
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Transport 包装出站请求的 RoundTripper，为每个请求生成客户端span并注入 traceparent 请求头；base 为nil时使用默认传输
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return otelhttp.NewTransport(base, otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+		return "HTTP " + r.Method + " " + r.URL.Host
+	}))
+}
+
+// TraceID 返回上下文中的链路ID，没有有效的链路时返回空字符串
+func TraceID(ctx context.Context) string {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.HasTraceID() {
+		return ""
+	}
+	return spanContext.TraceID().String()
+}
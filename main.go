@@ -1,29 +1,115 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"log/slog"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 
+	"recipe-agent/internal/buildinfo"
+	"recipe-agent/internal/config"
 	"recipe-agent/internal/handlers"
+	"recipe-agent/internal/health"
+	"recipe-agent/internal/logging"
+	"recipe-agent/internal/metrics"
+	"recipe-agent/internal/openapi"
 	"recipe-agent/internal/services"
+	"recipe-agent/internal/tracing"
 )
 
 func main() {
-	// 加载环境变量
+	// 加载.env文件，其中的变量与系统环境变量一样覆盖配置文件
 	if err := godotenv.Load(); err != nil {
 		log.Println("未找到.env文件，将使用系统环境变量")
 	}
 
-	// 设置Gin模式
-	if os.Getenv("GIN_MODE") == "" {
-		gin.SetMode(gin.ReleaseMode)
+	// recipe-agent config print [--config 文件]：输出生效的配置
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		os.Exit(runConfigCommand(os.Args[2:]))
+	}
+
+	flags := flag.NewFlagSet("recipe-agent", flag.ExitOnError)
+	configPath := configFlag(flags)
+	flags.Parse(os.Args[1:])
+
+	// 配置无效时直接退出，不启动服务
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatalf("配置无效:\n%v", err)
+	}
+	// 之后的日志（包括标准库 log 的输出）统一为结构化格式，并隐藏配置中的密钥
+	logging.Setup(cfg.Log, cfg.Secrets()...)
+
+	serve(cfg)
+}
+
+// configFlag 注册 --config 参数，默认使用 CONFIG_FILE 环境变量
+func configFlag(flags *flag.FlagSet) *string {
+	return flags.String("config", os.Getenv("CONFIG_FILE"), "配置文件路径（.yaml、.yml 或 .toml），环境变量优先于文件中的设置")
+}
+
+// runConfigCommand 执行 config 子命令，返回进程退出码
+func runConfigCommand(args []string) int {
+	if len(args) == 0 || args[0] != "print" {
+		fmt.Fprintln(os.Stderr, "用法: recipe-agent config print [--config 文件]")
+		return 2
+	}
+
+	flags := flag.NewFlagSet("config print", flag.ExitOnError)
+	configPath := configFlag(flags)
+	flags.Parse(args[1:])
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "配置无效:\n%v\n", err)
+		return 1
+	}
+
+	output, err := cfg.YAML()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "输出配置失败: %v\n", err)
+		return 1
+	}
+	if *configPath != "" {
+		fmt.Printf("# 配置文件: %s\n", *configPath)
+	}
+	fmt.Println("# 生效的配置（默认值 → 配置文件 → 环境变量），密钥已隐藏")
+	os.Stdout.Write(output)
+	return 0
+}
+
+// serve 按配置创建服务并启动HTTP服务器，收到 SIGINT 或 SIGTERM 后优雅关闭
+func serve(cfg *config.Config) {
+	gin.SetMode(cfg.Server.Mode)
+
+	// 链路追踪，导出器为 none 时只传播上游传入的追踪上下文
+	tracingProvider, err := tracing.Setup(cfg.Tracing, buildinfo.Get().Version)
+	if err != nil {
+		slog.Error("链路追踪初始化失败", "error", err)
+		os.Exit(1)
 	}
 
 	// 创建Gin路由器
-	r := gin.Default()
+	r := gin.New()
+	r.Use(handlers.RequestID())
+	if cfg.Metrics.Enabled {
+		r.Use(handlers.Metrics())
+	}
+	r.Use(handlers.Tracing(cfg.Tracing.ServiceName, "/healthz", "/readyz", cfg.Metrics.Path)...)
+	// 访问日志位于追踪之后，日志中附带链路ID；健康检查和指标抓取只在debug级别记录
+	r.Use(handlers.AccessLog("/healthz", "/readyz", "/api/v1/health", "/api/health", cfg.Metrics.Path))
+	r.Use(handlers.Recovery())
 
 	// 加载HTML模板
 	r.LoadHTMLGlob("templates/*")
@@ -32,25 +118,130 @@ func main() {
 	r.Static("/static", "./static")
 
 	// 依赖注入
-	recipeService := services.NewRecipeService()
-	aiService := services.NewAIService()
-	agentHandler := handlers.NewAgentHandler(recipeService, aiService)
+	ontology := services.NewIngredientOntology(cfg.Data)
+	translationMemory := services.NewTranslationMemory(cfg.Translation)
+	// AI和翻译调用同一个DeepSeek接口，共用熔断器
+	deepSeekBreaker := services.NewBreaker(metrics.ProviderDeepSeek, cfg.Breaker)
+	spoonacularBreaker := services.NewBreaker(metrics.ProviderSpoonacular, cfg.Breaker)
+	translationService := services.NewTranslationService(cfg.DeepSeek, cfg.Translation, ontology, translationMemory, deepSeekBreaker)
+	recipeService := services.NewRecipeService(cfg.Spoonacular, translationService, ontology, spoonacularBreaker)
+	aiService := services.NewAIService(cfg.DeepSeek, deepSeekBreaker)
+	suggestService := services.NewSuggestService(cfg.Data, ontology, translationMemory)
+	correctionService := services.NewCorrectionService(cfg.Data, ontology)
+	agentHandler := handlers.NewAgentHandler(cfg.Batch, recipeService, aiService, ontology, suggestService, correctionService)
+	suggestHandler := handlers.NewSuggestHandler(suggestService)
+	translationHandler := handlers.NewTranslationHandler(translationService)
+	jobService := services.NewJobService(cfg.Jobs)
+	webhookService := services.NewWebhookService(cfg.Webhooks)
+	jobHandler := handlers.NewJobHandler(jobService, webhookService, agentHandler)
+	jobService.Start()
+	intentService := services.NewIntentService(cfg.Data, ontology, aiService)
+	askHandler := handlers.NewAskHandler(intentService, agentHandler)
+	traceHandler := handlers.NewTraceHandler(tracingProvider.Memory)
+	adminHandler := handlers.NewAdminHandler(cfg.Batch, recipeService, translationService, jobService, deepSeekBreaker, spoonacularBreaker)
+
+	// 抓取指标时读取缓存和任务工作池的状态
+	metrics.RegisterCache("recipe", recipeService.GetCacheStatus)
+	metrics.RegisterCache("translation", translationService.GetCacheStatus)
+	metrics.RegisterPool("jobs", jobService.PoolStatus)
+
+	readiness := handlers.NewReadiness()
+	// 就绪检查：持久化目录和任务工作池为关键检查，上游API不可达时只降级
+	checker := health.NewChecker(cfg.Health.ProbeTimeout.Duration,
+		health.WritableFiles("store", cfg.Jobs.StoreFile, cfg.Webhooks.StoreFile, cfg.Translation.MemoryFile),
+		health.Pool("jobs", jobService.PoolStatus),
+		health.Caches("cache", map[string]func() metrics.CacheStatus{
+			"recipe":      recipeService.GetCacheStatus,
+			"translation": translationService.GetCacheStatus,
+		}),
+		health.HTTPProbe("deepseek", cfg.DeepSeek.BaseURL, cfg.DeepSeek.APIKey != "", cfg.Health.ProbeInterval.Duration),
+		health.HTTPProbe("spoonacular", cfg.Spoonacular.BaseURL, cfg.Spoonacular.APIKey != "", cfg.Health.ProbeInterval.Duration),
+	)
 
 	// 路由定义
 	r.GET("/", handlers.IndexHandler)
-	r.POST("/api/recipes", agentHandler.GetRecipes)
-	r.GET("/api/health", handlers.HealthHandler)
+	if cfg.Metrics.Enabled {
+		r.GET(cfg.Metrics.Path, gin.WrapH(metrics.Handler()))
+	}
+	spec := openapi.NewGenerator("智能食谱助手 API", "根据食材推荐菜品或根据菜名提供详细制作方法。", "1.0.0")
+	handlers.RegisterAPIRoutes(r, spec, cfg, agentHandler, suggestHandler, translationHandler, jobHandler, askHandler, traceHandler, adminHandler, readiness, checker)
+
+	server := &http.Server{
+		Addr:         ":" + cfg.Server.Port,
+		Handler:      r,
+		ReadTimeout:  cfg.Server.ReadTimeout.Duration,
+		WriteTimeout: cfg.Server.WriteTimeout.Duration,
+		IdleTimeout:  cfg.Server.IdleTimeout.Duration,
+	}
+
+	// 先监听端口，端口被占用时直接退出
+	listener, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		slog.Error("服务器启动失败", "error", err)
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.Serve(listener)
+	}()
+	readiness.SetReady(true)
+
+	build := buildinfo.Get()
+	slog.Info("智能食谱助手服务已启动", "port", cfg.Server.Port, "url", "http://localhost:"+cfg.Server.Port, "version", build.Version, "commit", build.Commit, "build_time", build.BuildTime)
 
-	// 启动服务器
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	exitCode := 0
+	select {
+	case <-ctx.Done():
+		slog.Info("收到退出信号，开始优雅关闭")
+	case err := <-serveErr:
+		slog.Error("服务器运行失败", "error", err)
+		exitCode = 1
 	}
+	// 再次收到信号时按默认行为立即退出
+	stop()
 
-	log.Printf("智能食谱助手服务启动在端口 %s", port)
-	log.Printf("访问地址: http://localhost:%s", port)
+	shutdown(cfg.Server, server, readiness, jobService, webhookService, translationMemory, tracingProvider)
+	os.Exit(exitCode)
+}
 
-	if err := r.Run(":" + port); err != nil {
-		log.Fatalf("服务器启动失败: %v", err)
+// shutdown 优雅关闭：先标记为未就绪并等待负载均衡器摘除实例，再停止接受新连接，
+// 依次等待处理中的请求、后台任务和回调投递完成，最后写入持久化数据并导出剩余的span
+func shutdown(cfg config.Server, server *http.Server, readiness *handlers.Readiness, jobService *services.JobService, webhookService *services.WebhookService, translationMemory *services.TranslationMemory, tracingProvider *tracing.Provider) {
+	readiness.SetReady(false)
+	if cfg.ShutdownDelay.Duration > 0 {
+		slog.Info("已标记为未就绪，等待后停止接受新连接", "delay", cfg.ShutdownDelay.String())
+		time.Sleep(cfg.ShutdownDelay.Duration)
 	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout.Duration)
+	defer cancel()
+
+	slog.Info("等待处理中的请求完成", "timeout", cfg.ShutdownTimeout.String())
+	if err := server.Shutdown(ctx); err != nil {
+		slog.Warn("处理中的请求未能按时完成，强制断开连接", "error", err)
+		server.Close()
+	}
+
+	if err := jobService.Shutdown(ctx); errors.Is(err, context.DeadlineExceeded) {
+		slog.Warn("后台任务未能按时完成，已中断并保存，下次启动时重新执行")
+	} else if err != nil {
+		slog.Error("关闭任务服务失败", "error", err)
+	}
+	if err := webhookService.Shutdown(ctx); errors.Is(err, context.DeadlineExceeded) {
+		slog.Warn("回调投递未能按时完成，下次启动时继续投递")
+	} else if err != nil {
+		slog.Error("关闭回调服务失败", "error", err)
+	}
+	if err := translationMemory.Close(); err != nil {
+		slog.Error("保存翻译记忆失败", "error", err)
+	}
+	if err := tracingProvider.Shutdown(ctx); err != nil {
+		slog.Warn("导出剩余的链路数据失败", "error", err)
+	}
+
+	slog.Info("服务已关闭")
 }
\ No newline at end of file
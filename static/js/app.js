@@ -385,17 +385,30 @@ class RecipeAssistant {
     }
 
     // 自动补全功能
-    updateAutocomplete(value) {
+    async updateAutocomplete(value) {
         if (!value.trim()) {
             this.hideAutocomplete();
             return;
         }
 
+        const requestId = (this.autocompleteRequestId || 0) + 1;
+        this.autocompleteRequestId = requestId;
+
         let suggestions = [];
-        if (this.currentSearchType === 'ingredients') {
-            suggestions = this.getIngredientSuggestions(value);
-        } else {
-            suggestions = this.getDishSuggestions(value);
+        try {
+            suggestions = await this.fetchSuggestions(value);
+        } catch (error) {
+            // 服务端补全不可用时使用本地列表
+            if (this.currentSearchType === 'ingredients') {
+                suggestions = this.getIngredientSuggestions(value);
+            } else {
+                suggestions = this.getDishSuggestions(value);
+            }
+        }
+
+        // 忽略过期的补全结果
+        if (requestId !== this.autocompleteRequestId) {
+            return;
         }
 
         if (suggestions.length > 0) {
@@ -405,6 +418,29 @@ class RecipeAssistant {
         }
     }
 
+    // 从服务端获取补全建议（支持拼音首字母和全拼）
+    async fetchSuggestions(value) {
+        const isIngredients = this.currentSearchType === 'ingredients';
+        // 食材模式下只补全最后一个食材
+        const parts = value.split(/[、，,\s]+/);
+        const query = isIngredients ? parts[parts.length - 1] : value.trim();
+        if (!query) {
+            return [];
+        }
+
+        const type = isIngredients ? 'ingredient' : 'dish';
+        const response = await fetch(`/api/v1/suggest?type=${type}&q=${encodeURIComponent(query)}&limit=6`);
+        if (!response.ok) {
+            throw new Error(`补全请求失败: ${response.status}`);
+        }
+
+        const data = await response.json();
+        const current = isIngredients ? this.extractIngredients(this.smartSearchInput.value) : [];
+        return data.suggestions
+            .map(item => item.text)
+            .filter(text => !current.includes(text));
+    }
+
     // 获取食材建议
     getIngredientSuggestions(value) {
         const allIngredients = [
@@ -451,6 +487,10 @@ class RecipeAssistant {
     selectSuggestion(suggestion) {
         if (this.currentSearchType === 'ingredients') {
             const currentIngredients = this.extractIngredients(this.smartSearchInput.value);
+            // 用所选建议替换正在输入的最后一个食材（如拼音 xhs → 西红柿）
+            if (!/[、，,\s]$/.test(this.smartSearchInput.value)) {
+                currentIngredients.pop();
+            }
             if (!currentIngredients.includes(suggestion)) {
                 currentIngredients.push(suggestion);
                 this.smartSearchInput.value = currentIngredients.join('、');
@@ -577,7 +617,7 @@ class RecipeAssistant {
     }
 
     
-    // 执行搜索
+    // 执行搜索：由服务端解析输入的意图（查询类型、食材用量和附加要求）
     async performSearch() {
         const value = this.smartSearchInput.value.trim();
         if (!value) {
@@ -585,43 +625,30 @@ class RecipeAssistant {
             return;
         }
 
-        let requestData = {};
-
-        if (this.currentSearchType === 'ingredients') {
-            const ingredients = this.extractIngredients(value);
-            if (ingredients.length === 0) {
-                this.showError('请输入有效的食材');
-                return;
-            }
-            requestData = {
-                queryType: 'ingredients',
-                ingredients: ingredients
-            };
-        } else {
-            requestData = {
-                queryType: 'dish',
-                dishName: value
-            };
-        }
-
         this.showLoading();
 
         try {
-            const response = await fetch('/api/recipes', {
+            const response = await fetch('/api/v1/ask', {
                 method: 'POST',
                 headers: {
                     'Content-Type': 'application/json',
                 },
-                body: JSON.stringify(requestData)
+                body: JSON.stringify({ text: value })
             });
 
             const data = await response.json();
 
             if (!response.ok || !data.success) {
-                throw new Error(data.message || '请求失败');
+                throw new Error(this.formatProblem(data));
             }
 
-            this.showResult(data);
+            // 按服务端的解析结果显示，并提示解析摘要供用户确认
+            this.lastRequest = data.request;
+            this.currentSearchType = data.request.queryType === 'ingredients' ? 'ingredients' : 'dish';
+            this.updateTypeIndicator();
+            this.showToast(`已理解为：${this.escapeHTML(data.interpretation.summary)}`, 'info');
+
+            this.showResult(data.response);
         } catch (error) {
             console.error('搜索错误:', error);
             this.showError(error.message || '搜索过程中出现错误，请稍后重试');
@@ -630,6 +657,13 @@ class RecipeAssistant {
         }
     }
 
+    // 转义HTML特殊字符，引号也会转义，结果可用于属性值
+    escapeHTML(text) {
+        const div = document.createElement('div');
+        div.textContent = text;
+        return div.innerHTML.replace(/"/g, '&quot;').replace(/'/g, '&#39;');
+    }
+
     // 显示加载状态
     showLoading() {
         this.hideAllContainers();
@@ -753,6 +787,12 @@ class RecipeAssistant {
         this.serveCount.textContent = this.getQueryTypeLabel(data.type);
         this.timestamp.textContent = this.formatTimestamp(data.timestamp);
 
+        // 提示服务端自动纠正的名称
+        if (data.corrections && data.corrections.length > 0) {
+            const corrected = data.corrections.map(c => `${c.original} → ${c.corrected}`).join('、');
+            this.showToast(`已自动纠正：${this.escapeHTML(corrected)}`, 'info');
+        }
+
         // 创建卡片式结果
         this.createRecipeCards(data);
 
@@ -778,7 +818,11 @@ class RecipeAssistant {
     showSupplementaryData(data) {
         let content = '<div class="row">';
 
-        if (data.ai_available) {
+        const sources = data.sources || {};
+        const ai = sources.ai || {};
+        const spoonacular = sources.spoonacular || {};
+
+        if (ai.available) {
             content += `
                 <div class="col-md-6 mb-3">
                     <div class="card border-success">
@@ -786,14 +830,14 @@ class RecipeAssistant {
                             <h6 class="card-title text-success">
                                 <i class="bi bi-robot"></i> AI分析
                             </h6>
-                            <p class="card-text small">智能食谱分析和个性化推荐已启用</p>
+                            <p class="card-text small">${ai.errorCode ? this.getSourceErrorLabel(ai.errorCode) : '智能食谱分析和个性化推荐已启用'}</p>
                         </div>
                     </div>
                 </div>
             `;
         }
 
-        if (data.api_available) {
+        if (spoonacular.available) {
             content += `
                 <div class="col-md-6 mb-3">
                     <div class="card border-info">
@@ -801,28 +845,41 @@ class RecipeAssistant {
                             <h6 class="card-title text-info">
                                 <i class="bi bi-database"></i> 数据源
                             </h6>
-                            <p class="card-text small">${data.nutrition_tips || '专业食谱数据支持'}</p>
+                            <p class="card-text small">获得${data.referenceCount}个食谱参考${spoonacular.cacheHit ? '（缓存）' : ''}</p>
+                        </div>
+                    </div>
+                </div>
+            `;
+        } else if (spoonacular.errorCode) {
+            content += `
+                <div class="col-md-6 mb-3">
+                    <div class="card border-warning">
+                        <div class="card-body">
+                            <h6 class="card-title text-warning">
+                                <i class="bi bi-database"></i> 数据源
+                            </h6>
+                            <p class="card-text small">${this.getSourceErrorLabel(spoonacular.errorCode)}</p>
                         </div>
                     </div>
                 </div>
             `;
         }
 
-        if (data.api_recipes && data.api_recipes.length > 0) {
+        if (data.referenceRecipes && data.referenceRecipes.length > 0) {
             content += `
                 <div class="col-12">
                     <h6 class="text-muted mb-3">
-                        <i class="bi bi-book"></i> 参考食谱 (${data.api_recipes.length}个)
+                        <i class="bi bi-book"></i> 参考食谱 (${data.referenceRecipes.length}个)
                     </h6>
                     <div class="row">
             `;
 
-            data.api_recipes.forEach((recipe, index) => {
+            data.referenceRecipes.forEach((recipe, index) => {
                 content += `
                     <div class="col-md-4 mb-3">
                         <div class="card">
                             <div class="card-body">
-                                <h6 class="card-title">${recipe.title}</h6>
+                                <h6 class="card-title">${this.escapeHTML(recipe.title)}</h6>
                                 ${recipe.readyInMinutes ? `<p class="card-text small"><i class="bi bi-clock"></i> ${recipe.readyInMinutes}分钟</p>` : ''}
                                 ${recipe.servings ? `<p class="card-text small"><i class="bi bi-people"></i> ${recipe.servings}人份</p>` : ''}
                             </div>
@@ -840,45 +897,46 @@ class RecipeAssistant {
         this.supplementaryInfo.style.display = 'block';
     }
 
-    // 格式化内容
-    formatContent(content) {
-        if (!content) return '<p>暂无内容</p>';
-
-        // 处理Markdown格式
-        let formatted = content
-            // 处理标题
-            .replace(/^### (.+)$/gm, '<h3>$1</h3>')
-            .replace(/^## (.+)$/gm, '<h2>$1</h2>')
-            .replace(/^# (.+)$/gm, '<h1>$1</h1>')
-            // 处理粗体
-            .replace(/\*\*(.+?)\*\*/g, '<strong>$1</strong>')
-            // 处理斜体
-            .replace(/\*(.+?)\*/g, '<em>$1</em>')
-            // 处理列表
-            .replace(/^- (.+)$/gm, '<li>$1</li>')
-            .replace(/^\d+\. (.+)$/gm, '<li>$1</li>')
-            // 处理换行
-            .replace(/\n\n/g, '</p><p>')
-            .replace(/\n/g, '<br>');
-
-        // 包装列表
-        formatted = formatted.replace(/(<li>.*?<\/li>)/gs, (match) => {
-            if (!match.includes('<ul>') && !match.includes('<ol>')) {
-                if (/^\d+/.test(match)) {
-                    return `<ol>${match}</ol>`;
-                } else {
-                    return `<ul>${match}</ul>`;
-                }
-            }
-            return match;
-        });
-
-        // 添加段落标签
-        if (!formatted.startsWith('<h') && !formatted.startsWith('<')) {
-            formatted = `<p>${formatted}</p>`;
+    // 将 problem+json 错误响应转换为提示文案
+    formatProblem(problem) {
+        let message = problem.title || '请求失败';
+        if (problem.errors && problem.errors.length > 0) {
+            message += '：' + problem.errors.map(e => {
+                const hint = e.suggestions && e.suggestions.length > 0 ? `（${e.suggestions.join('、')}）` : '';
+                return `${e.field} ${e.message}${hint}`;
+            }).join('；');
+        } else if (problem.detail) {
+            message += '：' + problem.detail;
         }
+        if (problem.requestId) {
+            message += `（请求ID: ${problem.requestId}）`;
+        }
+        return message;
+    }
+
+    // 数据源降级原因
+    getSourceErrorLabel(code) {
+        const labels = {
+            not_configured: '未配置API密钥，显示基础内容',
+            low_confidence: '翻译置信度较低，未查询参考食谱',
+            request_failed: '服务请求失败',
+            upstream_status: '服务返回错误',
+            invalid_response: '服务响应无法解析',
+            empty_response: '服务返回空结果',
+            timeout: '服务响应超时'
+        };
+        return labels[code] || '服务暂不可用';
+    }
+
+    // 显示结果内容：使用服务端渲染并过滤后的HTML，没有时按纯文本显示
+    formatContent(data) {
+        if (data.resultHtml) return data.resultHtml;
+        if (!data.result) return '<p>暂无内容</p>';
 
-        return formatted;
+        const pre = document.createElement('pre');
+        pre.className = 'result-text';
+        pre.textContent = data.result;
+        return pre.outerHTML;
     }
 
     // 显示错误
@@ -939,8 +997,8 @@ class RecipeAssistant {
         this.recipeCardsContainer.appendChild(detailCard);
 
         // 如果有API食谱，创建食谱卡片数组
-        if (data.supplementaryData && data.supplementaryData.api_recipes) {
-            data.supplementaryData.api_recipes.forEach((recipe, index) => {
+        if (data.supplementaryData && data.supplementaryData.referenceRecipes) {
+            data.supplementaryData.referenceRecipes.forEach((recipe, index) => {
                 const recipeCard = this.createApiRecipeCard(recipe);
                 this.recipeCardsContainer.appendChild(recipeCard);
             });
@@ -952,9 +1010,10 @@ class RecipeAssistant {
         const card = document.createElement('div');
         card.className = 'recipe-card';
 
-        const ingredients = this.currentSearchType === 'ingredients'
-            ? this.extractIngredients(this.smartSearchInput.value).join('、')
-            : this.smartSearchInput.value;
+        const request = this.lastRequest || {};
+        const ingredients = this.escapeHTML([request.dishName, ...(request.ingredients || []), request.question]
+            .filter(Boolean)
+            .join('、'));
 
         card.innerHTML = `
             <div class="recipe-card-image">
@@ -1033,11 +1092,11 @@ class RecipeAssistant {
 
         card.innerHTML = `
             <div class="recipe-card-image">
-                <img src="https://via.placeholder.com/320x200/FFA726/ffffff?text=${encodeURIComponent(recipe.title)}" alt="${recipe.title}">
+                <img src="https://via.placeholder.com/320x200/FFA726/ffffff?text=${encodeURIComponent(recipe.title)}" alt="${this.escapeHTML(recipe.title)}">
                 ${recipe.readyInMinutes ? `<div class="recipe-card-badge">${recipe.readyInMinutes}分钟</div>` : ''}
             </div>
             <div class="recipe-card-content">
-                <h3 class="recipe-card-title">${recipe.title}</h3>
+                <h3 class="recipe-card-title">${this.escapeHTML(recipe.title)}</h3>
                 <div class="recipe-card-meta">
                     ${recipe.readyInMinutes ? `
                         <div class="recipe-card-meta-item">
@@ -1091,7 +1150,7 @@ class RecipeAssistant {
     // 显示详细结果
     showDetailedResult(data) {
         this.resultDetails.style.display = 'block';
-        this.detailsContent.innerHTML = this.formatContent(data.result);
+        this.detailsContent.innerHTML = this.formatContent(data);
 
         // 滚动到详情区域
         this.resultDetails.scrollIntoView({
@@ -1105,7 +1164,7 @@ class RecipeAssistant {
         this.resultDetails.style.display = 'block';
 
         let detailsHTML = `
-            <h3>${recipe.title}</h3>
+            <h3>${this.escapeHTML(recipe.title)}</h3>
             <div class="recipe-details-meta">
                 ${recipe.readyInMinutes ? `<p><i class="bi bi-clock"></i> 制作时间：${recipe.readyInMinutes}分钟</p>` : ''}
                 ${recipe.servings ? `<p><i class="bi bi-people"></i> 分量：${recipe.servings}人份</p>` : ''}